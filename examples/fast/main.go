@@ -9,6 +9,7 @@ import (
 	"github.com/metalgrid/tr069-cel-mapper/pkg/mapper"
 	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
 	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/tr069keys"
 )
 
 type Host struct {
@@ -46,9 +47,14 @@ func main() {
 
 	fastMapper := mapper.NewFast(reg, mapper.WithFastStats())
 
-	setupHostRules(fastMapper)
-	setupWifiRules(fastMapper)
-	setupWANRules(fastMapper)
+	var rules []*mapper.FastRule
+	rules = append(rules, hostRules()...)
+	rules = append(rules, wifiRules()...)
+	rules = append(rules, wanRules()...)
+
+	if err := fastMapper.AddRules(rules); err != nil {
+		log.Fatalf("Failed to add rules: %v", err)
+	}
 
 	testData := [][2]string{
 		{"InternetGatewayDevice.LANDevice.1.Hosts.1.MACAddress", "AA:BB:CC:DD:EE:FF"},
@@ -66,10 +72,12 @@ func main() {
 		{"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.KeyPassphrase", "SecretPass123"},
 		{"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.Channel", "6"},
 		{"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.Enable", "true"},
+		{"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.OperatingFrequencyBand", "2.4 GHz"},
 
 		{"Device.WiFi.AccessPoint.2.SSID", "GuestNetwork"},
 		{"Device.WiFi.AccessPoint.2.Security.KeyPassphrase", "Guest2024"},
 		{"Device.WiFi.Radio.2.Channel", "149"},
+		{"Device.WiFi.Radio.2.OperatingFrequencyBand", "5G"},
 		{"Device.WiFi.AccessPoint.2.Enable", "false"},
 
 		{"InternetGatewayDevice.WANDevice.1.WANConnectionDevice.1.WANPPPConnection.1.Enable", "true"},
@@ -96,8 +104,8 @@ func main() {
 			fmt.Printf("Host[%s]: MAC=%s, IP=%s, Name=%s, Active=%v, Type=%s\n",
 				key, v.MACAddress, v.IPAddress, v.HostName, v.Active, v.InterfaceType)
 		case *Wifi:
-			fmt.Printf("Wifi[%s]: SSID=%s, Channel=%d, Enabled=%v\n",
-				key, v.SSID, v.Channel, v.Enabled)
+			fmt.Printf("Wifi[%s]: SSID=%s, Band=%s, Channel=%d, Enabled=%v\n",
+				key, v.SSID, v.Band, v.Channel, v.Enabled)
 		case *WANPPPConnection:
 			fmt.Printf("WAN[%s]: Status=%s, IP=%s, Uptime=%d\n",
 				key, v.ConnectionStatus, v.ExternalIP, v.Uptime)
@@ -114,7 +122,9 @@ func main() {
 	}
 }
 
-func setupHostRules(m *mapper.FastMapper) {
+func hostRules() []*mapper.FastRule {
+	var rules []*mapper.FastRule
+
 	hostPatterns := []struct {
 		path      string
 		field     string
@@ -132,50 +142,52 @@ func setupHostRules(m *mapper.FastMapper) {
 		{"Device.Hosts.Host.*.Active", "Active", "bool"},
 	}
 
+	hostKey := &extractor.ModelAwareExtractor{TR098Position: 4, TR181Position: 3, Prefix: "host:"}
+
 	for i, p := range hostPatterns {
 		pattern := router.CompilePattern(p.path)
 		pattern.Entity = "host"
 		pattern.Field = p.field
 
-		var ext extractor.KeyExtractor
-		if pattern.Parts != nil && len(pattern.Parts) > 4 {
-			ext = &extractor.IndexExtractor{Position: 4, Prefix: "host:"}
-		} else {
-			ext = &extractor.IndexExtractor{Position: 3, Prefix: "host:"}
-		}
-
-		m.AddRule(&mapper.FastRule{
+		rules = append(rules, &mapper.FastRule{
 			ID:        fmt.Sprintf("host_%d", i),
 			Pattern:   pattern,
 			Entity:    "host",
 			Field:     p.field,
 			Transform: p.transform,
-			Extractor: ext,
+			Extractor: hostKey,
 		})
 	}
+
+	return rules
 }
 
-func setupWifiRules(m *mapper.FastMapper) {
+func wifiRules() []*mapper.FastRule {
+	var rules []*mapper.FastRule
+
 	wifiPatterns := []struct {
 		path      string
 		field     string
-		band      string
+		key       string
 		transform string
 	}{
-		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.1.SSID", "SSID", "2.4GHz", ""},
-		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.1.KeyPassphrase", "Password", "2.4GHz", ""},
-		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.1.Channel", "Channel", "2.4GHz", "int"},
-		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.1.Enable", "Enabled", "2.4GHz", "bool"},
-
-		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.2.SSID", "SSID", "5GHz", ""},
-		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.2.KeyPassphrase", "Password", "5GHz", ""},
-		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.2.Channel", "Channel", "5GHz", "int"},
-		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.2.Enable", "Enabled", "5GHz", "bool"},
+		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.1.SSID", "SSID", "wlan:1", ""},
+		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.1.KeyPassphrase", "Password", "wlan:1", ""},
+		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.1.Channel", "Channel", "wlan:1", "int"},
+		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.1.Enable", "Enabled", "wlan:1", "bool"},
+		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.1.OperatingFrequencyBand", "Band", "wlan:1", "band_normalize"},
+
+		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.2.SSID", "SSID", "wlan:2", ""},
+		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.2.KeyPassphrase", "Password", "wlan:2", ""},
+		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.2.Channel", "Channel", "wlan:2", "int"},
+		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.2.Enable", "Enabled", "wlan:2", "bool"},
+		{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.2.OperatingFrequencyBand", "Band", "wlan:2", "band_normalize"},
 
 		{"Device.WiFi.AccessPoint.*.SSID", "SSID", "", ""},
 		{"Device.WiFi.AccessPoint.*.Security.KeyPassphrase", "Password", "", ""},
 		{"Device.WiFi.AccessPoint.*.Enable", "Enabled", "", "bool"},
 		{"Device.WiFi.Radio.*.Channel", "Channel", "", "int"},
+		{"Device.WiFi.Radio.*.OperatingFrequencyBand", "Band", "", "band_normalize"},
 	}
 
 	for i, p := range wifiPatterns {
@@ -184,15 +196,15 @@ func setupWifiRules(m *mapper.FastMapper) {
 		pattern.Field = p.field
 
 		var ext extractor.KeyExtractor
-		if p.band != "" {
-			ext = &extractor.StaticExtractor{Value: p.band}
+		if p.key != "" {
+			ext = &extractor.StaticExtractor{Value: p.key}
 		} else if pattern.Parts != nil && len(pattern.Parts) > 3 {
 			ext = &extractor.IndexExtractor{Position: 3, Prefix: "wifi:"}
 		} else {
 			ext = &extractor.StaticExtractor{Value: "default"}
 		}
 
-		m.AddRule(&mapper.FastRule{
+		rules = append(rules, &mapper.FastRule{
 			ID:        fmt.Sprintf("wifi_%d", i),
 			Pattern:   pattern,
 			Entity:    "wifi",
@@ -200,21 +212,14 @@ func setupWifiRules(m *mapper.FastMapper) {
 			Transform: p.transform,
 			Extractor: ext,
 		})
-
-		if p.band != "" {
-			m.AddRule(&mapper.FastRule{
-				ID:        fmt.Sprintf("wifi_band_%d", i),
-				Pattern:   pattern,
-				Entity:    "wifi",
-				Field:     "Band",
-				Transform: "",
-				Extractor: ext,
-			})
-		}
 	}
+
+	return rules
 }
 
-func setupWANRules(m *mapper.FastMapper) {
+func wanRules() []*mapper.FastRule {
+	var rules []*mapper.FastRule
+
 	wanPatterns := []struct {
 		path      string
 		field     string
@@ -235,15 +240,15 @@ func setupWANRules(m *mapper.FastMapper) {
 		pattern.Entity = "wanppp"
 		pattern.Field = p.field
 
-		ext := &extractor.IndexExtractor{Position: 5, Prefix: "wan:"}
-
-		m.AddRule(&mapper.FastRule{
+		rules = append(rules, &mapper.FastRule{
 			ID:        fmt.Sprintf("wan_%d", i),
 			Pattern:   pattern,
 			Entity:    "wanppp",
 			Field:     p.field,
 			Transform: p.transform,
-			Extractor: ext,
+			Extractor: &tr069keys.WANExtractor{Prefix: "wan:"},
 		})
 	}
+
+	return rules
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/metalgrid/tr069-cel-mapper/pkg/loader"
 	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/transform"
 	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
 	"github.com/google/cel-go/cel"
 	"github.com/google/cel-go/ext"
@@ -23,7 +24,7 @@ func New(reg *registry.Registry) *Builder {
 	return &Builder{
 		registry:  reg,
 		variables: make(map[string]*cel.Type),
-		functions: []cel.EnvOption{ext.Strings()},
+		functions: append([]cel.EnvOption{ext.Strings()}, tr069Functions()...),
 	}
 }
 
@@ -122,16 +123,56 @@ func (b *Builder) buildRule(env *cel.Env, config *types.RuleConfig) (*types.Comp
 		fields = append(fields, *field)
 	}
 
+	derived, err := b.buildDerived(env, config.Derived, typeInfo)
+	if err != nil {
+		return nil, err
+	}
+
 	return &types.CompiledRule{
 		Name:      config.Name,
 		Target:    config.Target,
 		Route:     routeProg,
 		EntityKey: keyProg,
 		Fields:    fields,
+		Derived:   derived,
 		Factory:   typeInfo.Factory,
+		Metadata:  config.Metadata,
+		Source:    *config,
 	}, nil
 }
 
+func (b *Builder) buildDerived(env *cel.Env, configs []types.DerivedField, typeInfo *registry.TypeInfo) ([]types.CompiledDerivedField, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+
+	derivedEnv, err := env.Extend(cel.Variable("entity", cel.MapType(cel.StringType, cel.DynType)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create derived field environment: %w", err)
+	}
+
+	derived := make([]types.CompiledDerivedField, 0, len(configs))
+	for _, config := range configs {
+		valueProg, err := b.compileExpression(derivedEnv, config.Value, fmt.Sprintf("derived[%s].value", config.Name))
+		if err != nil {
+			return nil, err
+		}
+
+		setter, ok := typeInfo.Setters[config.Name]
+		if !ok {
+			return nil, fmt.Errorf("derived field %s not found in type %s", config.Name, typeInfo.Type.Name())
+		}
+
+		derived = append(derived, types.CompiledDerivedField{
+			Name:   config.Name,
+			Value:  valueProg,
+			Setter: setter,
+		})
+	}
+
+	return derived, nil
+}
+
 func (b *Builder) buildField(env *cel.Env, config *types.FieldMapping, typeInfo *registry.TypeInfo) (*types.CompiledFieldRule, error) {
 	whenProg, err := b.compileExpression(env, config.When, fmt.Sprintf("field[%s].when", config.Name))
 	if err != nil {
@@ -148,11 +189,18 @@ func (b *Builder) buildField(env *cel.Env, config *types.FieldMapping, typeInfo
 		return nil, fmt.Errorf("field %s not found in type %s", config.Name, typeInfo.Type.Name())
 	}
 
+	for _, name := range config.Transforms {
+		if _, err := transform.Compile(name); err != nil {
+			return nil, err
+		}
+	}
+
 	return &types.CompiledFieldRule{
-		Name:   config.Name,
-		When:   whenProg,
-		Value:  valueProg,
-		Setter: setter,
+		Name:       config.Name,
+		When:       whenProg,
+		Value:      valueProg,
+		Setter:     setter,
+		Transforms: config.Transforms,
 	}, nil
 }
 
@@ -0,0 +1,72 @@
+package builder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+)
+
+type testHost struct {
+	MACAddress string
+	HostName   string
+}
+
+func newTestBuilder() *Builder {
+	reg := registry.New()
+	reg.MustRegister("Host", func() any { return &testHost{} })
+	return New(reg).WithStandardVariables()
+}
+
+func hostRules(transforms string) string {
+	return `
+version: "1.0"
+rules:
+  - name: hosts
+    target: Host
+    route: 'path.startsWith("Device.Hosts.Host.")'
+    entity_key: 'segments(path)[3]'
+    fields:
+      - name: MACAddress
+        when: 'path.endsWith(".PhysAddress")'
+        value: 'value'
+        transforms: ` + transforms + `
+      - name: HostName
+        when: 'path.endsWith(".HostName")'
+        value: 'value'
+`
+}
+
+func TestBuildTransforms(t *testing.T) {
+	rules, err := newTestBuilder().BuildFromString(hostRules("[trim, mac_normalize]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := rules[0].Fields
+	if got := strings.Join(fields[0].Transforms, ","); got != "trim,mac_normalize" {
+		t.Errorf("MACAddress transforms = %q, want trim,mac_normalize in order", got)
+	}
+	if fields[1].Transforms != nil {
+		t.Errorf("HostName transforms = %v, want none", fields[1].Transforms)
+	}
+}
+
+func TestBuildTransformsRejectsUnknown(t *testing.T) {
+	tests := []struct {
+		name       string
+		transforms string
+		errMsg     string
+	}{
+		{"unknown", "[trim, mac_normalise]", "mac_normalise"},
+		{"bad params", `["float:scale=x"]`, `invalid scale "x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestBuilder().BuildFromString(hostRules(tt.transforms))
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) || !strings.Contains(err.Error(), "failed to build rule hosts") {
+				t.Errorf("BuildFromString error = %v, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
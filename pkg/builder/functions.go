@@ -0,0 +1,99 @@
+package builder
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	celtypes "github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/transform"
+)
+
+func tr069Functions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Function("instanceIndexInt",
+			cel.Overload("instanceIndexInt_string",
+				[]*cel.Type{cel.StringType}, cel.IntType,
+				cel.UnaryBinding(func(path ref.Val) ref.Val {
+					return celtypes.Int(lastInstanceIndex(string(path.(celtypes.String))))
+				})),
+			cel.Overload("instanceIndexInt_string_string",
+				[]*cel.Type{cel.StringType, cel.StringType}, cel.IntType,
+				cel.BinaryBinding(func(path, collection ref.Val) ref.Val {
+					return celtypes.Int(instanceIndexAfter(
+						string(path.(celtypes.String)), string(collection.(celtypes.String))))
+				})),
+		),
+		cel.Function("segments",
+			cel.Overload("segments_string",
+				[]*cel.Type{cel.StringType}, cel.ListType(cel.StringType),
+				cel.UnaryBinding(func(path ref.Val) ref.Val {
+					return celtypes.NewStringList(celtypes.DefaultTypeAdapter,
+						extractor.SplitPath(string(path.(celtypes.String))))
+				})),
+		),
+		cel.Function("toInt",
+			cel.Overload("toInt_string", []*cel.Type{cel.StringType}, cel.IntType,
+				cel.UnaryBinding(convertBinding("toInt", transform.ToInt)))),
+		cel.Function("toFloat",
+			cel.Overload("toFloat_string", []*cel.Type{cel.StringType}, cel.DoubleType,
+				cel.UnaryBinding(convertBinding("toFloat", transform.ToFloat))),
+			cel.Overload("toFloat_string_string", []*cel.Type{cel.StringType, cel.StringType}, cel.DoubleType,
+				cel.BinaryBinding(func(value, params ref.Val) ref.Val {
+					fn, err := transform.Compile("float:" + string(params.(celtypes.String)))
+					if err != nil {
+						return celtypes.NewErr("toFloat: %v", err)
+					}
+					return convertBinding("toFloat", fn)(value)
+				}))),
+		cel.Function("toBool",
+			cel.Overload("toBool_string", []*cel.Type{cel.StringType}, cel.BoolType,
+				cel.UnaryBinding(convertBinding("toBool", transform.ToBool)))),
+	}
+}
+
+func convertBinding(name string, fn transform.Transformer) func(ref.Val) ref.Val {
+	return func(value ref.Val) ref.Val {
+		result, err := fn(string(value.(celtypes.String)))
+		if err != nil {
+			return celtypes.NewErr("%s: %v", name, err)
+		}
+		return celtypes.DefaultTypeAdapter.NativeToValue(result)
+	}
+}
+
+func lastInstanceIndex(path string) int64 {
+	parts := strings.Split(path, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if idx, ok := parseInstance(parts[i]); ok {
+			return idx
+		}
+	}
+	return -1
+}
+
+func instanceIndexAfter(path, collection string) int64 {
+	parts := strings.Split(path, ".")
+	for i := 0; i < len(parts)-1; i++ {
+		if parts[i] == collection {
+			if idx, ok := parseInstance(parts[i+1]); ok {
+				return idx
+			}
+		}
+	}
+	return -1
+}
+
+func parseInstance(part string) (int64, bool) {
+	if part == "" || part[0] < '0' || part[0] > '9' {
+		return 0, false
+	}
+	idx, err := strconv.ParseInt(part, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return idx, true
+}
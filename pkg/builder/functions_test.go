@@ -0,0 +1,158 @@
+package builder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+)
+
+func evalExpr(t *testing.T, expr, path, value string) (any, error) {
+	t.Helper()
+	env, err := cel.NewEnv(append([]cel.EnvOption{
+		cel.Variable("path", cel.StringType),
+		cel.Variable("value", cel.StringType),
+	}, tr069Functions()...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast, issues := env.Compile(expr)
+	if issues.Err() != nil {
+		t.Fatalf("compile %q: %v", expr, issues.Err())
+	}
+	prog, err := env.Program(ast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _, err := prog.Eval(map[string]any{"path": path, "value": value})
+	if err != nil {
+		return nil, err
+	}
+	return out.Value(), nil
+}
+
+func TestInstanceIndexInt(t *testing.T) {
+	tests := []struct {
+		expr string
+		path string
+		want any
+	}{
+		{"instanceIndexInt(path)", "Device.WiFi.Radio.2.Channel", int64(2)},
+		{"instanceIndexInt(path)", "Device.Hosts.Host.3.IPv4Address.7.IPAddress", int64(7)},
+		{"instanceIndexInt(path)", "Device.LANDevice.1.WLANConfiguration.12.", int64(12)},
+		{"instanceIndexInt(path)", "Device.DeviceInfo.SerialNumber", int64(-1)},
+		{"instanceIndexInt(path)", "", int64(-1)},
+		{"instanceIndexInt(path)", "Device.Host.-3.Name", int64(-1)},
+		{"instanceIndexInt(path)", "Device.Host.99999999999999999999.Name", int64(-1)},
+		{`instanceIndexInt(path, "Host")`, "Device.Hosts.Host.3.IPv4Address.7.IPAddress", int64(3)},
+		{`instanceIndexInt(path, "IPv4Address")`, "Device.Hosts.Host.3.IPv4Address.7.IPAddress", int64(7)},
+		{`instanceIndexInt(path, "Radio")`, "Device.Hosts.Host.3.IPAddress", int64(-1)},
+		{`instanceIndexInt(path, "Host")`, "Device.Hosts.Host.Count", int64(-1)},
+		{`instanceIndexInt(path, "IPAddress")`, "Device.Hosts.Host.3.IPAddress", int64(-1)},
+		{"instanceIndexInt(path) <= 2", "Device.LANDevice.1.WLANConfiguration.2.SSID", true},
+		{"instanceIndexInt(path) <= 2", "Device.LANDevice.1.WLANConfiguration.5.SSID", false},
+		{"instanceIndexInt(path) > 0", "Device.DeviceInfo.SerialNumber", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr+"/"+tt.path, func(t *testing.T) {
+			got, err := evalExpr(t, tt.expr, tt.path, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("%s = %v (%T), want %v", tt.expr, got, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSegments(t *testing.T) {
+	tests := []struct {
+		expr string
+		path string
+		want any
+	}{
+		{"size(segments(path))", "Device.Hosts.Host.3.IPAddress", int64(5)},
+		{`segments(path)[2] + ":" + segments(path)[3]`, "Device.Hosts.Host.3.IPAddress", "Host:3"},
+		{`segments(path)[3] + "/" + segments(path)[5]`, "Device.Hosts.Host.3.IPv4Address.7.IPAddress", "3/7"},
+		{"size(segments(path))", "Device.WiFi.Radio.1.", int64(4)},
+		{"size(segments(path))", "", int64(0)},
+		{`segments(path)[1]`, `Device.X_Vendor\.Com.Enabled`, `X_Vendor\.Com`},
+		{`"Hosts" in segments(path)`, "Device.Hosts.Host.3.IPAddress", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr+"/"+tt.path, func(t *testing.T) {
+			got, err := evalExpr(t, tt.expr, tt.path, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := evalExpr(t, "segments(path)[9]", "Device.Hosts.Host.3.IPAddress", ""); err == nil {
+		t.Error("out of range segment did not fail")
+	}
+}
+
+func TestConverters(t *testing.T) {
+	tests := []struct {
+		expr  string
+		value string
+		want  any
+	}{
+		{"toInt(value)", "42", int64(42)},
+		{"toInt(value)", " 1,234 ", int64(1234)},
+		{"toInt(value)", "3.9", int64(3)},
+		{"toInt(value)", "", int64(0)},
+		{"toInt(value) + 1", "41", int64(42)},
+		{"toFloat(value)", "1.5", 1.5},
+		{"toFloat(value)", "87%", 87.0},
+		{"toFloat(value)", "", 0.0},
+		{`toFloat(value, "scale=0.001")`, "2500", 2.5},
+		{`toFloat(value, "scale=0.1:round=1")`, "123", 12.3},
+		{"toBool(value)", "Enabled", true},
+		{"toBool(value)", "0", false},
+		{"toBool(value) && true", "on", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr+"/"+tt.value, func(t *testing.T) {
+			got, err := evalExpr(t, tt.expr, "", tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("%s = %v (%T), want %v (%T)", tt.expr, got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverterErrors(t *testing.T) {
+	tests := []struct {
+		expr   string
+		value  string
+		errMsg string
+	}{
+		{"toInt(value)", "auto", "toInt: "},
+		{"toFloat(value)", "n/a", "toFloat: "},
+		{"toBool(value)", "maybe", "toBool: "},
+		{`toFloat(value, "scale=x")`, "1", `invalid scale "x"`},
+		{`toFloat(value, "unit=ms")`, "1", `unknown parameter "unit"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr+"/"+tt.value, func(t *testing.T) {
+			_, err := evalExpr(t, tt.expr, "", tt.value)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("%s error = %v, want it to contain %q", tt.expr, err, tt.errMsg)
+			}
+		})
+	}
+
+	got, err := evalExpr(t, `value == "auto" || toInt(value) > 0`, "", "auto")
+	if err != nil || got != true {
+		t.Errorf("short-circuit = %v, %v, want true without a conversion error", got, err)
+	}
+}
@@ -0,0 +1,17 @@
+package cwmp
+
+import "context"
+
+type deviceIDKey struct{}
+
+func WithDeviceID(ctx context.Context, id DeviceID) context.Context {
+	return context.WithValue(ctx, deviceIDKey{}, id)
+}
+
+func DeviceIDFromContext(ctx context.Context) (DeviceID, bool) {
+	if ctx == nil {
+		return DeviceID{}, false
+	}
+	id, ok := ctx.Value(deviceIDKey{}).(DeviceID)
+	return id, ok
+}
@@ -0,0 +1,18 @@
+package cwmp
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDeviceIDContext(t *testing.T) {
+	if _, ok := DeviceIDFromContext(context.Background()); ok {
+		t.Error("background context reported a device ID")
+	}
+
+	want := DeviceID{OUI: "00259E", ProductClass: "HG8245", SerialNumber: "485754430A1B2C3D"}
+	got, ok := DeviceIDFromContext(WithDeviceID(context.Background(), want))
+	if !ok || got != want {
+		t.Errorf("DeviceIDFromContext = %+v, %v; want %+v", got, ok, want)
+	}
+}
@@ -0,0 +1,103 @@
+package cwmp
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type DeviceID struct {
+	Manufacturer string `xml:"Manufacturer"`
+	OUI          string `xml:"OUI"`
+	ProductClass string `xml:"ProductClass"`
+	SerialNumber string `xml:"SerialNumber"`
+}
+
+type Event struct {
+	Code       string `xml:"EventCode"`
+	CommandKey string `xml:"CommandKey"`
+}
+
+type Inform struct {
+	DeviceID     DeviceID
+	Events       []Event
+	MaxEnvelopes int
+	CurrentTime  string
+	RetryCount   int
+	Parameters   [][2]string
+}
+
+func (i *Inform) HasEvent(code string) bool {
+	for _, e := range i.Events {
+		if strings.EqualFold(e.Code, code) {
+			return true
+		}
+	}
+	return false
+}
+
+type envelope struct {
+	Body struct {
+		Inform *informXML `xml:"Inform"`
+	} `xml:"Body"`
+}
+
+type informXML struct {
+	DeviceID     DeviceID `xml:"DeviceId"`
+	Events       []Event  `xml:"Event>EventStruct"`
+	MaxEnvelopes int      `xml:"MaxEnvelopes"`
+	CurrentTime  string   `xml:"CurrentTime"`
+	RetryCount   int      `xml:"RetryCount"`
+	Parameters   []struct {
+		Name  string `xml:"Name"`
+		Value string `xml:"Value"`
+	} `xml:"ParameterList>ParameterValueStruct"`
+}
+
+func ParseInform(r io.Reader) ([][2]string, DeviceID, error) {
+	inform, err := ParseInformMessage(r)
+	if err != nil {
+		return nil, DeviceID{}, err
+	}
+	return inform.Parameters, inform.DeviceID, nil
+}
+
+func ParseInformMessage(r io.Reader) (*Inform, error) {
+	var env envelope
+	if err := xml.NewDecoder(r).Decode(&env); err != nil {
+		return nil, fmt.Errorf("failed to decode inform: %w", err)
+	}
+	if env.Body.Inform == nil {
+		return nil, fmt.Errorf("envelope does not contain an Inform")
+	}
+
+	in := env.Body.Inform
+	inform := &Inform{
+		DeviceID:     trimDeviceID(in.DeviceID),
+		Events:       make([]Event, 0, len(in.Events)),
+		MaxEnvelopes: in.MaxEnvelopes,
+		CurrentTime:  strings.TrimSpace(in.CurrentTime),
+		RetryCount:   in.RetryCount,
+		Parameters:   make([][2]string, 0, len(in.Parameters)),
+	}
+	for _, e := range in.Events {
+		inform.Events = append(inform.Events, Event{
+			Code:       strings.TrimSpace(e.Code),
+			CommandKey: strings.TrimSpace(e.CommandKey),
+		})
+	}
+	for _, p := range in.Parameters {
+		inform.Parameters = append(inform.Parameters, [2]string{strings.TrimSpace(p.Name), p.Value})
+	}
+	return inform, nil
+}
+
+func trimDeviceID(id DeviceID) DeviceID {
+	return DeviceID{
+		Manufacturer: strings.TrimSpace(id.Manufacturer),
+		OUI:          strings.TrimSpace(id.OUI),
+		ProductClass: strings.TrimSpace(id.ProductClass),
+		SerialNumber: strings.TrimSpace(id.SerialNumber),
+	}
+}
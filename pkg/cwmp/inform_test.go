@@ -0,0 +1,93 @@
+package cwmp
+
+import (
+	"strings"
+	"testing"
+)
+
+const informEnvelope = `<?xml version="1.0" encoding="UTF-8"?>
+<soap-env:Envelope xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"
+    xmlns:soap-enc="http://schemas.xmlsoap.org/soap/encoding/"
+    xmlns:xsd="http://www.w3.org/2001/XMLSchema"
+    xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+    xmlns:cwmp="urn:dslforum-org:cwmp-1-0">
+  <soap-env:Header>
+    <cwmp:ID soap-env:mustUnderstand="1">1</cwmp:ID>
+  </soap-env:Header>
+  <soap-env:Body>
+    <cwmp:Inform>
+      <DeviceId>
+        <Manufacturer>Acme</Manufacturer>
+        <OUI>00D09E</OUI>
+        <ProductClass>HGW</ProductClass>
+        <SerialNumber>SN123456</SerialNumber>
+      </DeviceId>
+      <Event soap-enc:arrayType="cwmp:EventStruct[2]">
+        <EventStruct>
+          <EventCode>0 BOOTSTRAP</EventCode>
+          <CommandKey></CommandKey>
+        </EventStruct>
+        <EventStruct>
+          <EventCode>2 PERIODIC</EventCode>
+          <CommandKey/>
+        </EventStruct>
+      </Event>
+      <MaxEnvelopes>1</MaxEnvelopes>
+      <CurrentTime>2024-01-02T03:04:05Z</CurrentTime>
+      <RetryCount>0</RetryCount>
+      <ParameterList soap-enc:arrayType="cwmp:ParameterValueStruct[2]">
+        <ParameterValueStruct>
+          <Name>InternetGatewayDevice.DeviceInfo.SoftwareVersion</Name>
+          <Value xsi:type="xsd:string">1.2.3</Value>
+        </ParameterValueStruct>
+        <ParameterValueStruct>
+          <Name>InternetGatewayDevice.ManagementServer.ConnectionRequestURL</Name>
+          <Value xsi:type="xsd:string">http://10.0.0.1:7547/</Value>
+        </ParameterValueStruct>
+      </ParameterList>
+    </cwmp:Inform>
+  </soap-env:Body>
+</soap-env:Envelope>`
+
+func TestParseInform(t *testing.T) {
+	params, id, err := ParseInform(strings.NewReader(informEnvelope))
+	if err != nil {
+		t.Fatalf("ParseInform: %v", err)
+	}
+
+	want := DeviceID{Manufacturer: "Acme", OUI: "00D09E", ProductClass: "HGW", SerialNumber: "SN123456"}
+	if id != want {
+		t.Errorf("DeviceID = %+v, want %+v", id, want)
+	}
+
+	if len(params) != 2 {
+		t.Fatalf("got %d parameters, want 2", len(params))
+	}
+	if params[0] != [2]string{"InternetGatewayDevice.DeviceInfo.SoftwareVersion", "1.2.3"} {
+		t.Errorf("params[0] = %q", params[0])
+	}
+}
+
+func TestParseInformMessageEvents(t *testing.T) {
+	inform, err := ParseInformMessage(strings.NewReader(informEnvelope))
+	if err != nil {
+		t.Fatalf("ParseInformMessage: %v", err)
+	}
+
+	if len(inform.Events) != 2 || inform.Events[0].Code != "0 BOOTSTRAP" {
+		t.Fatalf("Events = %+v", inform.Events)
+	}
+	if !inform.HasEvent("2 periodic") {
+		t.Error("HasEvent(2 PERIODIC) = false")
+	}
+	if inform.CurrentTime != "2024-01-02T03:04:05Z" {
+		t.Errorf("CurrentTime = %q", inform.CurrentTime)
+	}
+}
+
+func TestParseInformRejectsOtherMessages(t *testing.T) {
+	body := `<Envelope><Body><GetParameterValuesResponse/></Body></Envelope>`
+	if _, _, err := ParseInform(strings.NewReader(body)); err == nil {
+		t.Fatal("expected error for envelope without Inform")
+	}
+}
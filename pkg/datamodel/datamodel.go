@@ -0,0 +1,151 @@
+package datamodel
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/tr069keys"
+)
+
+type DataModel struct {
+	Name       string
+	parameters map[string][][]string
+	count      int
+}
+
+func ParseFile(filename string) (*DataModel, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data model %s: %w", filename, err)
+	}
+	defer file.Close()
+
+	return Parse(file)
+}
+
+func Parse(r io.Reader) (*DataModel, error) {
+	dm := &DataModel{
+		parameters: make(map[string][][]string),
+	}
+
+	decoder := xml.NewDecoder(r)
+	inModel := false
+	object := ""
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode data model: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "model":
+				inModel = true
+				if dm.Name == "" {
+					dm.Name = attr(t, "name")
+				}
+			case "object":
+				if inModel {
+					object = nameOrBase(t)
+				}
+			case "parameter":
+				if inModel && object != "" {
+					if name := nameOrBase(t); name != "" {
+						dm.add(object + name)
+					}
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "model":
+				inModel = false
+			case "object":
+				object = ""
+			}
+		}
+	}
+
+	if dm.count == 0 {
+		return nil, fmt.Errorf("data model contains no parameters")
+	}
+
+	return dm, nil
+}
+
+func (dm *DataModel) add(path string) {
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		if part == "{i}" {
+			parts[i] = "*"
+		}
+	}
+	leaf := parts[len(parts)-1]
+	dm.parameters[leaf] = append(dm.parameters[leaf], parts)
+	dm.count++
+}
+
+func (dm *DataModel) HasLeaf(name string) bool {
+	_, ok := dm.parameters[name]
+	return ok
+}
+
+func (dm *DataModel) HasParameter(path string) bool {
+	parts := strings.Split(path, ".")
+	for _, candidate := range dm.parameters[parts[len(parts)-1]] {
+		if matchSegments(parts, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func (dm *DataModel) Parameters() []string {
+	paths := make([]string, 0, dm.count)
+	for _, candidates := range dm.parameters {
+		for _, parts := range candidates {
+			paths = append(paths, strings.Join(parts, "."))
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func matchSegments(path, model []string) bool {
+	if len(path) != len(model) {
+		return false
+	}
+	for i, part := range path {
+		switch {
+		case part == "*", part == model[i]:
+		case model[i] == "*" && tr069keys.IsInstance(part):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func nameOrBase(el xml.StartElement) string {
+	if name := attr(el, "name"); name != "" {
+		return name
+	}
+	return attr(el, "base")
+}
@@ -0,0 +1,107 @@
+package datamodel
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func loadDevice(t *testing.T) *DataModel {
+	t.Helper()
+	dm, err := ParseFile("testdata/device.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dm
+}
+
+func TestParse(t *testing.T) {
+	dm := loadDevice(t)
+
+	if dm.Name != "Device:2.15" {
+		t.Errorf("Name = %q, want Device:2.15", dm.Name)
+	}
+	want := []string{
+		"Device.Hosts.Host.*.Active",
+		"Device.Hosts.Host.*.HostName",
+		"Device.Hosts.Host.*.IPAddress",
+		"Device.Hosts.Host.*.IPv4Address.*.IPAddress",
+		"Device.Hosts.Host.*.PhysAddress",
+		"Device.RootDataModelVersion",
+		"Device.WiFi.Radio.*.Channel",
+	}
+	if got := dm.Parameters(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Parameters() = %v, want %v", got, want)
+	}
+}
+
+func TestHasLeaf(t *testing.T) {
+	dm := loadDevice(t)
+
+	for leaf, want := range map[string]bool{
+		"IPAddress":            true,
+		"Channel":              true,
+		"RootDataModelVersion": true,
+		"Outside":              false,
+		"HostNme":              false,
+		"":                     false,
+	} {
+		if got := dm.HasLeaf(leaf); got != want {
+			t.Errorf("HasLeaf(%q) = %v, want %v", leaf, got, want)
+		}
+	}
+}
+
+func TestHasParameter(t *testing.T) {
+	dm := loadDevice(t)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"Device.RootDataModelVersion", true},
+		{"Device.Hosts.Host.1.HostName", true},
+		{"Device.Hosts.Host.*.HostName", true},
+		{"Device.Hosts.Host.12.IPv4Address.3.IPAddress", true},
+		{"Device.Hosts.Host.*.IPv4Address.*.IPAddress", true},
+		{"Device.WiFi.Radio.2.Channel", true},
+		{"Device.*.Host.1.HostName", true},
+		{"Device.Hosts.Host.abc.HostName", false},
+		{"Device.Hosts.Host..HostName", false},
+		{"Device.Hosts.HostName", false},
+		{"Device.Hosts.Host.1.2.HostName", false},
+		{"Device.Hosts.Host.1.Channel", false},
+		{"Device.Hosts.Host.1.Missing", false},
+		{"Shared.Outside", false},
+	}
+	for _, tt := range tests {
+		if got := dm.HasParameter(tt.path); got != tt.want {
+			t.Errorf("HasParameter(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		errMsg string
+	}{
+		{"no model", `<document><component name="C"><object name="C."><parameter name="P"/></object></component></document>`, "contains no parameters"},
+		{"empty model", `<document><model name="Device:2.15"><object name="Device."/></model></document>`, "contains no parameters"},
+		{"malformed", `<document><model name="Device:2.15"><object name="Device.">`, "failed to decode data model"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Parse error = %v, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+
+	if _, err := ParseFile("testdata/missing.xml"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ParseFile error = %v, want ErrNotExist", err)
+	}
+}
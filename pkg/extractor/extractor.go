@@ -1,30 +1,190 @@
 package extractor
 
 import (
+	"context"
+	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"unsafe"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/cwmp"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/transform"
 )
 
 type KeyExtractor interface {
 	Extract(path, value string) string
 }
 
+type CaptureExtractor interface {
+	KeyExtractor
+	ExtractCaptures(captures []string, path, value string) string
+}
+
+type PartsExtractor interface {
+	KeyExtractor
+	ExtractParts(parts []string, path, value string) string
+}
+
+type ContextExtractor interface {
+	KeyExtractor
+	ExtractContext(ctx context.Context, parts []string, path, value string) string
+}
+
+type ContextCaptureExtractor interface {
+	ContextExtractor
+	ExtractContextCaptures(ctx context.Context, captures, parts []string, path, value string) string
+}
+
+func UsesCaptures(e KeyExtractor) bool {
+	switch e := e.(type) {
+	case *TransformExtractor:
+		return UsesCaptures(e.Inner)
+	case *DeviceExtractor:
+		return UsesCaptures(e.Inner)
+	case CaptureExtractor:
+		return true
+	}
+	return false
+}
+
 type IndexExtractor struct {
 	Position int
 	Prefix   string
+	Sep      string
 }
 
 func (e *IndexExtractor) Extract(path, value string) string {
-	parts := splitPathCached(path)
+	return e.ExtractParts(splitPathCached(path), path, value)
+}
+
+func (e *IndexExtractor) ExtractParts(parts []string, path, value string) string {
 	if e.Position < 0 || e.Position >= len(parts) {
 		return ""
 	}
-	if e.Prefix != "" {
-		return e.Prefix + parts[e.Position]
+	return joinPrefix(e.Prefix, e.Sep, parts[e.Position])
+}
+
+type PaddedIndexExtractor struct {
+	Position int
+	Width    int
+	Prefix   string
+	Sep      string
+}
+
+func (e *PaddedIndexExtractor) Extract(path, value string) string {
+	return e.ExtractParts(splitPathCached(path), path, value)
+}
+
+func (e *PaddedIndexExtractor) ExtractParts(parts []string, path, value string) string {
+	if e.Position < 0 || e.Position >= len(parts) {
+		return ""
+	}
+	return joinPrefix(e.Prefix, e.Sep, padIndex(parts[e.Position], e.Width))
+}
+
+func joinPrefix(prefix, sep, segment string) string {
+	if segment == "" {
+		return ""
 	}
-	return parts[e.Position]
+	if prefix == "" {
+		return segment
+	}
+	return prefix + sep + segment
+}
+
+func padIndex(segment string, width int) string {
+	if segment == "" || len(segment) >= width {
+		return segment
+	}
+	for i := 0; i < len(segment); i++ {
+		if segment[i] < '0' || segment[i] > '9' {
+			return segment
+		}
+	}
+	return strings.Repeat("0", width-len(segment)) + segment
+}
+
+type ModelAwareExtractor struct {
+	TR098Position int
+	TR181Position int
+	Prefix        string
+	Sep           string
+}
+
+func (e *ModelAwareExtractor) Extract(path, value string) string {
+	return e.ExtractParts(splitPathCached(path), path, value)
+}
+
+func (e *ModelAwareExtractor) ExtractParts(parts []string, path, value string) string {
+	var position int
+	switch {
+	case strings.HasPrefix(path, "InternetGatewayDevice."):
+		position = e.TR098Position
+	case strings.HasPrefix(path, "Device."):
+		position = e.TR181Position
+	default:
+		return ""
+	}
+
+	if position < 0 || position >= len(parts) {
+		return ""
+	}
+	return joinPrefix(e.Prefix, e.Sep, parts[position])
+}
+
+type WildcardExtractor struct {
+	Index   int
+	Prefix  string
+	Sep     string
+	Pattern *router.Pattern
+}
+
+func (e *WildcardExtractor) Extract(path, value string) string {
+	if e.Pattern == nil {
+		return ""
+	}
+	return e.ExtractCaptures(router.Captures(path, e.Pattern), path, value)
+}
+
+func (e *WildcardExtractor) ExtractCaptures(captures []string, path, value string) string {
+	if e.Index < 0 || e.Index >= len(captures) {
+		return ""
+	}
+	return joinPrefix(e.Prefix, e.Sep, captures[e.Index])
+}
+
+type TransformExtractor struct {
+	Inner     KeyExtractor
+	Transform string
+}
+
+func (e *TransformExtractor) Extract(path, value string) string {
+	return e.apply(e.Inner.Extract(path, value))
+}
+
+func (e *TransformExtractor) ExtractCaptures(captures []string, path, value string) string {
+	if ce, ok := e.Inner.(CaptureExtractor); ok {
+		return e.apply(ce.ExtractCaptures(captures, path, value))
+	}
+	return e.Extract(path, value)
+}
+
+func (e *TransformExtractor) ExtractParts(parts []string, path, value string) string {
+	return e.apply(ExtractParts(e.Inner, parts, path, value))
+}
+
+func (e *TransformExtractor) apply(key string) string {
+	result, err := transform.Apply(e.Transform, key)
+	if err != nil {
+		return key
+	}
+	if s, ok := result.(string); ok {
+		return s
+	}
+	return fmt.Sprint(result)
 }
 
 type ValueExtractor struct{}
@@ -33,17 +193,57 @@ func (e *ValueExtractor) Extract(path, value string) string {
 	return value
 }
 
+type ValueTokenExtractor struct {
+	Position int
+	Sep      string
+	Prefix   string
+}
+
+func (e *ValueTokenExtractor) Extract(path, value string) string {
+	var tokens []string
+	if e.Sep == "" {
+		tokens = strings.FieldsFunc(value, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+	} else {
+		tokens = strings.Split(value, e.Sep)
+	}
+	if e.Position < 0 || e.Position >= len(tokens) {
+		return ""
+	}
+	return e.Prefix + strings.TrimSpace(tokens[e.Position])
+}
+
+func parseValueToken(spec string) (*ValueTokenExtractor, bool, error) {
+	if !strings.HasPrefix(spec, "value[") {
+		return nil, false, nil
+	}
+	if !strings.HasSuffix(spec, "]") {
+		return nil, true, fmt.Errorf("malformed extractor spec %q: missing closing bracket", spec)
+	}
+	idx, sep, _ := strings.Cut(spec[6:len(spec)-1], ",")
+	pos, err := strconv.Atoi(idx)
+	if err != nil || pos < 0 {
+		return nil, true, fmt.Errorf("malformed extractor spec %q: index must be a non-negative integer", spec)
+	}
+	return &ValueTokenExtractor{Position: pos, Sep: sep}, true, nil
+}
+
 type CompositeExtractor struct {
 	Parts []KeyExtractor
 	Sep   string
 }
 
 func (e *CompositeExtractor) Extract(path, value string) string {
+	return e.ExtractParts(nil, path, value)
+}
+
+func (e *CompositeExtractor) ExtractParts(parts []string, path, value string) string {
 	if len(e.Parts) == 0 {
 		return ""
 	}
 	if len(e.Parts) == 1 {
-		return e.Parts[0].Extract(path, value)
+		return ExtractParts(e.Parts[0], parts, path, value)
 	}
 
 	sb := getStringBuilder()
@@ -53,11 +253,53 @@ func (e *CompositeExtractor) Extract(path, value string) string {
 		if i > 0 && e.Sep != "" {
 			sb.WriteString(e.Sep)
 		}
-		sb.WriteString(part.Extract(path, value))
+		sb.WriteString(ExtractParts(part, parts, path, value))
 	}
 	return sb.String()
 }
 
+func ExtractParts(e KeyExtractor, parts []string, path, value string) string {
+	if pe, ok := e.(PartsExtractor); ok && parts != nil {
+		return pe.ExtractParts(parts, path, value)
+	}
+	return e.Extract(path, value)
+}
+
+type DeviceExtractor struct {
+	Inner KeyExtractor
+	Sep   string
+}
+
+func (e *DeviceExtractor) Extract(path, value string) string {
+	return e.Inner.Extract(path, value)
+}
+
+func (e *DeviceExtractor) ExtractCaptures(captures []string, path, value string) string {
+	if ce, ok := e.Inner.(CaptureExtractor); ok {
+		return ce.ExtractCaptures(captures, path, value)
+	}
+	return e.Inner.Extract(path, value)
+}
+
+func (e *DeviceExtractor) ExtractContext(ctx context.Context, parts []string, path, value string) string {
+	return e.withDevice(ctx, ExtractParts(e.Inner, parts, path, value))
+}
+
+func (e *DeviceExtractor) ExtractContextCaptures(ctx context.Context, captures, parts []string, path, value string) string {
+	if ce, ok := e.Inner.(CaptureExtractor); ok {
+		return e.withDevice(ctx, ce.ExtractCaptures(captures, path, value))
+	}
+	return e.ExtractContext(ctx, parts, path, value)
+}
+
+func (e *DeviceExtractor) withDevice(ctx context.Context, key string) string {
+	id, ok := cwmp.DeviceIDFromContext(ctx)
+	if !ok || id.SerialNumber == "" {
+		return key
+	}
+	return joinPrefix(id.SerialNumber, e.Sep, key)
+}
+
 type StaticExtractor struct {
 	Value string
 }
@@ -88,6 +330,46 @@ func (e *LastPartExtractor) Extract(path, value string) string {
 	return path
 }
 
+type ObjectPathExtractor struct {
+	Prefix string
+}
+
+func (e *ObjectPathExtractor) Extract(path, value string) string {
+	dot := strings.LastIndexByte(path, '.')
+	if dot <= 0 {
+		return ""
+	}
+	return e.Prefix + path[:dot]
+}
+
+type RegexKeyExtractor struct {
+	Regexp   *regexp.Regexp
+	Template string
+}
+
+var templateRef = regexp.MustCompile(`\$\{(\w+)\}`)
+
+func NewRegexKeyExtractor(pattern, template string) (*RegexKeyExtractor, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key regexp: %w", err)
+	}
+	for _, ref := range templateRef.FindAllStringSubmatch(template, -1) {
+		if re.SubexpIndex(ref[1]) < 0 {
+			return nil, fmt.Errorf("key template %q references unknown group %q", template, ref[1])
+		}
+	}
+	return &RegexKeyExtractor{Regexp: re, Template: template}, nil
+}
+
+func (e *RegexKeyExtractor) Extract(path, value string) string {
+	match := e.Regexp.FindStringSubmatchIndex(path)
+	if match == nil {
+		return ""
+	}
+	return string(e.Regexp.ExpandString(nil, e.Template, path, match))
+}
+
 func CompileExtractor(pattern string) KeyExtractor {
 	if pattern == "value" {
 		return &ValueExtractor{}
@@ -100,6 +382,10 @@ func CompileExtractor(pattern string) KeyExtractor {
 		}
 	}
 
+	if ext, ok, err := parseValueToken(pattern); ok && err == nil {
+		return ext
+	}
+
 	if strings.Contains(pattern, "+") {
 		parts := strings.Split(pattern, "+")
 		extractors := make([]KeyExtractor, len(parts))
@@ -121,6 +407,74 @@ func CompileExtractor(pattern string) KeyExtractor {
 	return &StaticExtractor{Value: pattern}
 }
 
+func CompileExtractorStrict(spec string) (KeyExtractor, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("empty extractor spec")
+	}
+
+	if inner, name, ok := strings.Cut(spec, "|"); ok {
+		name = strings.TrimSpace(name)
+		if !transform.Has(name) {
+			return nil, fmt.Errorf("malformed extractor spec %q: unknown transform %q", spec, name)
+		}
+		ext, err := CompileExtractorStrict(inner)
+		if err != nil {
+			return nil, err
+		}
+		return &TransformExtractor{Inner: ext, Transform: name}, nil
+	}
+
+	if spec == "value" {
+		return &ValueExtractor{}, nil
+	}
+
+	if strings.HasPrefix(spec, "path[") {
+		if !strings.HasSuffix(spec, "]") {
+			return nil, fmt.Errorf("malformed extractor spec %q: missing closing bracket", spec)
+		}
+		idx, err := strconv.Atoi(spec[5 : len(spec)-1])
+		if err != nil || idx < 0 {
+			return nil, fmt.Errorf("malformed extractor spec %q: index must be a non-negative integer", spec)
+		}
+		return &IndexExtractor{Position: idx}, nil
+	}
+
+	if ext, ok, err := parseValueToken(spec); ok {
+		if err != nil {
+			return nil, err
+		}
+		return ext, nil
+	}
+
+	if strings.Contains(spec, "+") {
+		return compileCompositeStrict(spec, "+", "")
+	}
+
+	if strings.Contains(spec, ":") {
+		return compileCompositeStrict(spec, ":", ":")
+	}
+
+	if strings.ContainsAny(spec, "[]") {
+		return nil, fmt.Errorf("malformed extractor spec %q", spec)
+	}
+
+	return &StaticExtractor{Value: spec}, nil
+}
+
+func compileCompositeStrict(spec, splitOn, sep string) (KeyExtractor, error) {
+	parts := strings.Split(spec, splitOn)
+	extractors := make([]KeyExtractor, len(parts))
+	for i, part := range parts {
+		ext, err := CompileExtractorStrict(part)
+		if err != nil {
+			return nil, fmt.Errorf("part %d of %q: %w", i, spec, err)
+		}
+		extractors[i] = ext
+	}
+	return &CompositeExtractor{Parts: extractors, Sep: sep}, nil
+}
+
 var pathCache = &sync.Map{}
 
 func splitPathCached(path string) []string {
@@ -133,10 +487,17 @@ func splitPathCached(path string) []string {
 	return parts
 }
 
+func SplitPath(path string) []string {
+	return splitPathFast(path)
+}
+
 func splitPathFast(path string) []string {
 	n := 1
 	for i := 0; i < len(path); i++ {
-		if path[i] == '.' {
+		switch path[i] {
+		case '\\':
+			i++
+		case '.':
 			n++
 		}
 	}
@@ -144,7 +505,10 @@ func splitPathFast(path string) []string {
 	parts := make([]string, 0, n)
 	start := 0
 	for i := 0; i < len(path); i++ {
-		if path[i] == '.' {
+		switch path[i] {
+		case '\\':
+			i++
+		case '.':
 			if i > start {
 				parts = append(parts, path[start:i])
 			}
@@ -0,0 +1,32 @@
+package extractor
+
+import (
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+)
+
+func TestWildcardExtractorFromPath(t *testing.T) {
+	pattern := router.CompilePattern("Device.WiFi.AccessPoint.*.AssociatedDevice.*.MACAddress")
+	path := "Device.WiFi.AccessPoint.2.AssociatedDevice.7.MACAddress"
+
+	tests := []struct {
+		name string
+		ext  KeyExtractor
+		want string
+	}{
+		{"first capture", &WildcardExtractor{Index: 0, Prefix: "ap", Sep: ":", Pattern: pattern}, "ap:2"},
+		{"second capture", &WildcardExtractor{Index: 1, Pattern: pattern}, "7"},
+		{"index out of range", &WildcardExtractor{Index: 2, Pattern: pattern}, ""},
+		{"no pattern", &WildcardExtractor{Index: 0}, ""},
+		{"inside composite", &CompositeExtractor{Parts: []KeyExtractor{
+			&WildcardExtractor{Index: 0, Pattern: pattern},
+			&WildcardExtractor{Index: 1, Pattern: pattern},
+		}, Sep: "/"}, "2/7"},
+	}
+	for _, tt := range tests {
+		if got := tt.ext.Extract(path, ""); got != tt.want {
+			t.Errorf("%s: Extract = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -5,13 +5,15 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
 	"gopkg.in/yaml.v3"
 )
 
 type Loader struct {
-	searchPaths []string
+	searchPaths        []string
+	allowUnknownFields bool
 }
 
 func New(searchPaths ...string) *Loader {
@@ -24,6 +26,11 @@ func (l *Loader) AddSearchPath(path string) {
 	l.searchPaths = append(l.searchPaths, path)
 }
 
+func (l *Loader) WithAllowUnknownFields(allow bool) *Loader {
+	l.allowUnknownFields = allow
+	return l
+}
+
 func (l *Loader) LoadFile(filename string) (*types.RulesConfig, error) {
 	file, err := l.findFile(filename)
 	if err != nil {
@@ -35,23 +42,90 @@ func (l *Loader) LoadFile(filename string) (*types.RulesConfig, error) {
 }
 
 func (l *Loader) Load(r io.Reader) (*types.RulesConfig, error) {
+	config, err := l.decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := l.validate(config); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	return config, nil
+}
+
+func (l *Loader) LoadWithOverrides(base, override string) (*types.RulesConfig, error) {
+	baseConfig, err := l.decodeFile(base)
+	if err != nil {
+		return nil, err
+	}
+	overrideConfig, err := l.decodeFile(override)
+	if err != nil {
+		return nil, err
+	}
+
+	config := mergeConfigs(baseConfig, overrideConfig)
+	if err := l.validate(config); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	return config, nil
+}
+
+func (l *Loader) decodeFile(filename string) (*types.RulesConfig, error) {
+	file, err := l.findFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	config, err := l.decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return config, nil
+}
+
+func (l *Loader) decode(r io.Reader) (*types.RulesConfig, error) {
 	decoder := yaml.NewDecoder(r)
-	decoder.KnownFields(true)
+	decoder.KnownFields(!l.allowUnknownFields)
 
 	var config types.RulesConfig
 	if err := decoder.Decode(&config); err != nil {
 		return nil, fmt.Errorf("failed to decode YAML: %w", err)
 	}
+	return &config, nil
+}
+
+func mergeConfigs(base, override *types.RulesConfig) *types.RulesConfig {
+	merged := &types.RulesConfig{
+		Version: base.Version,
+		Rules:   make([]types.RuleConfig, len(base.Rules), len(base.Rules)+len(override.Rules)),
+	}
+	if override.Version != "" {
+		merged.Version = override.Version
+	}
+	copy(merged.Rules, base.Rules)
 
-	if err := l.validate(&config); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+	index := make(map[string]int, len(merged.Rules))
+	for i, rule := range merged.Rules {
+		index[rule.Name] = i
 	}
 
-	return &config, nil
+	for _, rule := range override.Rules {
+		if i, ok := index[rule.Name]; ok {
+			merged.Rules[i] = rule
+			continue
+		}
+		index[rule.Name] = len(merged.Rules)
+		merged.Rules = append(merged.Rules, rule)
+	}
+
+	return merged
 }
 
 func (l *Loader) LoadString(content string) (*types.RulesConfig, error) {
-	return l.Load(stringReader(content))
+	return l.Load(strings.NewReader(content))
 }
 
 func (l *Loader) findFile(filename string) (*os.File, error) {
@@ -123,6 +197,15 @@ func (l *Loader) validate(config *types.RulesConfig) error {
 				return fmt.Errorf("rule[%d] %s field[%d] %s: value expression is required", i, rule.Name, j, field.Name)
 			}
 		}
+
+		for j, derived := range rule.Derived {
+			if derived.Name == "" {
+				return fmt.Errorf("rule[%d] %s derived[%d]: name is required", i, rule.Name, j)
+			}
+			if derived.Value == "" {
+				return fmt.Errorf("rule[%d] %s derived[%d] %s: value expression is required", i, rule.Name, j, derived.Name)
+			}
+		}
 	}
 
 	return nil
@@ -133,17 +216,12 @@ func LoadFile(filename string) (*types.RulesConfig, error) {
 	return loader.LoadFile(filename)
 }
 
-func LoadString(content string) (*types.RulesConfig, error) {
+func LoadWithOverrides(base, override string) (*types.RulesConfig, error) {
 	loader := New()
-	return loader.LoadString(content)
+	return loader.LoadWithOverrides(base, override)
 }
 
-type stringReader string
-
-func (s stringReader) Read(p []byte) (n int, err error) {
-	n = copy(p, s)
-	if n < len(s) {
-		return n, nil
-	}
-	return n, io.EOF
+func LoadString(content string) (*types.RulesConfig, error) {
+	loader := New()
+	return loader.LoadString(content)
 }
@@ -0,0 +1,233 @@
+package loader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
+)
+
+const baseRules = `
+version: "1.0"
+rules:
+  - name: hosts
+    target: Host
+    route: 'path.startsWith("Device.Hosts.Host.")'
+    entity_key: 'path.split(".")[3]'
+    fields:
+      - name: HostName
+        when: 'path.endsWith(".HostName")'
+        value: 'value'
+  - name: wifi
+    target: WiFi
+    route: 'path.startsWith("Device.WiFi.SSID.")'
+    entity_key: 'path.split(".")[3]'
+    fields:
+      - name: SSID
+        when: 'path.endsWith(".SSID")'
+        value: 'value'
+  - name: interfaces
+    target: Interface
+    route: 'path.startsWith("Device.IP.Interface.")'
+    entity_key: 'path.split(".")[3]'
+    fields:
+      - name: Name
+        when: 'path.endsWith(".Name")'
+        value: 'value'
+`
+
+func writeRules(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func ruleNames(config *types.RulesConfig) string {
+	names := make([]string, len(config.Rules))
+	for i, rule := range config.Rules {
+		names[i] = rule.Name
+	}
+	return strings.Join(names, " ")
+}
+
+func TestLoadWithOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeRules(t, dir, "base.yaml", baseRules)
+	writeRules(t, dir, "override.yaml", `
+version: "1.1"
+rules:
+  - name: dns
+    target: DNS
+    route: 'path.startsWith("Device.DNS.")'
+    entity_key: '"dns"'
+    fields:
+      - name: Servers
+        when: 'true'
+        value: 'value'
+  - name: wifi
+    target: Radio
+    route: 'path.startsWith("Device.WiFi.Radio.")'
+    entity_key: 'path.split(".")[3]'
+    priority: 5
+    fields:
+      - name: Channel
+        when: 'path.endsWith(".Channel")'
+        value: 'value'
+`)
+
+	config, err := New(dir).LoadWithOverrides("base.yaml", "override.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Version != "1.1" {
+		t.Errorf("Version = %q, want the override's 1.1", config.Version)
+	}
+	if got, want := ruleNames(config), "hosts wifi interfaces dns"; got != want {
+		t.Errorf("rules = %q, want %q", got, want)
+	}
+	wifi := config.Rules[1]
+	if wifi.Target != "Radio" || wifi.Priority != 5 || len(wifi.Fields) != 1 || wifi.Fields[0].Name != "Channel" {
+		t.Errorf("wifi rule was not replaced: %+v", wifi)
+	}
+	if config.Rules[0].Target != "Host" || config.Rules[2].Target != "Interface" {
+		t.Errorf("base rules changed: %+v", config.Rules)
+	}
+}
+
+func TestLoadWithOverridesKeepsBaseVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeRules(t, dir, "base.yaml", baseRules)
+	writeRules(t, dir, "override.yaml", `
+rules:
+  - name: hosts
+    target: Client
+    route: 'path.startsWith("Device.Hosts.Host.")'
+    entity_key: 'path.split(".")[3]'
+`)
+
+	config, err := LoadWithOverrides(filepath.Join(dir, "base.yaml"), filepath.Join(dir, "override.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Version != "1.0" {
+		t.Errorf("Version = %q, want the base's 1.0", config.Version)
+	}
+	if got, want := ruleNames(config), "hosts wifi interfaces"; got != want {
+		t.Errorf("rules = %q, want %q", got, want)
+	}
+	if config.Rules[0].Target != "Client" || len(config.Rules[0].Fields) != 0 {
+		t.Errorf("hosts rule = %+v, want the override without fields", config.Rules[0])
+	}
+}
+
+func TestLoadWithOverridesErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		errMsg   string
+	}{
+		{"invalid merged rule", `
+rules:
+  - name: wifi
+    route: 'true'
+    entity_key: '"x"'
+`, "validation failed: rule[1] wifi: target is required"},
+		{"unknown key", `
+rules:
+  - name: wifi
+    target: WiFi
+    route: 'true'
+    entity_kye: '"x"'
+`, "override.yaml: failed to decode YAML"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeRules(t, dir, "base.yaml", baseRules)
+			writeRules(t, dir, "override.yaml", tt.override)
+
+			_, err := New(dir).LoadWithOverrides("base.yaml", "override.yaml")
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("LoadWithOverrides error = %v, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+
+	_, err := New(t.TempDir()).LoadWithOverrides("base.yaml", "override.yaml")
+	if err == nil || !strings.Contains(err.Error(), "base.yaml") {
+		t.Errorf("missing base error = %v, want it to name base.yaml", err)
+	}
+}
+
+func TestAllowUnknownFields(t *testing.T) {
+	content := `
+version: "1.0"
+rules:
+  - name: hosts
+    target: Host
+    route: 'path.startsWith("Device.Hosts.Host.")'
+    entity_key: 'path.split(".")[3]'
+    sample_rate: 0.5
+    fields:
+      - name: HostName
+        when: 'true'
+        value: 'value'
+        unit: seconds
+`
+
+	if _, err := LoadString(content); err == nil || !strings.Contains(err.Error(), "field sample_rate not found") {
+		t.Errorf("strict LoadString error = %v, want unknown field sample_rate", err)
+	}
+	if _, err := New().WithAllowUnknownFields(false).LoadString(content); err == nil {
+		t.Error("WithAllowUnknownFields(false) accepted unknown fields")
+	}
+
+	config, err := New().WithAllowUnknownFields(true).LoadString(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Rules) != 1 || config.Rules[0].Target != "Host" || config.Rules[0].Fields[0].Value != "value" {
+		t.Errorf("lenient config = %+v", config.Rules)
+	}
+}
+
+func TestAllowUnknownFieldsWithOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeRules(t, dir, "base.yaml", baseRules)
+	writeRules(t, dir, "override.yaml", `
+rules:
+  - name: wifi
+    target: WiFi
+    route: 'true'
+    entity_key: '"x"'
+    added_in_v2: true
+`)
+
+	if _, err := New(dir).LoadWithOverrides("base.yaml", "override.yaml"); err == nil {
+		t.Error("strict loader accepted an unknown key in the override")
+	}
+	if _, err := New(dir).WithAllowUnknownFields(true).LoadWithOverrides("base.yaml", "override.yaml"); err != nil {
+		t.Errorf("lenient loader error = %v", err)
+	}
+}
+
+func TestLoadStringLongConfig(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("version: \"1.0\"\nrules:\n")
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&b, "  - name: rule%d\n    target: Host\n    route: 'true'\n    entity_key: '\"%d\"'\n", i, i)
+	}
+
+	config, err := LoadString(b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Rules) != 50 || config.Rules[49].Name != "rule49" {
+		t.Errorf("loaded %d rules, want 50 ending with rule49", len(config.Rules))
+	}
+}
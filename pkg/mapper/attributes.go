@@ -0,0 +1,42 @@
+package mapper
+
+import "strings"
+
+var attributeNames = []string{"Writable", "Notification", "AccessList"}
+
+func SplitAttribute(path string) (string, string, bool) {
+	dot := strings.LastIndexByte(path, '.')
+	if dot <= 0 || path[dot-1] == '\\' {
+		return "", "", false
+	}
+	attribute := path[dot+1:]
+	for _, name := range attributeNames {
+		if attribute == name {
+			return path[:dot], attribute, true
+		}
+	}
+	return "", "", false
+}
+
+func SkipAttributes() func(path string) bool {
+	return func(path string) bool {
+		_, _, ok := SplitAttribute(path)
+		return !ok
+	}
+}
+
+func WithAttributeHandler(handler func(param, attribute, value string)) FastOption {
+	return func(m *FastMapper) {
+		m.attributeHandler = handler
+	}
+}
+
+func (m *FastMapper) skipPath(path, value string) bool {
+	if m.attributeHandler != nil {
+		if param, attribute, ok := SplitAttribute(path); ok {
+			m.attributeHandler(param, attribute, value)
+			return true
+		}
+	}
+	return m.pathFilter != nil && !m.pathFilter(path)
+}
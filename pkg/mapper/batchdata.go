@@ -0,0 +1,24 @@
+package mapper
+
+import (
+	"github.com/google/cel-go/cel"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
+)
+
+func WithVariable(name string, celType *cel.Type) Option {
+	return func(m *Mapper) {
+		if m.variables == nil {
+			m.variables = make(map[string]*cel.Type)
+		}
+		m.variables[name] = celType
+	}
+}
+
+func seedData(pc *types.ProcessContext, data map[string]any) {
+	for name, value := range data {
+		if name == "path" || name == "value" {
+			continue
+		}
+		pc.Data[name] = value
+	}
+}
@@ -0,0 +1,70 @@
+package mapper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+)
+
+func newSerialMapper(t *testing.T, opts ...Option) *Mapper {
+	t.Helper()
+	reg := registry.New()
+	reg.MustRegister("WiFi", func() any { return &TestWifi{} })
+
+	m := New(reg, append([]Option{WithVariable("serial", cel.StringType)}, opts...)...)
+	err := m.LoadRulesFromString(`
+version: "1.0"
+rules:
+  - name: wifi
+    target: WiFi
+    route: 'path.startsWith("Device.WiFi.SSID.")'
+    entity_key: 'serial + "/" + path.split(".")[3]'
+    fields:
+      - name: SSID
+        when: 'path.endsWith(".SSID")'
+        value: 'value'
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestProcessBatchWithData(t *testing.T) {
+	m := newSerialMapper(t)
+	data := map[string]any{"serial": "SN1", "path": "ignored", "value": "ignored"}
+	items := [][2]string{
+		{"Device.WiFi.SSID.1.SSID", "home"},
+		{"Device.WiFi.SSID.2.SSID", "guest"},
+	}
+	if err := m.ProcessBatchWithData(context.Background(), items, data); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{"SN1/1": "home", "SN1/2": "guest"} {
+		obj, ok := m.GetStore().Get("WiFi", key)
+		if !ok {
+			t.Fatalf("WiFi %q not stored", key)
+		}
+		if got := obj.(*TestWifi).SSID; got != want {
+			t.Errorf("%s SSID = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestProcessBatchWithoutData(t *testing.T) {
+	var errs []error
+	m := newSerialMapper(t, WithErrorHandler(func(err error) { errs = append(errs, err) }))
+
+	if err := m.ProcessBatch([][2]string{{"Device.WiFi.SSID.1.SSID", "home"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 {
+		t.Errorf("got %d errors for unset variable, want 1", len(errs))
+	}
+	if _, ok := m.GetStore().Get("WiFi", "SN1/1"); ok {
+		t.Error("entity stored without batch data")
+	}
+}
@@ -2,12 +2,14 @@ package mapper
 
 import (
 	"fmt"
+	"sync/atomic"
 	"testing"
 
 	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
 	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
 	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
 	"github.com/metalgrid/tr069-cel-mapper/pkg/transform"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
 )
 
 type TestHost struct {
@@ -193,3 +195,190 @@ func BenchmarkTransformOnly(b *testing.B) {
 		transformer.Transform(data.transform, data.value)
 	}
 }
+
+func BenchmarkSettersOnly(b *testing.B) {
+	reg := registry.New()
+	reg.MustRegister("wifi", func() any { return &TestWifi{} })
+	info, _ := reg.Get("wifi")
+
+	testData := []struct {
+		setter func(any, any) error
+		value  any
+	}{
+		{info.Setters["SSID"], "HomeNetwork"},
+		{info.Setters["Channel"], int64(6)},
+		{info.Setters["Enabled"], true},
+	}
+	obj := info.Factory()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		data := testData[i%len(testData)]
+		if err := data.setter(obj, data.value); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type upsertCountingStore struct {
+	*types.MapStore
+	upserts atomic.Int64
+}
+
+func (s *upsertCountingStore) Upsert(target, key string, factory func() any) any {
+	s.upserts.Add(1)
+	return s.MapStore.Upsert(target, key, factory)
+}
+
+func BenchmarkProcessBatchUngrouped(b *testing.B) {
+	benchmarkBatchGrouping(b, false)
+}
+
+func BenchmarkProcessBatchGrouped(b *testing.B) {
+	benchmarkBatchGrouping(b, true)
+}
+
+func benchmarkBatchGrouping(b *testing.B, grouped bool) {
+	reg := registry.New()
+	reg.MustRegister("host", func() any { return &TestHost{} })
+
+	store := &upsertCountingStore{MapStore: types.NewMapStore()}
+	mapper := NewFast(reg, WithFastStore(store))
+
+	fields := []struct {
+		leaf      string
+		field     string
+		transform string
+	}{
+		{"MACAddress", "MACAddress", "mac_normalize"},
+		{"IPAddress", "IPAddress", "ip_validate"},
+		{"HostName", "HostName", ""},
+		{"Active", "Active", "bool"},
+	}
+
+	for _, f := range fields {
+		mapper.AddRule(&FastRule{
+			ID:        "host_" + f.field,
+			Pattern:   router.CompilePattern("InternetGatewayDevice.LANDevice.*.Hosts.*." + f.leaf),
+			Entity:    "host",
+			Field:     f.field,
+			Transform: f.transform,
+			Extractor: extractor.CompileExtractor("path[4]"),
+		})
+	}
+
+	// Field-major order: every entity is touched once per field.
+	items := make([][2]string, 0, 40*len(fields))
+	for _, f := range fields {
+		for host := 1; host <= 40; host++ {
+			path := fmt.Sprintf("InternetGatewayDevice.LANDevice.1.Hosts.%d.%s", host, f.leaf)
+			items = append(items, [2]string{path, "1"})
+		}
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if grouped {
+			mapper.ProcessBatchGrouped(items)
+		} else {
+			mapper.ProcessBatch(items)
+		}
+	}
+
+	b.StopTimer()
+	b.ReportMetric(float64(store.upserts.Load())/float64(b.N), "upserts/op")
+}
+
+func BenchmarkProcessPath(b *testing.B) {
+	benchmarkPreSplit(b, false)
+}
+
+func BenchmarkProcessParts(b *testing.B) {
+	benchmarkPreSplit(b, true)
+}
+
+func benchmarkPreSplit(b *testing.B, preSplit bool) {
+	reg := registry.New()
+	reg.MustRegister("host", func() any { return &TestHost{} })
+
+	mapper := NewFast(reg)
+	for _, field := range []string{"MACAddress", "IPAddress", "HostName"} {
+		mapper.AddRule(&FastRule{
+			ID:        "host_" + field,
+			Pattern:   router.CompilePattern("InternetGatewayDevice.LANDevice.*.Hosts.*." + field),
+			Entity:    "host",
+			Field:     field,
+			Extractor: extractor.CompileExtractor("path[4]"),
+		})
+	}
+
+	const hosts = 10000
+	paths := make([]string, 0, hosts*3)
+	parts := make([][]string, 0, hosts*3)
+	for host := 1; host <= hosts; host++ {
+		for _, field := range []string{"MACAddress", "IPAddress", "HostName"} {
+			path := fmt.Sprintf("InternetGatewayDevice.LANDevice.1.Hosts.%d.%s", host, field)
+			paths = append(paths, path)
+			parts = append(parts, []string{"InternetGatewayDevice", "LANDevice", "1", "Hosts", fmt.Sprint(host), field})
+		}
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		n := i % len(paths)
+		if preSplit {
+			mapper.ProcessParts(parts[n], "x")
+		} else {
+			mapper.Process(paths[n], "x")
+		}
+	}
+}
+
+func BenchmarkMapperProcessBatch(b *testing.B) {
+	reg := registry.New()
+	reg.MustRegister("Host", func() any { return &TestHost{} })
+
+	mapper := New(reg)
+	err := mapper.LoadRulesFromString(`
+version: "1.0"
+rules:
+  - name: hosts
+    target: Host
+    route: 'path.startsWith("InternetGatewayDevice.LANDevice.") && path.contains(".Hosts.")'
+    entity_key: 'path.split(".")[4]'
+    fields:
+      - name: MACAddress
+        when: 'path.endsWith(".MACAddress")'
+        value: 'value'
+      - name: HostName
+        when: 'path.endsWith(".HostName")'
+        value: 'value'
+`)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	const lines = 100000
+	items := make([][2]string, 0, lines)
+	for i := 0; len(items) < lines; i++ {
+		host := i/2 + 1
+		field := "MACAddress"
+		if i%2 == 1 {
+			field = "HostName"
+		}
+		items = append(items, [2]string{fmt.Sprintf("InternetGatewayDevice.LANDevice.1.Hosts.%d.%s", host, field), "x"})
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		mapper.ProcessBatch(items)
+	}
+}
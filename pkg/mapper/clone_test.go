@@ -0,0 +1,102 @@
+package mapper
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
+)
+
+func TestCloneSharesCompiledRules(t *testing.T) {
+	m := newSerialMapper(t, WithMetrics())
+	c := m.Clone()
+
+	if len(c.rules) != len(m.rules) || c.rules[0] != m.rules[0] {
+		t.Fatal("clone does not share the compiled rules")
+	}
+	if !reflect.DeepEqual(c.GetRuleNames(), m.GetRuleNames()) {
+		t.Errorf("rule names %v, want %v", c.GetRuleNames(), m.GetRuleNames())
+	}
+	if c.GetStore() == m.GetStore() {
+		t.Error("clone shares the store")
+	}
+	if c.GetMetrics() == nil || c.GetMetrics() == m.GetMetrics() {
+		t.Error("clone did not get fresh metrics")
+	}
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	m := newSerialMapper(t, WithMetrics())
+	store := types.NewMapStore()
+	c := m.Clone(WithStore(store))
+
+	err := c.ProcessBatchWithData(t.Context(), [][2]string{{"Device.WiFi.SSID.1.SSID", "home"}}, map[string]any{"serial": "SN1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Get("WiFi", "SN1/1"); !ok {
+		t.Error("clone option store not used")
+	}
+	if n := len(m.GetStore().GetAll("WiFi")); n != 0 {
+		t.Errorf("original store has %d entities", n)
+	}
+	if m.GetMetrics().ProcessedLines != 0 || c.GetMetrics().ProcessedLines != 1 {
+		t.Errorf("processed lines: original %d, clone %d", m.GetMetrics().ProcessedLines, c.GetMetrics().ProcessedLines)
+	}
+
+	err = m.LoadRulesFromString(`
+version: "1.0"
+rules:
+  - name: other
+    target: WiFi
+    route: 'path.startsWith("Device.Other.")'
+    entity_key: 'serial'
+    fields:
+      - name: SSID
+        when: 'true'
+        value: 'value'
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetRuleNames(); !reflect.DeepEqual(got, []string{"wifi"}) {
+		t.Errorf("reloading the original changed the clone's rules to %v", got)
+	}
+}
+
+func TestClonesProcessConcurrently(t *testing.T) {
+	m := newSerialMapper(t)
+
+	var wg sync.WaitGroup
+	clones := make([]*Mapper, 8)
+	for i := range clones {
+		clones[i] = m.Clone()
+		wg.Add(1)
+		go func(c *Mapper, serial string) {
+			defer wg.Done()
+			items := make([][2]string, 0, 50)
+			for j := 0; j < 50; j++ {
+				items = append(items, [2]string{fmt.Sprintf("Device.WiFi.SSID.%d.SSID", j), serial})
+			}
+			if err := c.ProcessBatchWithData(t.Context(), items, map[string]any{"serial": serial}); err != nil {
+				t.Error(err)
+			}
+		}(clones[i], fmt.Sprintf("SN%d", i))
+	}
+	wg.Wait()
+
+	for i, c := range clones {
+		entities := c.GetStore().GetAll("WiFi")
+		if len(entities) != 50 {
+			t.Errorf("clone %d stored %d entities, want 50", i, len(entities))
+		}
+		serial := fmt.Sprintf("SN%d", i)
+		for key, obj := range entities {
+			if obj.(*TestWifi).SSID != serial {
+				t.Errorf("clone %d entity %s has SSID %q", i, key, obj.(*TestWifi).SSID)
+			}
+		}
+	}
+}
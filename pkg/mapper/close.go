@@ -0,0 +1,42 @@
+package mapper
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrClosed = errors.New("mapper closed")
+
+type flusher interface {
+	Flush() error
+}
+
+func (m *Mapper) Close() error {
+	if m.closed.Swap(true) {
+		return nil
+	}
+	return closeStore(m.store)
+}
+
+func (m *FastMapper) Close() error {
+	if m.closed.Swap(true) {
+		return nil
+	}
+	m.objectPool.Drain()
+	return closeStore(m.store)
+}
+
+func closeStore(store any) error {
+	var errs []error
+	if f, ok := store.(flusher); ok {
+		if err := f.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c, ok := store.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
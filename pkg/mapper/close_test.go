@@ -0,0 +1,122 @@
+package mapper
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
+)
+
+type closingStore struct {
+	*types.MapStore
+	calls    []string
+	flushErr error
+	closeErr error
+}
+
+func (s *closingStore) Flush() error {
+	s.calls = append(s.calls, "flush")
+	return s.flushErr
+}
+
+func (s *closingStore) Close() error {
+	s.calls = append(s.calls, "close")
+	return s.closeErr
+}
+
+func TestFastMapperClose(t *testing.T) {
+	store := &closingStore{MapStore: types.NewMapStore()}
+	m := newHostMapper(t, WithFastStore(store))
+	if err := m.Process("Device.Hosts.Host.1.HostName", "laptop"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if got := strings.Join(store.calls, ","); got != "flush,close" {
+		t.Errorf("store calls = %s, want flush,close", got)
+	}
+
+	items := [][2]string{{"Device.Hosts.Host.2.HostName", "phone"}}
+	for name, err := range map[string]error{
+		"Process":             m.Process(items[0][0], items[0][1]),
+		"ProcessParts":        m.ProcessParts(strings.Split(items[0][0], "."), items[0][1]),
+		"ProcessBatch":        m.ProcessBatch(items),
+		"ProcessBatchGrouped": m.ProcessBatchGrouped(items),
+		"ProcessMap":          m.ProcessMap(map[string]string{items[0][0]: items[0][1]}),
+	} {
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("%s after Close = %v, want ErrClosed", name, err)
+		}
+	}
+
+	var streamErr error
+	for event := range m.ProcessStreamEmit(strings.NewReader(items[0][0] + " " + items[0][1] + "\n")) {
+		if event.Err != nil {
+			streamErr = event.Err
+		}
+	}
+	if !errors.Is(streamErr, ErrClosed) {
+		t.Errorf("ProcessStreamEmit after Close = %v, want ErrClosed", streamErr)
+	}
+
+	if getHost(t, m, "1").HostName != "laptop" {
+		t.Error("entity stored before Close was lost")
+	}
+	if _, ok := store.Get("host", "2"); ok {
+		t.Error("entity stored after Close")
+	}
+}
+
+func TestMapperClose(t *testing.T) {
+	store := &closingStore{MapStore: types.NewMapStore()}
+	m := newSerialMapper(t, WithStore(store))
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if got := strings.Join(store.calls, ","); got != "flush,close" {
+		t.Errorf("store calls = %s, want flush,close", got)
+	}
+
+	items := [][2]string{{"Device.WiFi.SSID.1.SSID", "home"}}
+	for name, err := range map[string]error{
+		"Process":              m.Process(items[0][0], items[0][1]),
+		"ProcessBatch":         m.ProcessBatch(items),
+		"ProcessBatchWithData": m.ProcessBatchWithData(t.Context(), items, map[string]any{"serial": "SN1"}),
+		"ProcessMap":           m.ProcessMap(map[string]string{items[0][0]: items[0][1]}),
+	} {
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("%s after Close = %v, want ErrClosed", name, err)
+		}
+	}
+	if n := len(store.GetAll("WiFi")); n != 0 {
+		t.Errorf("%d entities stored after Close", n)
+	}
+}
+
+func TestCloseJoinsStoreErrors(t *testing.T) {
+	flushErr := errors.New("flush failed")
+	closeErr := errors.New("close failed")
+	store := &closingStore{MapStore: types.NewMapStore(), flushErr: flushErr, closeErr: closeErr}
+	m := newHostMapper(t, WithFastStore(store))
+
+	err := m.Close()
+	if !errors.Is(err, flushErr) || !errors.Is(err, closeErr) {
+		t.Errorf("Close = %v, want both store errors", err)
+	}
+	if got := strings.Join(store.calls, ","); got != "flush,close" {
+		t.Errorf("store calls = %s, want flush,close", got)
+	}
+	if err := m.Close(); err != nil {
+		t.Errorf("second Close = %v, want nil", err)
+	}
+}
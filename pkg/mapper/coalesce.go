@@ -0,0 +1,110 @@
+package mapper
+
+import (
+	"strings"
+	"sync"
+)
+
+func Coalesce(rules ...*FastRule) []*FastRule {
+	out := make([]*FastRule, len(rules))
+	for i, rule := range rules {
+		c := *rule
+		c.Precedence = i + 1
+		out[i] = &c
+	}
+	return out
+}
+
+type candidateKey struct {
+	target string
+	key    string
+	field  string
+}
+
+const candidateStripes = 64
+
+type candidateTracker struct {
+	mu      sync.Mutex
+	set     map[candidateKey]int
+	entries [candidateStripes]sync.Mutex
+}
+
+func (t *candidateTracker) entity(target, key string) *sync.Mutex {
+	const prime = 16777619
+	h := uint32(2166136261)
+	for i := 0; i < len(target); i++ {
+		h ^= uint32(target[i])
+		h *= prime
+	}
+	h *= prime
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= prime
+	}
+	return &t.entries[h%candidateStripes]
+}
+
+func (t *candidateTracker) precedence(ck candidateKey) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	current, ok := t.set[ck]
+	return current, ok
+}
+
+func (t *candidateTracker) record(ck candidateKey, precedence int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.set == nil {
+		t.set = make(map[candidateKey]int)
+	}
+	t.set[ck] = precedence
+}
+
+func (t *candidateTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.set = nil
+}
+
+func (t *candidateTracker) resetTarget(target string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for k := range t.set {
+		if k.target == target {
+			delete(t.set, k)
+		}
+	}
+}
+
+func (t *candidateTracker) resetKey(target, key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for k := range t.set {
+		if k.target == target && k.key == key {
+			delete(t.set, k)
+		}
+	}
+}
+
+func (m *FastMapper) applyCandidate(line resolvedLine, obj any) lineResult {
+	if strings.TrimSpace(line.value) == "" {
+		return lineMatched
+	}
+
+	rule := line.rule
+	ck := candidateKey{target: rule.target(), key: line.key, field: rule.Field}
+
+	entity := m.candidates.entity(ck.target, ck.key)
+	entity.Lock()
+	defer entity.Unlock()
+
+	if current, ok := m.candidates.precedence(ck); ok && current < rule.Precedence {
+		return lineMatched
+	}
+
+	result := m.applyValue(line.ctx, rule, line.key, obj, line.value)
+	if result == lineMatched {
+		m.candidates.record(ck, rule.Precedence)
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package mapper
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+)
+
+func newCoalescedMapper(t *testing.T, opts ...FastOption) *FastMapper {
+	t.Helper()
+	m := newHostMapper(t, opts...)
+	phys := hostRule("host_phys", "PhysAddress")
+	phys.Field = "MACAddress"
+	mac := hostRule("host_mac", "MACAddress")
+	mac.Pattern = router.CompilePattern("Device.Hosts.Host.*.X_MACAddress")
+	if err := m.AddRules(Coalesce(phys, mac)); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestCoalescePrefersEarlierRule(t *testing.T) {
+	for _, order := range [][]string{{"PhysAddress", "X_MACAddress"}, {"X_MACAddress", "PhysAddress"}} {
+		m := newCoalescedMapper(t)
+		for _, param := range order {
+			if err := m.Process("Device.Hosts.Host.1."+param, param); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := getHost(t, m, "1").MACAddress; got != "PhysAddress" {
+			t.Errorf("order %v: MACAddress = %q, want the PhysAddress value", order, got)
+		}
+	}
+}
+
+func TestCoalesceSkipsEmptyValues(t *testing.T) {
+	m := newCoalescedMapper(t)
+	for _, item := range [][2]string{
+		{"Device.Hosts.Host.1.X_MACAddress", "aa:00"},
+		{"Device.Hosts.Host.1.PhysAddress", "  "},
+	} {
+		if err := m.Process(item[0], item[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := getHost(t, m, "1").MACAddress; got != "aa:00" {
+		t.Errorf("MACAddress = %q, want aa:00", got)
+	}
+}
+
+func TestCoalesceResetForgetsCandidates(t *testing.T) {
+	m := newCoalescedMapper(t)
+	if err := m.Process("Device.Hosts.Host.1.PhysAddress", "phys"); err != nil {
+		t.Fatal(err)
+	}
+	m.ResetTarget("host")
+	if err := m.Process("Device.Hosts.Host.1.X_MACAddress", "mac"); err != nil {
+		t.Fatal(err)
+	}
+	if got := getHost(t, m, "1").MACAddress; got != "mac" {
+		t.Errorf("MACAddress = %q after reset, want mac", got)
+	}
+}
+
+func TestCoalesceParallelBatch(t *testing.T) {
+	m := newCoalescedMapper(t)
+	items := make([][2]string, 0, 2000)
+	for i := 0; i < 1000; i++ {
+		items = append(items,
+			[2]string{fmt.Sprintf("Device.Hosts.Host.%d.X_MACAddress", i), "mac"},
+			[2]string{fmt.Sprintf("Device.Hosts.Host.%d.PhysAddress", i), "phys"},
+		)
+	}
+	if err := m.ProcessBatch(items); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		if got := getHost(t, m, fmt.Sprint(i)).MACAddress; got != "phys" {
+			t.Fatalf("host %d MACAddress = %q, want phys", i, got)
+		}
+	}
+}
+
+func TestCoalesceDoesNotMutateArguments(t *testing.T) {
+	a, b := hostRule("a", "HostName"), hostRule("b", "IPAddress")
+	rules := Coalesce(a, b)
+	if a.Precedence != 0 || b.Precedence != 0 {
+		t.Error("Coalesce changed the caller's rules")
+	}
+	if rules[0].Precedence != 1 || rules[1].Precedence != 2 || rules[0].ID != "a" {
+		t.Errorf("Coalesce = %+v, %+v", rules[0], rules[1])
+	}
+}
@@ -0,0 +1,109 @@
+package mapper
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/transform"
+)
+
+type CounterMode int
+
+const (
+	CounterOff CounterMode = iota
+	CounterDelta
+	CounterRate
+)
+
+func (c CounterMode) String() string {
+	switch c {
+	case CounterOff:
+		return "off"
+	case CounterDelta:
+		return "delta"
+	case CounterRate:
+		return "rate"
+	default:
+		return fmt.Sprintf("CounterMode(%d)", int(c))
+	}
+}
+
+func (m *FastMapper) validateCounter(rule *FastRule) error {
+	switch {
+	case rule.Counter != CounterDelta && rule.Counter != CounterRate:
+		return fmt.Errorf("rule %s: unknown counter mode %s", rule.ID, rule.Counter)
+	case rule.Transform != "":
+		return fmt.Errorf("rule %s: Counter and Transform are mutually exclusive", rule.ID)
+	case rule.SetConstant != nil:
+		return fmt.Errorf("rule %s: Counter and SetConstant are mutually exclusive", rule.ID)
+	}
+	return nil
+}
+
+type counterSample struct {
+	value float64
+	at    time.Time
+}
+
+type counterTracker struct {
+	mu   sync.Mutex
+	last map[candidateKey]counterSample
+}
+
+func (t *counterTracker) observe(ck candidateKey, sample counterSample) (counterSample, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.last == nil {
+		t.last = make(map[candidateKey]counterSample)
+	}
+	prev, ok := t.last[ck]
+	t.last[ck] = sample
+	return prev, ok
+}
+
+func (t *counterTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last = nil
+}
+
+func (t *counterTracker) resetTarget(target string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for k := range t.last {
+		if k.target == target {
+			delete(t.last, k)
+		}
+	}
+}
+
+func (m *FastMapper) counterValue(rule *FastRule, key, value string) (float64, bool, error) {
+	parsed, err := transform.ToFloat(value)
+	if err != nil {
+		return 0, false, &transform.TransformError{Name: "float", Value: value, Err: err}
+	}
+
+	now := time.Now()
+	current := parsed.(float64)
+	prev, ok := m.counters.observe(candidateKey{target: rule.target(), key: key, field: rule.Field}, counterSample{value: current, at: now})
+	if !ok {
+		return 0, false, nil
+	}
+
+	delta := current - prev.value
+	if delta < 0 {
+		delta = current
+	}
+
+	if rule.Counter == CounterDelta {
+		return delta, true, nil
+	}
+
+	elapsed := now.Sub(prev.at).Seconds()
+	if elapsed <= 0 {
+		return 0, false, nil
+	}
+	return delta / elapsed, true, nil
+}
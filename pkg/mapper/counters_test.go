@@ -0,0 +1,150 @@
+package mapper
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/transform"
+)
+
+type testInterface struct {
+	BytesSent int64 `tr069:"accumulate"`
+	Rate      float64
+}
+
+func counterRule(id, field string, mode CounterMode) *FastRule {
+	return &FastRule{
+		ID:        id,
+		Pattern:   router.CompilePattern("Device.IP.Interface.*.Stats." + field),
+		Entity:    "iface",
+		Field:     field,
+		Counter:   mode,
+		Extractor: &extractor.IndexExtractor{Position: 3},
+	}
+}
+
+func newCounterMapper(t *testing.T, opts ...FastOption) *FastMapper {
+	t.Helper()
+	reg := registry.New()
+	reg.MustRegister("iface", func() any { return &testInterface{} })
+	m := NewFast(reg, opts...)
+	if err := m.AddRules([]*FastRule{
+		counterRule("iface_bytes", "BytesSent", CounterDelta),
+		counterRule("iface_rate", "Rate", CounterRate),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func getInterface(t *testing.T, m *FastMapper, key string) *testInterface {
+	t.Helper()
+	obj, ok := m.GetStore().Get("iface", key)
+	if !ok {
+		t.Fatalf("iface %q not stored", key)
+	}
+	return obj.(*testInterface)
+}
+
+func TestCounterDeltaAccumulates(t *testing.T) {
+	m := newCounterMapper(t)
+
+	for _, tt := range []struct {
+		value string
+		want  int64
+	}{
+		{"100", 0},
+		{"150", 50},
+		{"180", 80},
+		{"30", 110},
+	} {
+		if err := m.Process("Device.IP.Interface.1.Stats.BytesSent", tt.value); err != nil {
+			t.Fatal(err)
+		}
+		if got := getInterface(t, m, "1").BytesSent; got != tt.want {
+			t.Errorf("after %s BytesSent = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+
+	m.Reset()
+	for _, value := range []string{"500", "510"} {
+		if err := m.Process("Device.IP.Interface.1.Stats.BytesSent", value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := getInterface(t, m, "1").BytesSent; got != 10 {
+		t.Errorf("after Reset BytesSent = %d, want 10", got)
+	}
+}
+
+func TestCounterRate(t *testing.T) {
+	m := newCounterMapper(t)
+
+	if err := m.Process("Device.IP.Interface.1.Stats.Rate", "1000"); err != nil {
+		t.Fatal(err)
+	}
+	if got := getInterface(t, m, "1").Rate; got != 0 {
+		t.Fatalf("first sample set Rate = %v, want it left as baseline", got)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := m.Process("Device.IP.Interface.1.Stats.Rate", "2000"); err != nil {
+		t.Fatal(err)
+	}
+	if got := getInterface(t, m, "1").Rate; got <= 0 || got > 1000/0.02 {
+		t.Errorf("Rate = %v, want 1000 per elapsed second of at least 20ms", got)
+	}
+}
+
+func TestCounterInvalidValueIsSoft(t *testing.T) {
+	var soft []error
+	m := newCounterMapper(t, WithFastSoftErrorHandler(func(err error) { soft = append(soft, err) }))
+
+	if err := m.Process("Device.IP.Interface.1.Stats.BytesSent", "n/a"); err != nil {
+		t.Fatal(err)
+	}
+	var transformErr *transform.TransformError
+	if len(soft) != 1 || !errors.As(soft[0], &transformErr) || transformErr.Name != "float" {
+		t.Errorf("soft errors = %v, want one float TransformError", soft)
+	}
+}
+
+func TestCounterValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*FastRule)
+		errMsg string
+	}{
+		{"unknown mode", func(r *FastRule) { r.Counter = CounterMode(9) }, "unknown counter mode CounterMode(9)"},
+		{"transform", func(r *FastRule) { r.Transform = "int" }, "Counter and Transform"},
+		{"constant", func(r *FastRule) { r.SetConstant = 1 }, "Counter and SetConstant"},
+		{"json", func(r *FastRule) {
+			r.Field = ""
+			r.JSON = &JSONFanOut{Fields: map[string]string{"BytesSent": "tx"}}
+		}, "Counter cannot be used with a json fan-out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newCounterMapper(t)
+			rule := counterRule("bad", "BytesSent", CounterDelta)
+			tt.modify(rule)
+			err := m.AddRule(rule)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("AddRule error = %v, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestCounterNamesAreNotTransforms(t *testing.T) {
+	m := newCounterMapper(t)
+	rule := counterRule("legacy", "BytesSent", CounterOff)
+	rule.Transform = "delta"
+	if err := m.AddRule(rule); err == nil {
+		t.Error("AddRule accepted delta as a transform name")
+	}
+}
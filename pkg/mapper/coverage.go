@@ -0,0 +1,137 @@
+package mapper
+
+import (
+	"sort"
+	"sync"
+)
+
+type CoverageReport struct {
+	Rules          []RuleCoverage    `json:"rules"`
+	Fired          int               `json:"fired"`
+	Total          int               `json:"total"`
+	Unfired        []string          `json:"unfired"`
+	UnmatchedLines int64             `json:"unmatched_lines"`
+	Unmatched      []UnmatchedSample `json:"unmatched"`
+}
+
+type RuleCoverage struct {
+	ID       string            `json:"id"`
+	Pattern  string            `json:"pattern"`
+	Hits     int64             `json:"hits"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type UnmatchedSample struct {
+	Shape   string `json:"shape"`
+	Example string `json:"example"`
+	Count   int64  `json:"count"`
+}
+
+func (r CoverageReport) Ratio() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Fired) / float64(r.Total)
+}
+
+func WithCoverage(maxUnmatched int) FastOption {
+	return func(m *FastMapper) {
+		if maxUnmatched <= 0 {
+			maxUnmatched = 100
+		}
+		m.coverage = &coverageTracker{limit: maxUnmatched}
+	}
+}
+
+type coverageTracker struct {
+	mu        sync.Mutex
+	limit     int
+	hits      map[string]int64
+	unmatched map[string]*UnmatchedSample
+	missed    int64
+}
+
+func (c *coverageTracker) hit(ruleID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.hits == nil {
+		c.hits = make(map[string]int64)
+	}
+	c.hits[ruleID]++
+}
+
+func (c *coverageTracker) miss(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.missed++
+	shape := pathShape(path)
+	if sample, ok := c.unmatched[shape]; ok {
+		sample.Count++
+		return
+	}
+	if len(c.unmatched) >= c.limit {
+		return
+	}
+	if c.unmatched == nil {
+		c.unmatched = make(map[string]*UnmatchedSample)
+	}
+	c.unmatched[shape] = &UnmatchedSample{Shape: shape, Example: path, Count: 1}
+}
+
+func (c *coverageTracker) forget(ruleID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.hits, ruleID)
+}
+
+func (c *coverageTracker) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.hits = nil
+	c.unmatched = nil
+	c.missed = 0
+}
+
+func (m *FastMapper) CoverageReport() CoverageReport {
+	m.mu.RLock()
+	rules := make([]RuleCoverage, 0, len(m.rules))
+	for id, rule := range m.rules {
+		rules = append(rules, RuleCoverage{ID: id, Pattern: rule.Pattern.OriginalPath, Metadata: copyMetadata(rule.Metadata)})
+	}
+	m.mu.RUnlock()
+
+	report := CoverageReport{Total: len(rules), Unfired: []string{}, Unmatched: []UnmatchedSample{}}
+	if c := m.coverage; c != nil {
+		c.mu.Lock()
+		for i := range rules {
+			rules[i].Hits = c.hits[rules[i].ID]
+		}
+		for _, sample := range c.unmatched {
+			report.Unmatched = append(report.Unmatched, *sample)
+		}
+		report.UnmatchedLines = c.missed
+		c.mu.Unlock()
+	}
+
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	for _, rule := range rules {
+		if rule.Hits > 0 {
+			report.Fired++
+		} else {
+			report.Unfired = append(report.Unfired, rule.ID)
+		}
+	}
+	sort.Slice(report.Unmatched, func(i, j int) bool {
+		if report.Unmatched[i].Count != report.Unmatched[j].Count {
+			return report.Unmatched[i].Count > report.Unmatched[j].Count
+		}
+		return report.Unmatched[i].Shape < report.Unmatched[j].Shape
+	})
+
+	report.Rules = rules
+	return report
+}
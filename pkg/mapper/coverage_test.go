@@ -0,0 +1,167 @@
+package mapper
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCoverageReport(t *testing.T) {
+	m := newHostMapper(t, WithCoverage(0))
+	err := m.ProcessBatch([][2]string{
+		{"Device.Hosts.Host.1.HostName", "laptop"},
+		{"Device.Hosts.Host.2.HostName", "phone"},
+		{"Device.Hosts.Host.1.IPAddress", "10.0.0.1"},
+		{"Device.Hosts.Host.1.Layer2Interface", "eth0"},
+		{"Device.Hosts.Host.2.Layer2Interface", "eth1"},
+		{"Device.DeviceInfo.UpTime", "42"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := m.CoverageReport()
+	if report.Total != 4 || report.Fired != 2 {
+		t.Errorf("fired %d of %d rules, want 2 of 4", report.Fired, report.Total)
+	}
+	if got := report.Ratio(); got != 0.5 {
+		t.Errorf("Ratio = %v, want 0.5", got)
+	}
+	if want := []string{"host_Active", "host_MACAddress"}; !reflect.DeepEqual(report.Unfired, want) {
+		t.Errorf("Unfired = %v, want %v", report.Unfired, want)
+	}
+
+	hits := make(map[string]int64)
+	for i, rule := range report.Rules {
+		if i > 0 && report.Rules[i-1].ID > rule.ID {
+			t.Errorf("rules not sorted by ID: %s before %s", report.Rules[i-1].ID, rule.ID)
+		}
+		hits[rule.ID] = rule.Hits
+	}
+	if hits["host_HostName"] != 2 || hits["host_IPAddress"] != 1 {
+		t.Errorf("hits = %v", hits)
+	}
+	if report.Rules[0].Pattern != "Device.Hosts.Host.*.Active" {
+		t.Errorf("pattern = %q", report.Rules[0].Pattern)
+	}
+
+	if report.UnmatchedLines != 3 {
+		t.Errorf("UnmatchedLines = %d, want 3", report.UnmatchedLines)
+	}
+	want := []UnmatchedSample{
+		{Shape: "Device.Hosts.Host.*.Layer2Interface", Example: "Device.Hosts.Host.1.Layer2Interface", Count: 2},
+		{Shape: "Device.DeviceInfo.UpTime", Example: "Device.DeviceInfo.UpTime", Count: 1},
+	}
+	if !reflect.DeepEqual(report.Unmatched, want) {
+		t.Errorf("Unmatched = %+v, want %+v", report.Unmatched, want)
+	}
+}
+
+func TestCoverageReportGrouped(t *testing.T) {
+	m := newHostMapper(t, WithCoverage(0))
+	err := m.ProcessBatchGrouped([][2]string{
+		{"Device.Hosts.Host.1.HostName", "laptop"},
+		{"Device.Hosts.Host.1.Unmapped", "x"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := m.CoverageReport()
+	if report.Fired != 1 || report.UnmatchedLines != 1 {
+		t.Errorf("fired %d, unmatched %d; want 1 and 1", report.Fired, report.UnmatchedLines)
+	}
+}
+
+func TestCoverageUnmatchedLimit(t *testing.T) {
+	m := newHostMapper(t, WithCoverage(2))
+	for _, path := range []string{"A.1.X", "B.Y", "C.Z", "A.2.X", "C.Z"} {
+		if err := m.Process(path, "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := m.CoverageReport()
+	if report.UnmatchedLines != 5 {
+		t.Errorf("UnmatchedLines = %d, want 5", report.UnmatchedLines)
+	}
+	want := []UnmatchedSample{
+		{Shape: "A.*.X", Example: "A.1.X", Count: 2},
+		{Shape: "B.Y", Example: "B.Y", Count: 1},
+	}
+	if !reflect.DeepEqual(report.Unmatched, want) {
+		t.Errorf("Unmatched = %+v, want %+v", report.Unmatched, want)
+	}
+}
+
+func TestCoverageForgetsAndResets(t *testing.T) {
+	m := newHostMapper(t, WithCoverage(0))
+	if err := m.Process("Device.Hosts.Host.1.HostName", "laptop"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RemoveRule("host_HostName"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddRule(hostRule("host_HostName", "HostName")); err != nil {
+		t.Fatal(err)
+	}
+	if report := m.CoverageReport(); report.Fired != 0 {
+		t.Errorf("re-added rule kept %d fired rules", report.Fired)
+	}
+
+	if err := m.Process("Device.Hosts.Host.1.HostName", "laptop"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Process("Device.Unknown", "x"); err != nil {
+		t.Fatal(err)
+	}
+	m.Reset()
+	report := m.CoverageReport()
+	if report.Fired != 0 || report.UnmatchedLines != 0 || len(report.Unmatched) != 0 {
+		t.Errorf("report after Reset = %+v", report)
+	}
+}
+
+func TestCoverageReportWithoutTracking(t *testing.T) {
+	m := newHostMapper(t)
+	if err := m.Process("Device.Hosts.Host.1.HostName", "laptop"); err != nil {
+		t.Fatal(err)
+	}
+
+	report := m.CoverageReport()
+	if report.Total != 4 || report.Fired != 0 || len(report.Unfired) != 4 {
+		t.Errorf("report = %+v", report)
+	}
+	if got := (CoverageReport{}).Ratio(); got != 0 {
+		t.Errorf("empty Ratio = %v, want 0", got)
+	}
+}
+
+func TestCoverageReportJSON(t *testing.T) {
+	m := newHostMapper(t, WithCoverage(0))
+	if err := m.Process("Device.Hosts.Host.1.HostName", "laptop"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(m.CoverageReport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded CoverageReport
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, m.CoverageReport()) {
+		t.Errorf("round trip = %+v, want %+v", decoded, m.CoverageReport())
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"rules", "fired", "total", "unfired", "unmatched_lines", "unmatched"} {
+		if _, ok := raw[key]; !ok {
+			t.Errorf("JSON has no %q key: %s", key, data)
+		}
+	}
+}
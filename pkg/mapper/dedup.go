@@ -0,0 +1,43 @@
+package mapper
+
+func WithBatchDedup(limit int) FastOption {
+	return func(m *FastMapper) {
+		if limit <= 0 {
+			limit = 4096
+		}
+		m.dedupLimit = limit
+	}
+}
+
+func (m *FastMapper) dedupBatch(items [][2]string) [][2]string {
+	if m.dedupLimit == 0 || len(items) < 2 {
+		return items
+	}
+
+	last := make(map[string]string, min(len(items), m.dedupLimit))
+	var kept [][2]string
+	var dropped int64
+	for i, item := range items {
+		if value, ok := last[item[0]]; ok && value == item[1] {
+			if kept == nil {
+				kept = append(make([][2]string, 0, len(items)-1), items[:i]...)
+			}
+			dropped++
+			continue
+		}
+		if _, ok := last[item[0]]; ok || len(last) < m.dedupLimit {
+			last[item[0]] = item[1]
+		}
+		if kept != nil {
+			kept = append(kept, item)
+		}
+	}
+
+	if kept == nil {
+		return items
+	}
+	if m.stats != nil {
+		m.stats.DuplicateLines.Add(dropped)
+	}
+	return kept
+}
@@ -0,0 +1,79 @@
+package mapper
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+)
+
+func TestDedupBatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		items [][2]string
+		want  [][2]string
+	}{
+		{
+			name:  "repeated value dropped",
+			limit: 10,
+			items: [][2]string{{"A", "v1"}, {"B", "x"}, {"A", "v1"}},
+			want:  [][2]string{{"A", "v1"}, {"B", "x"}},
+		},
+		{
+			name:  "changed and changed back kept",
+			limit: 10,
+			items: [][2]string{{"A", "v1"}, {"A", "v2"}, {"A", "v1"}},
+			want:  [][2]string{{"A", "v1"}, {"A", "v2"}, {"A", "v1"}},
+		},
+		{
+			name:  "consecutive repeats collapse",
+			limit: 10,
+			items: [][2]string{{"A", "v1"}, {"A", "v1"}, {"A", "v2"}, {"A", "v2"}},
+			want:  [][2]string{{"A", "v1"}, {"A", "v2"}},
+		},
+		{
+			name:  "paths beyond limit not tracked",
+			limit: 1,
+			items: [][2]string{{"A", "v1"}, {"B", "x"}, {"B", "x"}, {"A", "v1"}},
+			want:  [][2]string{{"A", "v1"}, {"B", "x"}, {"B", "x"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewFast(registry.New(), WithBatchDedup(tt.limit), WithFastStats())
+			got := m.dedupBatch(tt.items)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("dedupBatch = %v, want %v", got, tt.want)
+			}
+			if dropped := m.GetStats().DuplicateLines.Load(); dropped != int64(len(tt.items)-len(tt.want)) {
+				t.Errorf("DuplicateLines = %d, want %d", dropped, len(tt.items)-len(tt.want))
+			}
+		})
+	}
+}
+
+func TestDuplicateLinesStatsResetAndString(t *testing.T) {
+	m := newHostMapper(t, WithBatchDedup(0), WithFastStats())
+	err := m.ProcessBatch([][2]string{
+		{"Device.Hosts.Host.1.HostName", "a"},
+		{"Device.Hosts.Host.1.HostName", "a"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := m.GetStats()
+	if got := stats.DuplicateLines.Load(); got != 1 {
+		t.Fatalf("DuplicateLines = %d, want 1", got)
+	}
+	if s := stats.String(); !strings.Contains(s, "1 duplicate") {
+		t.Errorf("String() = %q, want duplicate count", s)
+	}
+
+	m.Reset()
+	if got := stats.DuplicateLines.Load(); got != 0 {
+		t.Errorf("DuplicateLines after Reset = %d, want 0", got)
+	}
+}
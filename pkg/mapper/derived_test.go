@@ -0,0 +1,66 @@
+package mapper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+)
+
+func TestDerivedOnlyForTouchedEntities(t *testing.T) {
+	reg := registry.New()
+	reg.MustRegister("WiFi", func() any { return &TestWifi{} })
+	m := New(reg)
+	err := m.LoadRulesFromString(`
+version: "1.0"
+rules:
+  - name: wifi
+    target: WiFi
+    route: 'path.endsWith(".SSID")'
+    entity_key: 'path.split(".")[3]'
+    fields:
+      - name: SSID
+        when: 'true'
+        value: 'value'
+    derived:
+      - name: Password
+        value: 'entity.SSID + "-key"'
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wifi := func(key string) *TestWifi {
+		t.Helper()
+		obj, ok := m.GetStore().Get("WiFi", key)
+		if !ok {
+			t.Fatalf("WiFi %q not stored", key)
+		}
+		return obj.(*TestWifi)
+	}
+
+	if err := m.ProcessBatch([][2]string{{"Device.WiFi.SSID.1.SSID", "home"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := wifi("1").Password; got != "home-key" {
+		t.Fatalf("Password = %q, want home-key", got)
+	}
+
+	wifi("1").Password = "manual"
+	if err := m.ProcessMap(map[string]string{"Device.WiFi.SSID.2.SSID": "guest"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := wifi("2").Password; got != "guest-key" {
+		t.Errorf("touched Password = %q, want guest-key", got)
+	}
+	if got := wifi("1").Password; got != "manual" {
+		t.Errorf("untouched Password = %q, want it left alone", got)
+	}
+
+	if err := m.ApplyDerived(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := wifi("1").Password; got != "home-key" {
+		t.Errorf("ApplyDerived Password = %q, want home-key", got)
+	}
+}
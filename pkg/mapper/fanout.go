@@ -0,0 +1,168 @@
+package mapper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+)
+
+type JSONFanOut struct {
+	KeyField string
+	Fields   map[string]string
+}
+
+func (m *FastMapper) validateJSON(rule *FastRule) error {
+	if len(rule.JSON.Fields) == 0 {
+		return fmt.Errorf("rule %s: json fan-out requires at least one field mapping", rule.ID)
+	}
+	for _, option := range []struct {
+		name string
+		set  bool
+	}{
+		{"Field", rule.Field != ""},
+		{"Transform", rule.Transform != ""},
+		{"Counter", rule.Counter != CounterOff},
+		{"KeyTransform", rule.KeyTransform != ""},
+		{"Precedence", rule.Precedence != 0},
+		{"SetConstant", rule.SetConstant != nil},
+	} {
+		if option.set {
+			return fmt.Errorf("rule %s: %s cannot be used with a json fan-out", rule.ID, option.name)
+		}
+	}
+
+	info, err := m.registry.Get(rule.Entity)
+	if err != nil {
+		return fmt.Errorf("rule %s: %w", rule.ID, err)
+	}
+	for field := range rule.JSON.Fields {
+		if _, ok := info.Setters[field]; !ok {
+			return fmt.Errorf("rule %s: entity %s has no field %s", rule.ID, rule.Entity, field)
+		}
+	}
+
+	if rule.Extractor == nil && rule.ExtractorSpec == "" && rule.JSON.KeyField != "" {
+		rule.Extractor = &extractor.StaticExtractor{}
+	}
+	return nil
+}
+
+func (m *FastMapper) applyJSON(line resolvedLine) lineResult {
+	rule := line.rule
+
+	var decoded any
+	if err := json.Unmarshal([]byte(line.value), &decoded); err != nil {
+		return m.fanOutFailed(rule.failure(line.key, "", fmt.Errorf("invalid json value: %w", err)))
+	}
+
+	var elements []any
+	switch v := decoded.(type) {
+	case []any:
+		elements = v
+	case map[string]any:
+		elements = []any{v}
+	default:
+		return m.fanOutFailed(rule.failure(line.key, "", fmt.Errorf("json value must be an array or object, got %T", decoded)))
+	}
+
+	info, err := m.registry.Get(rule.Entity)
+	if err != nil {
+		return m.fanOutFailed(rule.failure(line.key, "", err))
+	}
+
+	result := lineMatched
+	for i, element := range elements {
+		object, ok := element.(map[string]any)
+		if !ok {
+			result = m.fanOutFailed(rule.failure(line.key, "", fmt.Errorf("element %d is %T, not an object", i, element)))
+			continue
+		}
+
+		key, err := m.elementKey(line, object, i)
+		if err != nil {
+			result = m.fanOutFailed(err)
+			continue
+		}
+
+		if m.applyElement(line, info, key, object) == lineFailed {
+			result = lineFailed
+		}
+	}
+	return result
+}
+
+func (m *FastMapper) elementKey(line resolvedLine, object map[string]any, i int) (string, error) {
+	keyField := line.rule.JSON.KeyField
+	if keyField == "" {
+		return line.key + "." + strconv.Itoa(i+1), nil
+	}
+
+	var key string
+	switch v := object[keyField].(type) {
+	case string:
+		key = v
+	case float64:
+		key = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		key = strconv.FormatBool(v)
+	default:
+		return "", line.rule.failure(line.key, "", fmt.Errorf("element %d has no usable key field %s", i, keyField))
+	}
+
+	if line.key == line.prefix {
+		return line.prefix + key, nil
+	}
+	return line.key + "." + key, nil
+}
+
+func (m *FastMapper) applyElement(line resolvedLine, info *registry.TypeInfo, key string, object map[string]any) lineResult {
+	rule := line.rule
+	if m.locker != nil {
+		unlock := m.locker.LockEntity(rule.target(), key)
+		defer unlock()
+	}
+
+	obj, err := m.acquire(rule, key)
+	if err != nil {
+		if !errors.Is(err, ErrEntityLimit) {
+			err = rule.failure(key, "", err)
+		}
+		return m.fanOutFailed(err)
+	}
+	if m.sources != nil {
+		m.sources.record(rule.target(), key, line)
+	}
+
+	result := lineMatched
+	for field, jsonField := range rule.JSON.Fields {
+		value, ok := object[jsonField]
+		if !ok || value == nil || !line.allows(field) {
+			continue
+		}
+		if err := info.Setters[field](obj, value); err != nil {
+			if m.valueFailed(rule.failure(key, field, fmt.Errorf("setter failed: %w", err))) == lineFailed {
+				result = lineFailed
+			}
+			continue
+		}
+		if m.lastSeen {
+			touch(info, obj)
+		}
+		if m.recorder != nil {
+			m.recorder.Record(rule.target(), key, field, value)
+		}
+	}
+	return result
+}
+
+func (m *FastMapper) fanOutFailed(err error) lineResult {
+	if m.stats != nil {
+		m.stats.FailedRules.Add(1)
+	}
+	m.errorHandler(err)
+	return lineFailed
+}
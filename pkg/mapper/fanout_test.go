@@ -0,0 +1,139 @@
+package mapper
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+)
+
+func clientsRule(keyField string) *FastRule {
+	return &FastRule{
+		ID:        "clients",
+		Pattern:   router.CompilePattern("Device.X_Vendor.Radio.*.Clients"),
+		Entity:    "host",
+		Extractor: &extractor.IndexExtractor{Position: 3},
+		JSON: &JSONFanOut{
+			KeyField: keyField,
+			Fields:   map[string]string{"MACAddress": "mac", "HostName": "name"},
+		},
+	}
+}
+
+func storedHosts(m *FastMapper) string {
+	var got []string
+	for key, obj := range m.GetStore().GetAll("host") {
+		host := obj.(*TestHost)
+		got = append(got, key+"="+host.MACAddress+"/"+host.HostName)
+	}
+	sort.Strings(got)
+	return strings.Join(got, " ")
+}
+
+func TestJSONFanOutArrayByIndex(t *testing.T) {
+	m := newHostMapper(t)
+	if err := m.AddRule(clientsRule("")); err != nil {
+		t.Fatal(err)
+	}
+
+	value := `[{"mac":"aa:00","name":"a"},{"mac":"bb:00","name":"b","extra":1}]`
+	if err := m.Process("Device.X_Vendor.Radio.1.Clients", value); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := storedHosts(m), "1.1=aa:00/a 1.2=bb:00/b"; got != want {
+		t.Errorf("stored %q, want %q", got, want)
+	}
+}
+
+func TestJSONFanOutObject(t *testing.T) {
+	m := newHostMapper(t)
+	if err := m.AddRule(clientsRule("")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Process("Device.X_Vendor.Radio.4.Clients", `{"mac":"aa:00","name":null}`); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := storedHosts(m), "4.1=aa:00/"; got != want {
+		t.Errorf("stored %q, want %q", got, want)
+	}
+}
+
+func TestJSONFanOutKeyFieldScopedToParent(t *testing.T) {
+	m := newHostMapper(t)
+	if err := m.AddRule(clientsRule("mac")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Process("Device.X_Vendor.Radio.1.Clients", `[{"mac":"aa:00","name":"2g"}]`); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Process("Device.X_Vendor.Radio.2.Clients", `[{"mac":"aa:00","name":"5g"}]`); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := storedHosts(m), "1.aa:00=aa:00/2g 2.aa:00=aa:00/5g"; got != want {
+		t.Errorf("stored %q, want %q", got, want)
+	}
+}
+
+func TestJSONFanOutKeyFieldWithoutExtractor(t *testing.T) {
+	m := newHostMapper(t, WithFastKeyPrefix("cpe1/"))
+	rule := clientsRule("mac")
+	rule.Extractor = nil
+	if err := m.AddRule(rule); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Process("Device.X_Vendor.Radio.1.Clients", `[{"mac":"aa:00","name":"a"}]`); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := storedHosts(m), "cpe1/aa:00=aa:00/a"; got != want {
+		t.Errorf("stored %q, want %q", got, want)
+	}
+}
+
+func TestJSONFanOutReportsMalformedValues(t *testing.T) {
+	var errs []error
+	m := newHostMapper(t, WithFastErrorHandler(func(err error) { errs = append(errs, err) }))
+	if err := m.AddRule(clientsRule("mac")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, value := range []string{`not json`, `"text"`, `[1]`, `[{"name":"no key"}]`} {
+		if err := m.Process("Device.X_Vendor.Radio.1.Clients", value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(errs) != 4 {
+		t.Errorf("got %d errors, want 4: %v", len(errs), errs)
+	}
+	if got := storedHosts(m); got != "" {
+		t.Errorf("stored %q from malformed values", got)
+	}
+}
+
+func TestJSONFanOutRejectsIgnoredOptions(t *testing.T) {
+	tests := []struct {
+		option string
+		modify func(*FastRule)
+	}{
+		{"Field", func(r *FastRule) { r.Field = "HostName" }},
+		{"Transform", func(r *FastRule) { r.Transform = "lower" }},
+		{"KeyTransform", func(r *FastRule) { r.KeyTransform = "lower" }},
+		{"Precedence", func(r *FastRule) { r.Precedence = 1 }},
+		{"SetConstant", func(r *FastRule) { r.SetConstant = "x" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.option, func(t *testing.T) {
+			m := newHostMapper(t)
+			rule := clientsRule("mac")
+			tt.modify(rule)
+			err := m.AddRule(rule)
+			if err == nil || !strings.Contains(err.Error(), tt.option+" cannot be used with a json fan-out") {
+				t.Errorf("AddRule error = %v", err)
+			}
+		})
+	}
+}
@@ -2,11 +2,16 @@ package mapper
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/metalgrid/tr069-cel-mapper/pkg/datamodel"
 	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
 	"github.com/metalgrid/tr069-cel-mapper/pkg/pool"
 	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
@@ -16,24 +21,70 @@ import (
 )
 
 type FastRule struct {
-	ID        string
-	Pattern   *router.Pattern
-	Entity    string
-	Field     string
-	Transform string
-	Extractor extractor.KeyExtractor
+	ID            string
+	Pattern       *router.Pattern
+	Entity        string
+	Field         string
+	Transform     string
+	Counter       CounterMode
+	KeyTransform  string
+	Extractor     extractor.KeyExtractor
+	ExtractorSpec string
+	Precedence    int
+	JSON          *JSONFanOut
+	Split         *SplitFanOut
+	SetConstant   any
+	StoreTarget   string
+	Metadata      map[string]string
+}
+
+func (r *FastRule) target() string {
+	if r.StoreTarget != "" {
+		return r.StoreTarget
+	}
+	return r.Entity
 }
 
 type FastMapper struct {
-	router      *router.FastRouter
+	router      router.Router
+	partsRouter router.PartsRouter
 	rules       map[string]*FastRule
+	targets     map[string]string
 	registry    *registry.Registry
 	store       types.Store
+	locker      types.EntityLocker
+	recorder    types.FieldRecorder
 	objectPool  *pool.ObjectPool
 	transformer *transform.FastTransform
 
-	stats        *FastStats
-	errorHandler func(error)
+	stats            *FastStats
+	errorHandler     func(error)
+	softErrorHandler func(error)
+	tracer           Tracer
+	logger           Logger
+
+	lenientTransforms bool
+	trimmedAsKey      bool
+	recoverPanics     bool
+	lastSeen          bool
+	closed            atomic.Bool
+	pathFilter        func(path string) bool
+	inferred          map[reflect.Kind]string
+	attributeHandler  func(param, attribute, value string)
+
+	candidates    candidateTracker
+	counters      counterTracker
+	maxEntities   map[string]*entityLimit
+	maxPathLength int
+	dedupLimit    int
+	ordered       bool
+	typeCheck     *typeChecker
+	keyPrefix     func(path, value string) string
+	pathTrim      func(path string) (string, string)
+	required      requiredFields
+	unmatched     unmatchedReporter
+	coverage      *coverageTracker
+	sources       *sourceTracker
 
 	mu sync.RWMutex
 }
@@ -41,7 +92,10 @@ type FastMapper struct {
 type FastStats struct {
 	ProcessedLines  atomic.Int64
 	MatchedRules    atomic.Int64
+	UnmatchedLines  atomic.Int64
 	FailedRules     atomic.Int64
+	SkippedValues   atomic.Int64
+	DuplicateLines  atomic.Int64
 	CacheHits       atomic.Int64
 	CacheMisses     atomic.Int64
 	AllocCount      atomic.Int64
@@ -63,10 +117,57 @@ func WithFastErrorHandler(handler func(error)) FastOption {
 	}
 }
 
+func WithFastStore(store types.Store) FastOption {
+	return func(m *FastMapper) {
+		m.store = store
+	}
+}
+
+func WithLenientTransforms() FastOption {
+	return func(m *FastMapper) {
+		m.lenientTransforms = true
+	}
+}
+
+func WithRouter(r router.Router) FastOption {
+	return func(m *FastMapper) {
+		m.router = r
+	}
+}
+
+func WithPathFilter(keep func(path string) bool) FastOption {
+	return func(m *FastMapper) {
+		m.pathFilter = keep
+	}
+}
+
+func SkipPathPrefixes(prefixes ...string) func(path string) bool {
+	return func(path string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(path, prefix) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func AllowPathPrefixes(prefixes ...string) func(path string) bool {
+	return func(path string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 func NewFast(reg *registry.Registry, opts ...FastOption) *FastMapper {
 	m := &FastMapper{
 		router:       router.New(),
 		rules:        make(map[string]*FastRule),
+		targets:      make(map[string]string),
 		registry:     reg,
 		store:        types.NewMapStore(),
 		objectPool:   pool.New(),
@@ -78,21 +179,170 @@ func NewFast(reg *registry.Registry, opts ...FastOption) *FastMapper {
 		opt(m)
 	}
 
+	m.partsRouter, _ = m.router.(router.PartsRouter)
+	m.locker, _ = m.store.(types.EntityLocker)
+	m.recorder, _ = m.store.(types.FieldRecorder)
+
+	canonical := make(map[*registry.TypeInfo]string)
 	for _, typeName := range reg.List() {
 		info, _ := reg.Get(typeName)
+		if existing, ok := canonical[info]; ok {
+			m.objectPool.RegisterAlias(typeName, existing)
+			continue
+		}
+		canonical[info] = typeName
 		m.objectPool.Register(typeName, info.Factory)
 	}
 
 	return m
 }
 
-func (m *FastMapper) AddRule(rule *FastRule) {
+func (m *FastMapper) AddRule(rule *FastRule) error {
+	return m.AddRules([]*FastRule{rule})
+}
+
+func (m *FastMapper) AddRules(rules []*FastRule) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	rule.Pattern.ID = rule.ID
-	m.router.AddPattern(rule.Pattern)
-	m.rules[rule.ID] = rule
+	ids := make(map[string]bool, len(rules))
+	pending := make(map[string]string)
+	validated := make([]*FastRule, len(rules))
+	for i, rule := range rules {
+		if _, ok := m.rules[rule.ID]; ok || ids[rule.ID] {
+			return fmt.Errorf("rule %s: duplicate rule ID", rule.ID)
+		}
+		ids[rule.ID] = true
+		if err := m.checkTarget(rule); err != nil {
+			return err
+		}
+		if entity, ok := pending[rule.target()]; ok && entity != rule.Entity {
+			return fmt.Errorf("rule %s: store target %s already maps to entity %s", rule.ID, rule.target(), entity)
+		}
+		pending[rule.target()] = rule.Entity
+
+		copied, err := m.validatedCopy(rule)
+		if err != nil {
+			return err
+		}
+		validated[i] = copied
+	}
+	rules = validated
+
+	patterns := make([]*router.Pattern, len(rules))
+	for i, rule := range rules {
+		m.targets[rule.target()] = rule.Entity
+		rule.Pattern.ID = rule.ID
+		patterns[i] = rule.Pattern
+		m.rules[rule.ID] = rule
+	}
+	if batch, ok := m.router.(router.BatchRouter); ok {
+		batch.AddPatterns(patterns)
+	} else {
+		for _, p := range patterns {
+			m.router.AddPattern(p)
+		}
+	}
+	return nil
+}
+
+func (m *FastMapper) validatedCopy(rule *FastRule) (*FastRule, error) {
+	if rule.Pattern == nil {
+		return nil, fmt.Errorf("rule %s: pattern is required", rule.ID)
+	}
+	copied := *rule
+	pattern := *rule.Pattern
+	copied.Pattern = &pattern
+	if err := m.validateRule(&copied); err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
+
+func (m *FastMapper) checkTarget(rule *FastRule) error {
+	if entity, ok := m.targets[rule.target()]; ok && entity != rule.Entity {
+		return fmt.Errorf("rule %s: store target %s already maps to entity %s", rule.ID, rule.target(), entity)
+	}
+	return nil
+}
+
+func (m *FastMapper) targetType(target string) (*registry.TypeInfo, error) {
+	m.mu.RLock()
+	entity, ok := m.targets[target]
+	m.mu.RUnlock()
+	if !ok {
+		entity = target
+	}
+	return m.registry.Get(entity)
+}
+
+func (m *FastMapper) validateRule(rule *FastRule) error {
+	m.inferTransform(rule)
+	if rule.Split != nil {
+		if err := m.validateSplit(rule); err != nil {
+			return err
+		}
+	}
+	if rule.JSON != nil {
+		if err := m.validateJSON(rule); err != nil {
+			return err
+		}
+	}
+
+	if rule.Extractor == nil {
+		if rule.ExtractorSpec == "" {
+			return fmt.Errorf("rule %s: extractor is required", rule.ID)
+		}
+		ext, err := extractor.CompileExtractorStrict(rule.ExtractorSpec)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+		rule.Extractor = ext
+	}
+
+	if rule.SetConstant != nil && rule.Transform != "" {
+		return fmt.Errorf("rule %s: SetConstant and Transform are mutually exclusive", rule.ID)
+	}
+	if rule.Counter != CounterOff {
+		if err := m.validateCounter(rule); err != nil {
+			return err
+		}
+	}
+	if rule.Transform != "" && !m.lenientTransforms {
+		if _, err := transform.Compile(rule.Transform); err != nil {
+			return fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+	}
+	if rule.KeyTransform != "" && !m.lenientTransforms {
+		if _, err := transform.Compile(rule.KeyTransform); err != nil {
+			return fmt.Errorf("rule %s: invalid key transform: %w", rule.ID, err)
+		}
+	}
+	return nil
+}
+
+func (m *FastMapper) ValidatePatterns(dm *datamodel.DataModel) []error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make([]string, 0, len(m.rules))
+	for id := range m.rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var errs []error
+	for _, id := range ids {
+		path := m.rules[id].Pattern.OriginalPath
+		leaf := path[strings.LastIndexByte(path, '.')+1:]
+		switch {
+		case !dm.HasLeaf(leaf):
+			errs = append(errs, fmt.Errorf("rule %s: parameter %s does not exist in data model %s", id, leaf, dm.Name))
+		case !dm.HasParameter(path):
+			errs = append(errs, fmt.Errorf("rule %s: pattern %s matches no parameter in data model %s", id, path, dm.Name))
+		}
+	}
+	return errs
 }
 
 func (m *FastMapper) Process(path, value string) error {
@@ -100,30 +350,242 @@ func (m *FastMapper) Process(path, value string) error {
 }
 
 func (m *FastMapper) ProcessContext(ctx context.Context, path, value string) error {
+	_, err := m.processLine(ctx, path, value)
+	return err
+}
+
+func (m *FastMapper) ProcessParts(parts []string, value string) error {
+	return m.ProcessPartsContext(context.Background(), parts, value)
+}
+
+func (m *FastMapper) ProcessPartsContext(ctx context.Context, parts []string, value string) error {
+	var path string
+	if m.needsPath() {
+		path = router.JoinParts(parts)
+	}
+	_, _, err := m.processResolved(ctx, path, parts, value)
+	return err
+}
+
+func (m *FastMapper) needsPath() bool {
+	return m.partsRouter == nil || m.maxPathLength > 0 || m.pathTrim != nil || m.pathFilter != nil ||
+		m.attributeHandler != nil || m.keyPrefix != nil || m.logger != nil || m.sources != nil
+}
+
+func (m *FastMapper) processLine(ctx context.Context, path, value string) (lineResult, error) {
+	_, result, err := m.processResolved(ctx, path, nil, value)
+	return result, err
+}
+
+func (m *FastMapper) processResolved(ctx context.Context, path string, parts []string, value string) (resolvedLine, lineResult, error) {
+	if m.closed.Load() {
+		return resolvedLine{}, lineFailed, ErrClosed
+	}
+	if m.recoverPanics {
+		return m.processRecovering(ctx, path, parts, value)
+	}
+	return m.processUnguarded(ctx, path, parts, value)
+}
+
+func (m *FastMapper) processUnguarded(ctx context.Context, path string, parts []string, value string) (resolvedLine, lineResult, error) {
+	if m.rejectPath(path) {
+		return resolvedLine{}, lineFailed, nil
+	}
+	path, stripped := m.trimPath(path)
+	if stripped != "" {
+		parts = nil
+	}
+	if m.skipPath(path, value) {
+		return resolvedLine{}, lineUnmatched, nil
+	}
+
 	start := time.Now()
+	if m.stats != nil {
+		defer func() {
+			m.stats.ProcessedLines.Add(1)
+			m.stats.ProcessingNanos.Add(time.Since(start).Nanoseconds())
+		}()
+	}
 
-	pattern, matched := m.router.Route(path)
+	line, matched, err := m.resolve(ctx, path, parts, value, stripped)
+	if err != nil {
+		return line, lineFailed, err
+	}
 	if !matched {
 		if m.stats != nil {
-			m.stats.CacheMisses.Add(1)
+			m.stats.UnmatchedLines.Add(1)
 		}
-		return nil
+		if path == "" && (m.unmatched.enabled || m.coverage != nil) {
+			path = router.JoinParts(parts)
+		}
+		m.reportUnmatched(path)
+		if m.coverage != nil {
+			m.coverage.miss(path)
+		}
+		return line, lineUnmatched, nil
+	}
+	line.fields = allowedFields(ctx)
+	if !line.allowsRule() {
+		return line, lineUnmatched, nil
 	}
 
 	if m.stats != nil {
 		m.stats.MatchedRules.Add(1)
+	}
+	if m.logger != nil {
+		m.logger.Debug("rule matched", "rule", line.rule.ID, "path", path, "key", line.key)
+	}
+	if m.coverage != nil {
+		m.coverage.hit(line.rule.ID)
+	}
+
+	result := lineMatched
+	if m.tracer != nil {
+		var span Span
+		_, span = m.tracer.StartRule(ctx, line.rule.ID)
 		defer func() {
-			m.stats.ProcessedLines.Add(1)
-			m.stats.ProcessingNanos.Add(time.Since(start).Nanoseconds())
+			span.End(result.matched(), result.failed(), nil)
 		}()
 	}
 
+	result, err = m.applyResolved(line)
+	return line, result, err
+}
+
+func (m *FastMapper) applyResolved(line resolvedLine) (lineResult, error) {
+	if line.rule.fansOut() {
+		return m.applyFanOut(line), nil
+	}
+
+	if m.locker != nil {
+		unlock := m.locker.LockEntity(line.rule.target(), line.key)
+		defer unlock()
+	}
+
+	obj, err := m.acquire(line.rule, line.key)
+	if errors.Is(err, ErrEntityLimit) {
+		if m.stats != nil {
+			m.stats.FailedRules.Add(1)
+		}
+		m.errorHandler(err)
+		return lineFailed, nil
+	}
+	if err != nil {
+		return lineFailed, err
+	}
+
+	return m.apply(line, obj), nil
+}
+
+type resolvedLine struct {
+	ctx      context.Context
+	rule     *FastRule
+	path     string
+	stripped string
+	prefix   string
+	key      string
+	value    string
+	fields   map[string]bool
+	pre      *pretransformed
+}
+
+func (m *FastMapper) resolve(ctx context.Context, path string, parts []string, value, stripped string) (resolvedLine, bool, error) {
+	pattern, matched := m.route(path, parts)
+	if !matched {
+		return resolvedLine{}, false, nil
+	}
+
 	rule, ok := m.rules[pattern.ID]
 	if !ok {
-		return fmt.Errorf("rule not found: %s", pattern.ID)
+		return resolvedLine{}, false, fmt.Errorf("rule not found: %s", pattern.ID)
+	}
+
+	if path == "" && needsJoinedPath(rule.Extractor) {
+		path = router.JoinParts(parts)
+	}
+
+	var captures []string
+	usesCaptures := extractor.UsesCaptures(rule.Extractor)
+	if usesCaptures {
+		if parts != nil {
+			captures = router.PartsCaptures(parts, pattern)
+		} else {
+			captures = router.Captures(path, pattern)
+		}
 	}
 
-	key := rule.Extractor.Extract(path, value)
+	var key string
+	switch ce := rule.Extractor.(type) {
+	case extractor.ContextCaptureExtractor:
+		if usesCaptures {
+			key = ce.ExtractContextCaptures(ctx, captures, parts, path, value)
+		} else {
+			key = ce.ExtractContext(ctx, parts, path, value)
+		}
+	case extractor.ContextExtractor:
+		key = ce.ExtractContext(ctx, parts, path, value)
+	case extractor.CaptureExtractor:
+		if usesCaptures {
+			key = ce.ExtractCaptures(captures, path, value)
+		} else {
+			key = extractor.ExtractParts(ce, parts, path, value)
+		}
+	default:
+		key = extractor.ExtractParts(rule.Extractor, parts, path, value)
+	}
+	if rule.KeyTransform != "" {
+		key = m.transformKey(ctx, rule.KeyTransform, key)
+	}
+
+	var prefix string
+	if m.keyPrefix != nil {
+		prefix = m.keyPrefix(path, value)
+	}
+	if m.trimmedAsKey {
+		prefix += stripped
+	}
+
+	return resolvedLine{ctx: ctx, rule: rule, path: path, stripped: stripped, prefix: prefix, key: prefix + key, value: value}, true, nil
+}
+
+func needsJoinedPath(e extractor.KeyExtractor) bool {
+	switch e := e.(type) {
+	case extractor.ContextExtractor:
+		return true
+	case *extractor.TransformExtractor:
+		return needsJoinedPath(e.Inner)
+	case extractor.CaptureExtractor, extractor.PartsExtractor:
+		return false
+	}
+	return true
+}
+
+func (m *FastMapper) route(path string, parts []string) (*router.Pattern, bool) {
+	if m.partsRouter != nil {
+		return m.partsRouter.RouteParts(path, parts)
+	}
+	return m.router.Route(path)
+}
+
+func (m *FastMapper) transformKey(ctx context.Context, name, key string) string {
+	transformed, _, err := m.transformer.LookupContext(ctx, name, key)
+	if err != nil {
+		return key
+	}
+	if s, ok := transformed.(string); ok {
+		return s
+	}
+	return fmt.Sprint(transformed)
+}
+
+func (m *FastMapper) acquire(rule *FastRule, key string) (any, error) {
+	unlock, err := m.reserveEntity(rule.target(), key)
+	if err != nil {
+		return nil, err
+	}
+	if unlock != nil {
+		defer unlock()
+	}
 
 	var obj any
 	if m.objectPool != nil {
@@ -138,7 +600,7 @@ func (m *FastMapper) ProcessContext(ctx context.Context, path, value string) err
 	if obj == nil {
 		info, err := m.registry.Get(rule.Entity)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		obj = info.Factory()
 		if m.stats != nil {
@@ -146,46 +608,131 @@ func (m *FastMapper) ProcessContext(ctx context.Context, path, value string) err
 		}
 	}
 
-	existing := m.store.Upsert(rule.Entity, key, func() any {
+	existing := m.store.Upsert(rule.target(), key, func() any {
 		return obj
 	})
 
 	if existing != obj && m.objectPool != nil {
 		m.objectPool.Put(rule.Entity, obj)
 		obj = existing
+	} else if existing == obj {
+		m.entityCreated(rule.target())
+		if m.logger != nil {
+			m.logger.Info("entity created", "target", rule.target(), "key", key)
+		}
 	}
 
+	return obj, nil
+}
+
+func (m *FastMapper) apply(line resolvedLine, obj any) lineResult {
+	rule, value := line.rule, line.value
+	if m.sources != nil {
+		m.sources.record(rule.target(), line.key, line)
+	}
+	if rule.Precedence > 0 {
+		return m.applyCandidate(line, obj)
+	}
+	if line.pre != nil {
+		return m.applyTransformed(rule, line.key, obj, value, line.pre.value, line.pre.hit, line.pre.err)
+	}
+	return m.applyValue(line.ctx, rule, line.key, obj, value)
+}
+
+func (m *FastMapper) applyValue(ctx context.Context, rule *FastRule, key string, obj any, value string) lineResult {
 	var finalValue any = value
-	if rule.Transform != "" {
-		transformed, err := m.transformer.Transform(rule.Transform, value)
+	if rule.SetConstant != nil {
+		finalValue = rule.SetConstant
+	} else if rule.Counter != CounterOff {
+		counter, ok, err := m.counterValue(rule, key, value)
 		if err != nil {
-			if m.stats != nil {
-				m.stats.FailedRules.Add(1)
-			}
-			m.errorHandler(fmt.Errorf("transform failed: %w", err))
-			return nil
+			return m.valueFailed(rule.failure(key, rule.Field, err))
 		}
-		finalValue = transformed
+		if !ok {
+			return lineMatched
+		}
+		finalValue = counter
+	} else if rule.Transform != "" {
+		transformed, hit, err := m.transformer.LookupContext(ctx, rule.Transform, value)
+		return m.applyTransformed(rule, key, obj, value, transformed, hit, err)
 	}
+	return m.setField(rule, key, obj, finalValue)
+}
 
+func (m *FastMapper) applyTransformed(rule *FastRule, key string, obj any, value string, transformed any, hit bool, err error) lineResult {
+	if m.stats != nil {
+		if hit {
+			m.stats.CacheHits.Add(1)
+		} else {
+			m.stats.CacheMisses.Add(1)
+		}
+	}
+	if err != nil {
+		if m.logger != nil {
+			m.logger.Warn("transform failed", "rule", rule.ID, "transform", rule.Transform, "value", value, "error", err)
+		}
+		return m.valueFailed(rule.failure(key, rule.Field, err))
+	}
+	if m.logger != nil {
+		m.logger.Debug("transform applied", "rule", rule.ID, "transform", rule.Transform, "value", value, "result", transformed)
+	}
+	return m.setField(rule, key, obj, transformed)
+}
+
+func (m *FastMapper) setField(rule *FastRule, key string, obj any, finalValue any) lineResult {
 	info, _ := m.registry.Get(rule.Entity)
+	if m.typeCheck != nil && rule.Transform != "" {
+		m.typeCheck.check(info, rule.ID, rule.Field, rule.Transform, finalValue)
+	} else if m.typeCheck != nil && rule.Counter != CounterOff {
+		m.typeCheck.check(info, rule.ID, rule.Field, rule.Counter.String(), finalValue)
+	}
 	if setter, ok := info.Setters[rule.Field]; ok {
 		if err := setter(obj, finalValue); err != nil {
-			if m.stats != nil {
-				m.stats.FailedRules.Add(1)
+			if m.logger != nil {
+				m.logger.Warn("setter failed", "rule", rule.ID, "target", rule.target(), "key", key, "field", rule.Field, "error", err)
 			}
-			m.errorHandler(fmt.Errorf("setter failed: %w", err))
+			return m.valueFailed(rule.failure(key, rule.Field, fmt.Errorf("setter failed: %w", err)))
+		}
+		if m.lastSeen {
+			touch(info, obj)
+		}
+		if m.logger != nil {
+			m.logger.Debug("field set", "target", rule.target(), "key", key, "field", rule.Field, "value", finalValue)
+		}
+		if m.recorder != nil {
+			m.recorder.Record(rule.target(), key, rule.Field, finalValue)
 		}
 	}
 
-	return nil
+	return lineMatched
 }
 
 func (m *FastMapper) ProcessBatch(items [][2]string) error {
 	return m.ProcessBatchContext(context.Background(), items)
 }
 
-func (m *FastMapper) ProcessBatchContext(ctx context.Context, items [][2]string) error {
+func (m *FastMapper) ProcessBatchContext(ctx context.Context, items [][2]string) (err error) {
+	if m.closed.Load() {
+		return ErrClosed
+	}
+	items = m.dedupBatch(items)
+	var tally *batchTally
+	if m.tracer != nil {
+		var span Span
+		ctx, span = m.tracer.StartBatch(ctx, len(items))
+		tally = &batchTally{}
+		defer func() {
+			span.End(tally.matched.Load(), tally.failed.Load(), err)
+		}()
+	}
+	defer func() {
+		if err == nil {
+			m.reportIncomplete()
+		}
+	}()
+	m.unmatched.begin()
+	defer m.unmatched.end()
+
 	const batchSize = 100
 
 	if len(items) < batchSize*2 {
@@ -193,7 +740,7 @@ func (m *FastMapper) ProcessBatchContext(ctx context.Context, items [][2]string)
 			if err := ctx.Err(); err != nil {
 				return err
 			}
-			if err := m.ProcessContext(ctx, item[0], item[1]); err != nil {
+			if err := m.processItem(ctx, item, tally); err != nil {
 				return err
 			}
 		}
@@ -207,6 +754,9 @@ func (m *FastMapper) ProcessBatchContext(ctx context.Context, items [][2]string)
 	if numWorkers > 10 {
 		numWorkers = 10
 	}
+	if m.ordered {
+		return m.processOrdered(ctx, items, numWorkers, tally)
+	}
 
 	itemsChan := make(chan [2]string, len(items))
 	for _, item := range items {
@@ -226,7 +776,7 @@ func (m *FastMapper) ProcessBatchContext(ctx context.Context, items [][2]string)
 					}
 					return
 				}
-				if err := m.ProcessContext(ctx, item[0], item[1]); err != nil {
+				if err := m.processItem(ctx, item, tally); err != nil {
 					select {
 					case errChan <- err:
 					default:
@@ -247,6 +797,128 @@ func (m *FastMapper) ProcessBatchContext(ctx context.Context, items [][2]string)
 	}
 }
 
+func (m *FastMapper) ProcessBatchGrouped(items [][2]string) error {
+	return m.ProcessBatchGroupedContext(context.Background(), items)
+}
+
+func (m *FastMapper) ProcessBatchGroupedContext(ctx context.Context, items [][2]string) (err error) {
+	if m.closed.Load() {
+		return ErrClosed
+	}
+	items = m.dedupBatch(items)
+	var tally *batchTally
+	if m.tracer != nil {
+		var span Span
+		ctx, span = m.tracer.StartBatch(ctx, len(items))
+		tally = &batchTally{}
+		defer func() {
+			span.End(tally.matched.Load(), tally.failed.Load(), err)
+		}()
+	}
+	defer func() {
+		if err == nil {
+			m.reportIncomplete()
+		}
+	}()
+	m.unmatched.begin()
+	defer m.unmatched.end()
+
+	type entityKey struct {
+		target string
+		key    string
+	}
+
+	index := make(map[entityKey]int)
+	var buckets [][]*preparedLine
+
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		p := m.prepareLine(ctx, item)
+		if p.result != lineMatched || p.err != nil {
+			result, err := m.applyPrepared(ctx, &p)
+			tally.record(result)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		ek := entityKey{target: p.line.rule.target(), key: p.line.key}
+		i, ok := index[ek]
+		if !ok {
+			i = len(buckets)
+			index[ek] = i
+			buckets = append(buckets, nil)
+		}
+		buckets[i] = append(buckets[i], &p)
+	}
+
+	for _, bucket := range buckets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := m.applyBucket(ctx, bucket, tally); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *FastMapper) applyBucket(ctx context.Context, bucket []*preparedLine, tally *batchTally) error {
+	first := bucket[0].line
+	if first.rule.fansOut() {
+		for _, p := range bucket {
+			result, err := m.applyPrepared(ctx, p)
+			tally.record(result)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if m.locker != nil {
+		unlock := m.locker.LockEntity(first.rule.target(), first.key)
+		defer unlock()
+	}
+
+	obj, acquireErr := m.acquire(first.rule, first.key)
+	limited := errors.Is(acquireErr, ErrEntityLimit)
+	if limited {
+		m.errorHandler(acquireErr)
+	}
+
+	for _, p := range bucket {
+		result, err := m.applyPreparedWith(ctx, p, func(line resolvedLine) (lineResult, error) {
+			switch {
+			case limited:
+				if m.stats != nil {
+					m.stats.FailedRules.Add(1)
+				}
+				return lineFailed, nil
+			case acquireErr != nil:
+				return lineFailed, acquireErr
+			}
+			return m.apply(line, obj), nil
+		})
+		tally.record(result)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *FastMapper) processItem(ctx context.Context, item [2]string, tally *batchTally) error {
+	result, err := m.processLine(ctx, item[0], item[1])
+	tally.record(result)
+	return err
+}
+
 func (m *FastMapper) GetStore() types.Store {
 	return m.store
 }
@@ -260,10 +932,23 @@ func (m *FastMapper) Reset() {
 	defer m.mu.Unlock()
 
 	m.store.Clear()
+	m.recountEntities("")
+	m.candidates.reset()
+	m.counters.reset()
+	if m.coverage != nil {
+		m.coverage.reset()
+	}
+	if m.sources != nil {
+		m.sources.reset()
+	}
+	m.unmatched.count.Store(0)
 	if m.stats != nil {
 		m.stats.ProcessedLines.Store(0)
 		m.stats.MatchedRules.Store(0)
+		m.stats.UnmatchedLines.Store(0)
 		m.stats.FailedRules.Store(0)
+		m.stats.SkippedValues.Store(0)
+		m.stats.DuplicateLines.Store(0)
 		m.stats.CacheHits.Store(0)
 		m.stats.CacheMisses.Store(0)
 		m.stats.AllocCount.Store(0)
@@ -272,6 +957,24 @@ func (m *FastMapper) Reset() {
 	}
 }
 
+func (m *FastMapper) ResetTarget(target string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := types.ClearTarget(m.store, target); err != nil {
+		if m.logger != nil {
+			m.logger.Error("reset target failed", "target", target, "error", err)
+		}
+		m.errorHandler(err)
+	}
+	m.recountEntities(target)
+	m.candidates.resetTarget(target)
+	m.counters.resetTarget(target)
+	if m.sources != nil {
+		m.sources.resetTarget(target)
+	}
+}
+
 func (s *FastStats) String() string {
 	if s == nil {
 		return "Stats: disabled"
@@ -286,15 +989,36 @@ func (s *FastStats) String() string {
 	avgNanos := nanos / processed
 
 	return fmt.Sprintf(
-		"Stats: %d lines, %d matched, %d failed | "+
-			"Cache: %d hits, %d misses (%.1f%% hit rate) | "+
+		"Stats: %d lines, %d matched, %d unmatched, %d failed, %d skipped, %d duplicate | "+
+			"Transform cache: %d hits, %d misses (%.1f%% hit rate) | "+
 			"Memory: %d allocs, %d reused (%.1f%% reuse rate) | "+
-			"Avg latency: %dns",
-		processed, s.MatchedRules.Load(), s.FailedRules.Load(),
+			"Avg latency: %dns | Throughput: %.0f lines/s",
+		processed, s.MatchedRules.Load(), s.UnmatchedLines.Load(), s.FailedRules.Load(), s.SkippedValues.Load(), s.DuplicateLines.Load(),
 		s.CacheHits.Load(), s.CacheMisses.Load(),
-		float64(s.CacheHits.Load())*100/float64(s.CacheHits.Load()+s.CacheMisses.Load()+1),
+		percent(s.CacheHits.Load(), s.CacheHits.Load()+s.CacheMisses.Load()),
 		s.AllocCount.Load(), s.ReuseCount.Load(),
-		float64(s.ReuseCount.Load())*100/float64(s.AllocCount.Load()+s.ReuseCount.Load()+1),
-		avgNanos,
+		percent(s.ReuseCount.Load(), s.AllocCount.Load()+s.ReuseCount.Load()),
+		avgNanos, s.LinesPerSecond(),
 	)
 }
+
+func (s *FastStats) LinesPerSecond() float64 {
+	if s == nil {
+		return 0
+	}
+	return linesPerSecond(s.ProcessedLines.Load(), time.Duration(s.ProcessingNanos.Load()))
+}
+
+func linesPerSecond(lines int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(lines) / elapsed.Seconds()
+}
+
+func percent(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
@@ -0,0 +1,280 @@
+package mapper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/cwmp"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
+)
+
+func newHostMapper(t *testing.T, opts ...FastOption) *FastMapper {
+	t.Helper()
+	reg := registry.New()
+	reg.MustRegister("host", func() any { return &TestHost{} })
+
+	m := NewFast(reg, opts...)
+	for _, field := range []string{"MACAddress", "IPAddress", "HostName", "Active"} {
+		err := m.AddRule(&FastRule{
+			ID:        "host_" + field,
+			Pattern:   router.CompilePattern("Device.Hosts.Host.*." + field),
+			Entity:    "host",
+			Field:     field,
+			Extractor: &extractor.IndexExtractor{Position: 3},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func getHost(t *testing.T, m *FastMapper, key string) *TestHost {
+	t.Helper()
+	obj, ok := m.GetStore().Get("host", key)
+	if !ok {
+		t.Fatalf("host %q not stored", key)
+	}
+	return obj.(*TestHost)
+}
+
+func TestProcessPartsDottedSegment(t *testing.T) {
+	m := newHostMapper(t)
+	err := m.AddRule(&FastRule{
+		ID:        "vendor",
+		Pattern:   router.CompilePattern("Device.X_Vendor.*.Some\\.Name"),
+		Entity:    "host",
+		Field:     "HostName",
+		Extractor: &extractor.IndexExtractor{Position: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.ProcessParts([]string{"Device", "X_Vendor", "7", "Some.Name"}, "dotted"); err != nil {
+		t.Fatal(err)
+	}
+	if got := getHost(t, m, "7").HostName; got != "dotted" {
+		t.Errorf("HostName = %q, want %q", got, "dotted")
+	}
+	if err := m.ProcessParts([]string{"Device", "Hosts", "Host", "2", "HostName"}, "laptop"); err != nil {
+		t.Fatal(err)
+	}
+	if got := getHost(t, m, "2").HostName; got != "laptop" {
+		t.Errorf("HostName = %q, want %q", got, "laptop")
+	}
+}
+
+func TestProcessPartsJoinsPathForFilters(t *testing.T) {
+	var seen []string
+	m := newHostMapper(t, WithPathFilter(func(path string) bool {
+		seen = append(seen, path)
+		return true
+	}))
+
+	if err := m.ProcessParts([]string{"Device", "Hosts", "Host", "1", "HostName"}, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 1 || seen[0] != "Device.Hosts.Host.1.HostName" {
+		t.Errorf("filter saw %q", seen)
+	}
+}
+
+func TestFastMapperCountingStoreParallelBatch(t *testing.T) {
+	counts := types.NewCountingStore()
+	m := newHostMapper(t, WithFastStore(counts))
+
+	items := make([][2]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		items = append(items, [2]string{fmt.Sprintf("Device.Hosts.Host.%d.HostName", i%100), fmt.Sprintf("name-%d", i)})
+	}
+	if err := m.ProcessBatch(items); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := counts.Count("host"); got != 100 {
+		t.Errorf("Count = %d, want 100", got)
+	}
+	for key, n := range counts.Counts("host") {
+		if n != 10 {
+			t.Errorf("Counts[%s] = %d, want 10", key, n)
+		}
+	}
+}
+
+func TestDeviceExtractorWrappingWildcard(t *testing.T) {
+	reg := registry.New()
+	reg.MustRegister("host", func() any { return &TestHost{} })
+	m := NewFast(reg)
+	err := m.AddRule(&FastRule{
+		ID:      "host_name",
+		Pattern: router.CompilePattern("Device.Hosts.Host.*.HostName"),
+		Entity:  "host",
+		Field:   "HostName",
+		Extractor: &extractor.DeviceExtractor{
+			Inner: &extractor.WildcardExtractor{Index: 0, Prefix: "host", Sep: ":"},
+			Sep:   "/",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := cwmp.WithDeviceID(context.Background(), cwmp.DeviceID{SerialNumber: "SN1"})
+	if err := m.ProcessContext(ctx, "Device.Hosts.Host.4.HostName", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ProcessPartsContext(ctx, []string{"Device", "Hosts", "Host", "5", "HostName"}, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Process("Device.Hosts.Host.6.HostName", "c"); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{"SN1/host:4": "a", "SN1/host:5": "b", "host:6": "c"} {
+		if got := getHost(t, m, key).HostName; got != want {
+			t.Errorf("%s HostName = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func hostRule(id, field string) *FastRule {
+	return &FastRule{
+		ID:        id,
+		Pattern:   router.CompilePattern("Device.Hosts.Host.*." + field),
+		Entity:    "host",
+		Field:     field,
+		Extractor: &extractor.IndexExtractor{Position: 3},
+	}
+}
+
+func wifiRule(id, field, transform string) *FastRule {
+	return &FastRule{
+		ID:        id,
+		Pattern:   router.CompilePattern("Device.WiFi.Radio.*." + field),
+		Entity:    "wifi",
+		Field:     field,
+		Transform: transform,
+		Extractor: &extractor.IndexExtractor{Position: 3},
+	}
+}
+
+func newWifiMapper(t *testing.T, opts ...FastOption) *FastMapper {
+	t.Helper()
+	reg := registry.New()
+	reg.MustRegister("wifi", func() any { return &TestWifi{} })
+
+	m := NewFast(reg, opts...)
+	for _, field := range []string{"SSID", "Channel", "Enabled"} {
+		if err := m.AddRule(wifiRule("wifi_"+field, field, "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func getWifi(t *testing.T, m *FastMapper, key string) *TestWifi {
+	t.Helper()
+	obj, ok := m.GetStore().Get("wifi", key)
+	if !ok {
+		t.Fatalf("wifi %q not stored", key)
+	}
+	return obj.(*TestWifi)
+}
+
+func TestAddRulesRejectsDuplicateIDs(t *testing.T) {
+	m := newHostMapper(t)
+
+	if err := m.AddRules([]*FastRule{hostRule("host_HostName", "HostName")}); err == nil {
+		t.Error("expected error for ID already registered")
+	}
+	if err := m.AddRules([]*FastRule{hostRule("dup", "HostName"), hostRule("dup", "IPAddress")}); err == nil {
+		t.Error("expected error for ID repeated within the batch")
+	}
+	if _, ok := m.rules["dup"]; ok {
+		t.Error("rejected batch was partially added")
+	}
+}
+
+func TestAddRulesDoesNotMutateOnFailure(t *testing.T) {
+	m := newHostMapper(t)
+	valid := &FastRule{
+		ID:            "by_spec",
+		Pattern:       router.CompilePattern("Device.Hosts.Host.*.Layer2Interface"),
+		Entity:        "host",
+		Field:         "HostName",
+		ExtractorSpec: "path[3]",
+	}
+	invalid := &FastRule{
+		ID:        "bad",
+		Pattern:   router.CompilePattern("Device.Hosts.Host.*.Layer3Interface"),
+		Entity:    "host",
+		Field:     "HostName",
+		Transform: "no_such_transform",
+		Extractor: &extractor.IndexExtractor{Position: 3},
+	}
+
+	if err := m.AddRules([]*FastRule{valid, invalid}); err == nil {
+		t.Fatal("expected error for unknown transform")
+	}
+	if valid.Extractor != nil {
+		t.Error("AddRules compiled the extractor of a rule it did not add")
+	}
+
+	if err := m.AddRules([]*FastRule{valid}); err != nil {
+		t.Fatal(err)
+	}
+	if valid.Extractor != nil {
+		t.Error("AddRules mutated the caller's rule")
+	}
+	if err := m.Process("Device.Hosts.Host.9.Layer2Interface", "eth0"); err != nil {
+		t.Fatal(err)
+	}
+	if got := getHost(t, m, "9").HostName; got != "eth0" {
+		t.Errorf("HostName = %q, want %q", got, "eth0")
+	}
+}
+
+func TestAddRuleRejectsDuplicateID(t *testing.T) {
+	m := newHostMapper(t)
+	dup := hostRule("host_HostName", "Layer2Interface")
+	if err := m.AddRule(dup); err == nil {
+		t.Fatal("expected error for duplicate rule ID")
+	}
+	if err := m.Process("Device.Hosts.Host.1.Layer2Interface", "eth0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.GetStore().Get("host", "1"); ok {
+		t.Error("rejected rule's pattern was routed")
+	}
+	if err := m.Process("Device.Hosts.Host.1.HostName", "laptop"); err != nil {
+		t.Fatal(err)
+	}
+	if got := getHost(t, m, "1").HostName; got != "laptop" {
+		t.Errorf("HostName = %q, want laptop", got)
+	}
+}
+
+func TestAddRuleDoesNotMutateRule(t *testing.T) {
+	m := newHostMapper(t)
+	rule := &FastRule{
+		ID:            "by_spec",
+		Pattern:       router.CompilePattern("Device.Hosts.Host.*.Layer2Interface"),
+		Entity:        "host",
+		Field:         "HostName",
+		ExtractorSpec: "path[3]",
+	}
+	if err := m.AddRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	if rule.Extractor != nil || rule.Pattern.ID != "" {
+		t.Errorf("AddRule mutated the caller's rule: Extractor=%v, Pattern.ID=%q", rule.Extractor, rule.Pattern.ID)
+	}
+	if err := m.AddRule(&FastRule{ID: "no_pattern", Entity: "host", Field: "HostName", ExtractorSpec: "path[3]"}); err == nil {
+		t.Error("expected error for a rule without a pattern")
+	}
+}
@@ -0,0 +1,99 @@
+package mapper
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+)
+
+func hostItems(n int) [][2]string {
+	items := make([][2]string, 0, n*3)
+	for i := 0; i < n; i++ {
+		items = append(items,
+			[2]string{fmt.Sprintf("Device.Hosts.Host.%d.HostName", i%50), fmt.Sprintf("name-%d", i)},
+			[2]string{fmt.Sprintf("Device.Hosts.Host.%d.IPAddress", i%50), fmt.Sprintf("10.0.0.%d", i%50)},
+			[2]string{fmt.Sprintf("Device.Hosts.Host.%d.Unmapped", i), "x"},
+		)
+	}
+	return items
+}
+
+func storeSnapshot(m *FastMapper) map[string]TestHost {
+	snapshot := make(map[string]TestHost)
+	for key, obj := range m.GetStore().GetAll("host") {
+		snapshot[key] = *obj.(*TestHost)
+	}
+	return snapshot
+}
+
+func TestProcessBatchGroupedMatchesProcessBatch(t *testing.T) {
+	items := hostItems(150)
+	ctx := context.WithValue(context.Background(), fieldAllowlistKey{}, map[string]bool{"HostName": true})
+
+	tracer := &countingTracer{}
+	grouped := newHostMapper(t, WithFastStats(), WithFastTracer(tracer))
+	if err := grouped.ProcessBatchGroupedContext(ctx, items); err != nil {
+		t.Fatal(err)
+	}
+	plain := newHostMapper(t, WithFastStats(), WithOrderedBatches())
+	if err := plain.ProcessBatchContext(ctx, items); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := storeSnapshot(grouped), storeSnapshot(plain); !reflect.DeepEqual(got, want) {
+		t.Errorf("grouped store differs from ProcessBatch:\n%v\n%v", got, want)
+	}
+	for key, host := range storeSnapshot(grouped) {
+		if host.IPAddress != "" {
+			t.Fatalf("host %s IPAddress %q set despite the field allowlist", key, host.IPAddress)
+		}
+	}
+
+	gs, ps := grouped.GetStats(), plain.GetStats()
+	for name, pair := range map[string][2]int64{
+		"ProcessedLines": {gs.ProcessedLines.Load(), ps.ProcessedLines.Load()},
+		"MatchedRules":   {gs.MatchedRules.Load(), ps.MatchedRules.Load()},
+		"UnmatchedLines": {gs.UnmatchedLines.Load(), ps.UnmatchedLines.Load()},
+	} {
+		if pair[0] != pair[1] {
+			t.Errorf("%s = %d grouped, %d ungrouped", name, pair[0], pair[1])
+		}
+	}
+	if got := tracer.rules.Load(); got != 150 {
+		t.Errorf("rule spans = %d, want 150", got)
+	}
+}
+
+func TestProcessBatchGroupedStatsOnError(t *testing.T) {
+	m := newHostMapper(t, WithFastStats())
+	err := m.AddRule(&FastRule{
+		ID:        "ghost",
+		Pattern:   router.CompilePattern("Device.Ghost.*.Name"),
+		Entity:    "ghost",
+		Field:     "Name",
+		Extractor: &extractor.IndexExtractor{Position: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := [][2]string{
+		{"Device.Hosts.Host.1.HostName", "a"},
+		{"Device.Hosts.Host.2.HostName", "b"},
+		{"Device.Ghost.1.Name", "x"},
+		{"Device.Hosts.Host.3.HostName", "c"},
+	}
+	if err := m.ProcessBatchGrouped(items); err == nil {
+		t.Fatal("expected error for unregistered entity")
+	}
+	if got := m.GetStats().ProcessedLines.Load(); got != 3 {
+		t.Errorf("ProcessedLines = %d, want 3", got)
+	}
+	if got := m.GetStats().MatchedRules.Load(); got != 3 {
+		t.Errorf("MatchedRules = %d, want 3", got)
+	}
+}
@@ -0,0 +1,49 @@
+package mapper
+
+import (
+	"reflect"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+)
+
+func WithInferBool() FastOption {
+	return func(m *FastMapper) {
+		if m.inferred == nil {
+			m.inferred = make(map[reflect.Kind]string)
+		}
+		m.inferred[reflect.Bool] = "bool"
+	}
+}
+
+func WithInferNumeric() FastOption {
+	return func(m *FastMapper) {
+		if m.inferred == nil {
+			m.inferred = make(map[reflect.Kind]string)
+		}
+		for _, kind := range []reflect.Kind{
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		} {
+			m.inferred[kind] = "int"
+		}
+		m.inferred[reflect.Float32] = "float"
+		m.inferred[reflect.Float64] = "float"
+	}
+}
+
+func (m *FastMapper) inferTransform(rule *FastRule) {
+	if len(m.inferred) == 0 || rule.Transform != "" || rule.Counter != CounterOff || rule.SetConstant != nil || rule.JSON != nil {
+		return
+	}
+	info, err := m.registry.Get(rule.Entity)
+	if err != nil {
+		return
+	}
+	t, ok := info.FieldType(rule.Field)
+	if !ok || registry.HasConverter(t) {
+		return
+	}
+	if name, ok := m.inferred[t.Kind()]; ok {
+		rule.Transform = name
+	}
+}
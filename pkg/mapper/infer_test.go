@@ -0,0 +1,123 @@
+package mapper
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+)
+
+func TestInferTransforms(t *testing.T) {
+	m := newWifiMapper(t, WithInferBool(), WithInferNumeric())
+
+	for id, want := range map[string]string{"wifi_SSID": "", "wifi_Channel": "int", "wifi_Enabled": "bool"} {
+		if got := m.rules[id].Transform; got != want {
+			t.Errorf("%s Transform = %q, want %q", id, got, want)
+		}
+	}
+
+	for path, value := range map[string]string{
+		"Device.WiFi.Radio.1.SSID":    "1,234",
+		"Device.WiFi.Radio.1.Channel": " 1,234 ",
+		"Device.WiFi.Radio.1.Enabled": "Enabled",
+	} {
+		if err := m.Process(path, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wifi := getWifi(t, m, "1")
+	if wifi.SSID != "1,234" || wifi.Channel != 1234 || !wifi.Enabled {
+		t.Errorf("wifi = %+v", wifi)
+	}
+}
+
+func TestInferTransformsOnlyWhenEnabled(t *testing.T) {
+	var errs []error
+	m := newWifiMapper(t, WithInferNumeric(), WithFastErrorHandler(func(err error) { errs = append(errs, err) }))
+
+	if got := m.rules["wifi_Enabled"].Transform; got != "" {
+		t.Errorf("bool field inferred %q without WithInferBool", got)
+	}
+	if err := m.Process("Device.WiFi.Radio.1.Enabled", "yes"); err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 {
+		t.Errorf("got %d errors for uninferred bool, want 1", len(errs))
+	}
+}
+
+func TestInferTransformsKeepsExplicitRules(t *testing.T) {
+	m := newWifiMapper(t, WithInferNumeric())
+
+	explicit := wifiRule("wifi_channel_raw", "Channel", "raw")
+	explicit.Pattern = router.CompilePattern("Device.WiFi.Radio.*.ChannelRaw")
+	constant := wifiRule("wifi_channel_auto", "Channel", "")
+	constant.Pattern = router.CompilePattern("Device.WiFi.Radio.*.AutoChannel")
+	constant.SetConstant = 0
+	if err := m.AddRules([]*FastRule{explicit, constant}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := m.rules["wifi_channel_raw"].Transform; got != "raw" {
+		t.Errorf("explicit Transform replaced with %q", got)
+	}
+	if got := m.rules["wifi_channel_auto"].Transform; got != "" {
+		t.Errorf("SetConstant rule inferred %q", got)
+	}
+	if explicit.Transform != "raw" || constant.Transform != "" {
+		t.Error("inference mutated the caller's rules")
+	}
+}
+
+type radioMode int
+
+const (
+	radioAuto radioMode = iota + 1
+	radioManual
+)
+
+type testRadio struct {
+	Mode radioMode
+}
+
+func init() {
+	registry.RegisterConverter(reflect.TypeOf(radioAuto), func(s string) (any, error) {
+		switch s {
+		case "Auto":
+			return radioAuto, nil
+		case "Manual":
+			return radioManual, nil
+		}
+		return nil, fmt.Errorf("unknown radio mode %q", s)
+	})
+}
+
+func TestInferTransformsSkipsConverterFields(t *testing.T) {
+	reg := registry.New()
+	reg.MustRegister("radio", func() any { return &testRadio{} })
+	m := NewFast(reg, WithInferNumeric())
+	err := m.AddRule(&FastRule{
+		ID:        "radio_Mode",
+		Pattern:   router.CompilePattern("Device.WiFi.Radio.*.Mode"),
+		Entity:    "radio",
+		Field:     "Mode",
+		Extractor: &extractor.IndexExtractor{Position: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := m.rules["radio_Mode"].Transform; got != "" {
+		t.Errorf("converter field inferred %q", got)
+	}
+	if err := m.Process("Device.WiFi.Radio.1.Mode", "Manual"); err != nil {
+		t.Fatal(err)
+	}
+	obj, ok := m.GetStore().Get("radio", "1")
+	if !ok || obj.(*testRadio).Mode != radioManual {
+		t.Errorf("radio = %+v", obj)
+	}
+}
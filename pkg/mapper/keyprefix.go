@@ -0,0 +1,30 @@
+package mapper
+
+func WithKeyPrefix(prefix string) Option {
+	return WithDynamicKeyPrefix(staticPrefix(prefix))
+}
+
+func WithDynamicKeyPrefix(fn func(path, value string) string) Option {
+	return func(m *Mapper) {
+		m.keyPrefix = fn
+	}
+}
+
+func WithFastKeyPrefix(prefix string) FastOption {
+	return WithFastDynamicKeyPrefix(staticPrefix(prefix))
+}
+
+func WithFastDynamicKeyPrefix(fn func(path, value string) string) FastOption {
+	return func(m *FastMapper) {
+		m.keyPrefix = fn
+	}
+}
+
+func staticPrefix(prefix string) func(path, value string) string {
+	if prefix == "" {
+		return nil
+	}
+	return func(path, value string) string {
+		return prefix
+	}
+}
@@ -0,0 +1,25 @@
+package mapper
+
+import (
+	"time"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+)
+
+func WithLastSeen() Option {
+	return func(m *Mapper) {
+		m.lastSeen = true
+	}
+}
+
+func WithFastLastSeen() FastOption {
+	return func(m *FastMapper) {
+		m.lastSeen = true
+	}
+}
+
+func touch(info *registry.TypeInfo, obj any) {
+	if info != nil && info.LastSeen != nil {
+		info.LastSeen(obj, time.Now())
+	}
+}
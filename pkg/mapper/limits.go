@@ -0,0 +1,107 @@
+package mapper
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
+)
+
+var ErrEntityLimit = errors.New("entity limit reached")
+
+var ErrPathTooLong = errors.New("path too long")
+
+const rejectedPathPreview = 64
+
+type entityLimit struct {
+	mu      sync.Mutex
+	max     int
+	count   int
+	counted bool
+}
+
+func (l *entityLimit) size(store types.Store, target string) int {
+	if c, ok := store.(types.Counter); ok {
+		return c.Count(target)
+	}
+	if !l.counted {
+		l.count = len(store.GetAll(target))
+		l.counted = true
+	}
+	return l.count
+}
+
+func WithMaxEntities(target string, n int) FastOption {
+	return func(m *FastMapper) {
+		if m.maxEntities == nil {
+			m.maxEntities = make(map[string]*entityLimit)
+		}
+		m.maxEntities[target] = &entityLimit{max: n}
+	}
+}
+
+func WithMaxPathLength(n int) FastOption {
+	return func(m *FastMapper) {
+		m.maxPathLength = n
+	}
+}
+
+func (m *FastMapper) rejectPath(path string) bool {
+	if m.maxPathLength <= 0 || len(path) <= m.maxPathLength {
+		return false
+	}
+	preview := path
+	if len(preview) > rejectedPathPreview {
+		preview = preview[:rejectedPathPreview] + "..."
+	}
+	m.errorHandler(fmt.Errorf("%w: %d bytes exceeds limit of %d: %q", ErrPathTooLong, len(path), m.maxPathLength, preview))
+	return true
+}
+
+func (m *FastMapper) reserveEntity(target, key string) (func(), error) {
+	limit, ok := m.maxEntities[target]
+	if !ok {
+		return nil, nil
+	}
+
+	limit.mu.Lock()
+	if _, exists := m.store.Get(target, key); exists {
+		limit.mu.Unlock()
+		return nil, nil
+	}
+	if limit.size(m.store, target) >= limit.max {
+		limit.mu.Unlock()
+		return nil, fmt.Errorf("%w: target %s holds %d entities, dropping new key %s", ErrEntityLimit, target, limit.max, key)
+	}
+	return limit.mu.Unlock, nil
+}
+
+func (m *FastMapper) entityCreated(target string) {
+	if limit, ok := m.maxEntities[target]; ok && limit.counted {
+		limit.count++
+	}
+}
+
+func (m *FastMapper) entityDeleted(target string) {
+	limit, ok := m.maxEntities[target]
+	if !ok {
+		return
+	}
+	limit.mu.Lock()
+	if limit.counted && limit.count > 0 {
+		limit.count--
+	}
+	limit.mu.Unlock()
+}
+
+func (m *FastMapper) recountEntities(target string) {
+	for name, limit := range m.maxEntities {
+		if target != "" && name != target {
+			continue
+		}
+		limit.mu.Lock()
+		limit.counted = false
+		limit.mu.Unlock()
+	}
+}
@@ -0,0 +1,114 @@
+package mapper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
+)
+
+func TestMaxEntitiesParallelBatch(t *testing.T) {
+	var mu sync.Mutex
+	var dropped int
+	m := newHostMapper(t, WithMaxEntities("host", 10), WithFastErrorHandler(func(err error) {
+		if errors.Is(err, ErrEntityLimit) {
+			mu.Lock()
+			dropped++
+			mu.Unlock()
+		}
+	}))
+
+	items := make([][2]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		items = append(items, [2]string{fmt.Sprintf("Device.Hosts.Host.%d.HostName", i%500), "name"})
+	}
+	if err := m.ProcessBatch(items); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := types.CountTarget(m.GetStore(), "host"); got != 10 {
+		t.Errorf("stored %d hosts, want 10", got)
+	}
+	if dropped == 0 {
+		t.Error("no ErrEntityLimit reported")
+	}
+}
+
+type uncountedStore struct {
+	types.Store
+	mu      sync.Mutex
+	getAlls int
+}
+
+func (s *uncountedStore) GetAll(target string) map[string]any {
+	s.mu.Lock()
+	s.getAlls++
+	s.mu.Unlock()
+	return s.Store.GetAll(target)
+}
+
+func (s *uncountedStore) Delete(target, key string) {
+	types.DeleteEntity(s.Store, target, key)
+}
+
+func TestMaxEntitiesWithoutCounter(t *testing.T) {
+	store := &uncountedStore{Store: types.NewMapStore()}
+	m := newHostMapper(t, WithFastStore(store), WithMaxEntities("host", 10))
+
+	items := make([][2]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		items = append(items, [2]string{fmt.Sprintf("Device.Hosts.Host.%d.HostName", i), "name"})
+	}
+	if err := m.ProcessBatch(items); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(store.Store.GetAll("host")); got != 10 {
+		t.Errorf("stored %d hosts, want 10", got)
+	}
+	if store.getAlls != 1 {
+		t.Errorf("GetAll called %d times, want 1", store.getAlls)
+	}
+
+	m.ResetTarget("host")
+	if err := m.Process("Device.Hosts.Host.2000.HostName", "name"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Get("host", "2000"); !ok {
+		t.Error("limit still counts entities cleared by ResetTarget")
+	}
+}
+
+func TestMaxPathLength(t *testing.T) {
+	var errs []error
+	limit := len("Device.Hosts.Host.1.HostName")
+	m := newHostMapper(t, WithMaxPathLength(limit), WithFastErrorHandler(func(err error) { errs = append(errs, err) }))
+
+	if err := m.Process("Device.Hosts.Host.1.HostName", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Process("Device.Hosts.Host.10.HostName", "b"); err != nil {
+		t.Fatal(err)
+	}
+	long := "Device.Hosts.Host." + strings.Repeat("9", 100) + ".HostName"
+	if err := m.ProcessParts(strings.Split(long, "."), "c"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2: %v", len(errs), errs)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, ErrPathTooLong) {
+			t.Errorf("error = %v, want ErrPathTooLong", err)
+		}
+	}
+	if msg := errs[1].Error(); !strings.Contains(msg, long[:rejectedPathPreview]+"...") || strings.Contains(msg, long) {
+		t.Errorf("error does not truncate the path: %s", msg)
+	}
+	if got := types.CountTarget(m.GetStore(), "host"); got != 1 {
+		t.Errorf("stored %d hosts, want 1", got)
+	}
+}
@@ -0,0 +1,87 @@
+package mapper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+)
+
+type LintWarning struct {
+	Rule    string
+	Field   string
+	Message string
+}
+
+func (w *LintWarning) Error() string {
+	return fmt.Sprintf("rule %s field %s: %s", w.Rule, w.Field, w.Message)
+}
+
+func (m *FastMapper) Lint() []error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make([]string, 0, len(m.rules))
+	for id := range m.rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var warnings []error
+	for _, id := range ids {
+		rule := m.rules[id]
+		if rule.JSON != nil || rule.SetConstant != nil || rule.Transform != "" || rule.Counter != CounterOff {
+			continue
+		}
+		info, err := m.registry.Get(rule.Entity)
+		if err != nil {
+			continue
+		}
+		if w := checkPassthrough(rule.ID, rule.Field, info); w != nil {
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings
+}
+
+func (m *Mapper) Lint() []error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var warnings []error
+	for _, rule := range m.rules {
+		info, err := m.registry.Get(rule.Target)
+		if err != nil {
+			continue
+		}
+		for _, field := range rule.Source.Fields {
+			if len(field.Transforms) > 0 || field.Value != "value" {
+				continue
+			}
+			if w := checkPassthrough(rule.Name, field.Name, info); w != nil {
+				warnings = append(warnings, w)
+			}
+		}
+	}
+	return warnings
+}
+
+func checkPassthrough(rule, field string, info *registry.TypeInfo) error {
+	t, ok := info.FieldType(field)
+	if !ok || registry.HasConverter(t) {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return &LintWarning{
+			Rule:    rule,
+			Field:   field,
+			Message: fmt.Sprintf("no transform on %s field; the raw string is coerced by the setter (use \"raw\" if intended)", t.Kind()),
+		}
+	}
+	return nil
+}
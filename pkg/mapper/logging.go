@@ -0,0 +1,20 @@
+package mapper
+
+type Logger interface {
+	Debug(msg string, kv ...any)
+	Info(msg string, kv ...any)
+	Warn(msg string, kv ...any)
+	Error(msg string, kv ...any)
+}
+
+func WithLogger(logger Logger) Option {
+	return func(m *Mapper) {
+		m.logger = logger
+	}
+}
+
+func WithFastLogger(logger Logger) FastOption {
+	return func(m *FastMapper) {
+		m.logger = logger
+	}
+}
@@ -0,0 +1,75 @@
+package mapper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+)
+
+type recordingLogger struct {
+	mu     sync.Mutex
+	errors []string
+}
+
+func (l *recordingLogger) Debug(msg string, kv ...any) {}
+func (l *recordingLogger) Info(msg string, kv ...any)  {}
+func (l *recordingLogger) Warn(msg string, kv ...any)  {}
+
+func (l *recordingLogger) Error(msg string, kv ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors = append(l.errors, msg)
+}
+
+func TestFastLoggerReportsPanics(t *testing.T) {
+	logger := &recordingLogger{}
+	m := newPanickingMapper(t, WithPanicRecovery(), WithFastLogger(logger), WithFastErrorHandler(func(error) {}))
+
+	if err := m.Process("Device.Hosts.Host.1.Layer2Interface", "panic"); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(logger.errors) != "[panic recovered]" {
+		t.Errorf("logged errors %v", logger.errors)
+	}
+}
+
+func TestLoggerReportsFailedRules(t *testing.T) {
+	logger := &recordingLogger{}
+	reg := registry.New()
+	reg.MustRegister("WiFi", func() any { return &TestWifi{} })
+	m := New(reg, WithLogger(logger), WithErrorHandler(func(error) {}))
+	err := m.LoadRulesFromString(`
+version: "1.0"
+rules:
+  - name: wifi
+    target: WiFi
+    route: 'path.endsWith(".Channel")'
+    entity_key: 'path.split(".")[3]'
+    fields:
+      - name: Channel
+        when: 'true'
+        value: 'value'
+    derived:
+      - name: SSID
+        value: 'entity.Missing'
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Process("Device.WiFi.Radio.1.Channel", "auto"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Process("Device.WiFi.Radio.2.Channel", "6"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ApplyDerived(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(logger.errors); got != "[rule failed derived field failed derived field failed]" {
+		t.Errorf("logged errors %s", got)
+	}
+}
@@ -3,11 +3,17 @@ package mapper
 import (
 	"context"
 	"fmt"
+	"maps"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/metalgrid/tr069-cel-mapper/pkg/builder"
+	"github.com/google/cel-go/cel"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/loader"
 	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/transform"
 	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
 )
 
@@ -17,8 +23,18 @@ type Mapper struct {
 	store    types.Store
 	mu       sync.RWMutex
 
-	errorHandler func(error)
-	metrics      *Metrics
+	errorHandler     func(error)
+	softErrorHandler func(error)
+	metrics          *Metrics
+	keyPrefix        func(path, value string) string
+	required         requiredFields
+	logger           Logger
+	tracer           Tracer
+	lastSeen         bool
+	skipUnregistered bool
+	typeCheck        *typeChecker
+	variables        map[string]*cel.Type
+	closed           atomic.Bool
 }
 
 type Metrics struct {
@@ -26,6 +42,7 @@ type Metrics struct {
 	ProcessedLines  int64
 	MatchedRules    int64
 	FailedRules     int64
+	SkippedValues   int64
 	ProcessingTime  time.Duration
 	LastProcessTime time.Time
 }
@@ -65,36 +82,68 @@ func New(reg *registry.Registry, opts ...Option) *Mapper {
 	return m
 }
 
+func (m *Mapper) Clone(opts ...Option) *Mapper {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c := &Mapper{
+		rules:            m.rules,
+		registry:         m.registry,
+		store:            types.NewMapStore(),
+		errorHandler:     m.errorHandler,
+		softErrorHandler: m.softErrorHandler,
+		keyPrefix:        m.keyPrefix,
+		required:         m.required.clone(),
+		logger:           m.logger,
+		tracer:           m.tracer,
+		lastSeen:         m.lastSeen,
+		skipUnregistered: m.skipUnregistered,
+		variables:        maps.Clone(m.variables),
+	}
+	if m.typeCheck != nil {
+		c.typeCheck = &typeChecker{handler: m.typeCheck.handler}
+	}
+	if m.metrics != nil {
+		c.metrics = &Metrics{}
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
 func (m *Mapper) LoadRules(rules []*types.CompiledRule) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	for _, rule := range rules {
-		if !m.registry.Has(rule.Target) {
-			return fmt.Errorf("rule %s: target type %s not registered", rule.Name, rule.Target)
-		}
+	rules, err := m.registeredRules(rules)
+	if err != nil {
+		return err
 	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Source.Priority > rules[j].Source.Priority
+	})
 
 	m.rules = rules
 	return nil
 }
 
 func (m *Mapper) LoadRulesFromFile(filename string) error {
-	builder := builder.New(m.registry).WithStandardVariables()
-	rules, err := builder.BuildFromFile(filename)
+	config, err := loader.LoadFile(filename)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to load config file: %w", err)
 	}
-	return m.LoadRules(rules)
+	return m.loadConfig(config)
 }
 
 func (m *Mapper) LoadRulesFromString(content string) error {
-	builder := builder.New(m.registry).WithStandardVariables()
-	rules, err := builder.BuildFromString(content)
+	config, err := loader.LoadString(content)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to parse config: %w", err)
 	}
-	return m.LoadRules(rules)
+	return m.loadConfig(config)
 }
 
 func (m *Mapper) Process(path, value string) error {
@@ -102,6 +151,28 @@ func (m *Mapper) Process(path, value string) error {
 }
 
 func (m *Mapper) ProcessWithContext(ctx context.Context, path, value string) error {
+	_, err := m.processLine(ctx, path, value, nil, nil)
+	return err
+}
+
+type touchedEntities map[string]map[string]struct{}
+
+func (t touchedEntities) add(target, key string) {
+	if t == nil {
+		return
+	}
+	keys, ok := t[target]
+	if !ok {
+		keys = make(map[string]struct{})
+		t[target] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+func (m *Mapper) processLine(ctx context.Context, path, value string, data map[string]any, touched touchedEntities) (lineResult, error) {
+	if m.closed.Load() {
+		return lineUnmatched, ErrClosed
+	}
 	start := time.Now()
 	defer func() {
 		if m.metrics != nil {
@@ -117,23 +188,33 @@ func (m *Mapper) ProcessWithContext(ctx context.Context, path, value string) err
 	rules := m.rules
 	m.mu.RUnlock()
 
-	processCtx := types.NewProcessContext(path, value)
+	processCtx := types.AcquireProcessContext(path, value)
+	defer types.ReleaseProcessContext(processCtx)
+	seedData(processCtx, data)
 
+	result := lineUnmatched
 	for _, rule := range rules {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return result, ctx.Err()
 		default:
 		}
 
-		matched, err := m.applyRule(rule, processCtx)
+		matched, err := matchRoute(rule, processCtx)
+		if err == nil && matched {
+			err = m.applyTracedRule(ctx, rule, processCtx, touched)
+		}
 		if err != nil {
+			result = lineFailed
 			if m.metrics != nil {
 				m.metrics.mu.Lock()
 				m.metrics.FailedRules++
 				m.metrics.mu.Unlock()
 			}
-			m.errorHandler(fmt.Errorf("rule %s: %w", rule.Name, err))
+			if m.logger != nil {
+				m.logger.Error("rule failed", "rule", rule.Name, "path", path, "error", err)
+			}
+			m.errorHandler(ruleFailure(rule, err))
 			continue
 		}
 
@@ -143,15 +224,15 @@ func (m *Mapper) ProcessWithContext(ctx context.Context, path, value string) err
 				m.metrics.MatchedRules++
 				m.metrics.mu.Unlock()
 			}
-			return nil
+			return lineMatched, nil
 		}
 	}
 
-	return nil
+	return result, nil
 }
 
-func (m *Mapper) applyRule(rule *types.CompiledRule, ctx *types.ProcessContext) (bool, error) {
-	routeVal, _, err := rule.Route.Eval(ctx.Data)
+func matchRoute(rule *types.CompiledRule, pc *types.ProcessContext) (bool, error) {
+	routeVal, _, err := rule.Route.Eval(pc.Activation())
 	if err != nil {
 		return false, fmt.Errorf("route evaluation failed: %w", err)
 	}
@@ -160,34 +241,81 @@ func (m *Mapper) applyRule(rule *types.CompiledRule, ctx *types.ProcessContext)
 	if !ok {
 		return false, fmt.Errorf("route expression must return boolean, got %T", routeVal.Value())
 	}
+	return matched, nil
+}
 
-	if !matched {
-		return false, nil
+func (m *Mapper) applyTracedRule(ctx context.Context, rule *types.CompiledRule, pc *types.ProcessContext, touched touchedEntities) (err error) {
+	if m.tracer != nil {
+		var span Span
+		ctx, span = m.tracer.StartRule(ctx, rule.Name)
+		defer func() {
+			result := lineMatched
+			if err != nil {
+				result = lineFailed
+			}
+			span.End(result.matched(), result.failed(), nil)
+		}()
 	}
+	return m.applyRule(ctx, rule, pc, touched)
+}
 
-	keyVal, _, err := rule.EntityKey.Eval(ctx.Data)
+func (m *Mapper) applyRule(ctx context.Context, rule *types.CompiledRule, pc *types.ProcessContext, touched touchedEntities) error {
+	keyVal, _, err := rule.EntityKey.Eval(pc.Activation())
 	if err != nil {
-		return false, fmt.Errorf("entity key evaluation failed: %w", err)
+		return fmt.Errorf("entity key evaluation failed: %w", err)
 	}
 
 	key, ok := keyVal.Value().(string)
 	if !ok {
-		return false, fmt.Errorf("entity key must return string, got %T", keyVal.Value())
+		return fmt.Errorf("entity key must return string, got %T", keyVal.Value())
+	}
+	if m.keyPrefix != nil {
+		key = m.keyPrefix(pc.Path, pc.Value) + key
+	}
+
+	if m.logger != nil {
+		m.logger.Debug("rule matched", "rule", rule.Name, "path", pc.Path, "key", key)
 	}
 
-	obj := m.store.Upsert(rule.Target, key, rule.Factory)
+	factory := rule.Factory
+	if m.logger != nil {
+		factory = func() any {
+			m.logger.Info("entity created", "target", rule.Target, "key", key)
+			return rule.Factory()
+		}
+	}
+	obj := m.store.Upsert(rule.Target, key, factory)
+	touched.add(rule.Target, key)
 
 	for _, field := range rule.Fields {
-		if err := m.applyField(field, ctx, obj); err != nil {
-			return false, fmt.Errorf("field %s: %w", field.Name, err)
+		if err := m.applyField(ctx, rule, key, field, pc, obj); err != nil {
+			err = &MappingError{
+				Rule:     rule.Name,
+				Target:   rule.Target,
+				Key:      key,
+				Field:    field.Name,
+				Metadata: rule.Metadata,
+				Err:      fmt.Errorf("field %s: %w", field.Name, err),
+			}
+			if m.softErrorHandler != nil && IsSoftError(err) {
+				if m.metrics != nil {
+					m.metrics.mu.Lock()
+					m.metrics.SkippedValues++
+					m.metrics.mu.Unlock()
+				}
+				m.softErrorHandler(err)
+				continue
+			}
+			return err
 		}
 	}
 
-	return true, nil
+	return nil
 }
 
-func (m *Mapper) applyField(field types.CompiledFieldRule, ctx *types.ProcessContext, obj any) error {
-	whenVal, _, err := field.When.Eval(ctx.Data)
+func (m *Mapper) applyField(ctx context.Context, rule *types.CompiledRule, key string, field types.CompiledFieldRule, pc *types.ProcessContext, obj any) error {
+	target := rule.Target
+	whenVal, _, err := field.When.Eval(pc.Activation())
 	if err != nil {
 		return fmt.Errorf("when evaluation failed: %w", err)
 	}
@@ -201,14 +329,39 @@ func (m *Mapper) applyField(field types.CompiledFieldRule, ctx *types.ProcessCon
 		return nil
 	}
 
-	valueVal, _, err := field.Value.Eval(ctx.Data)
+	valueVal, _, err := field.Value.Eval(pc.Activation())
 	if err != nil {
 		return fmt.Errorf("value evaluation failed: %w", err)
 	}
 
-	if err := field.Setter(obj, valueVal.Value()); err != nil {
+	value := valueVal.Value()
+	for _, name := range field.Transforms {
+		input, ok := value.(string)
+		if !ok {
+			input = fmt.Sprint(value)
+		}
+		if value, err = transform.ApplyContext(ctx, name, input); err != nil {
+			return err
+		}
+	}
+	if m.typeCheck != nil && len(field.Transforms) > 0 {
+		info, _ := m.registry.Get(target)
+		m.typeCheck.check(info, rule.Name, field.Name, strings.Join(field.Transforms, ","), value)
+	}
+
+	if err := field.Setter(obj, value); err != nil {
 		return fmt.Errorf("setter failed: %w", err)
 	}
+	if m.lastSeen {
+		info, _ := m.registry.Get(target)
+		touch(info, obj)
+	}
+	if m.logger != nil {
+		m.logger.Debug("field set", "target", target, "key", key, "field", field.Name, "value", value)
+	}
+	if recorder, ok := m.store.(types.FieldRecorder); ok {
+		recorder.Record(target, key, field.Name, value)
+	}
 
 	return nil
 }
@@ -218,11 +371,110 @@ func (m *Mapper) ProcessBatch(items [][2]string) error {
 }
 
 func (m *Mapper) ProcessBatchWithContext(ctx context.Context, items [][2]string) error {
+	return m.ProcessBatchWithData(ctx, items, nil)
+}
+
+func (m *Mapper) ProcessBatchWithData(ctx context.Context, items [][2]string, data map[string]any) (err error) {
+	if m.closed.Load() {
+		return ErrClosed
+	}
+	var tally *batchTally
+	if m.tracer != nil {
+		var span Span
+		ctx, span = m.tracer.StartBatch(ctx, len(items))
+		tally = &batchTally{}
+		defer func() {
+			span.End(tally.matched.Load(), tally.failed.Load(), err)
+		}()
+	}
+	data = maps.Clone(data)
+	touched := make(touchedEntities)
 	for _, item := range items {
-		if err := m.ProcessWithContext(ctx, item[0], item[1]); err != nil {
+		result, err := m.processLine(ctx, item[0], item[1], data, touched)
+		tally.record(result)
+		if err != nil {
+			return err
+		}
+	}
+	if err := m.applyDerived(ctx, touched); err != nil {
+		return err
+	}
+	m.reportIncomplete()
+	return nil
+}
+
+func (m *Mapper) ApplyDerived(ctx context.Context) error {
+	return m.applyDerived(ctx, nil)
+}
+
+func (m *Mapper) applyDerived(ctx context.Context, touched touchedEntities) error {
+	m.mu.RLock()
+	rules := m.rules
+	m.mu.RUnlock()
+
+	for _, rule := range rules {
+		if len(rule.Derived) == 0 {
+			continue
+		}
+
+		info, err := m.registry.Get(rule.Target)
+		if err != nil {
 			return err
 		}
+
+		var entities map[string]any
+		if touched == nil {
+			entities = m.store.GetAll(rule.Target)
+		} else {
+			entities = make(map[string]any, len(touched[rule.Target]))
+			for key := range touched[rule.Target] {
+				if obj, ok := m.store.Get(rule.Target, key); ok {
+					entities[key] = obj
+				}
+			}
+		}
+		for key, obj := range entities {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			activation := map[string]any{"entity": info.Fields(obj)}
+			for _, field := range rule.Derived {
+				if err := m.applyDerivedField(field, activation, obj); err != nil {
+					if m.logger != nil {
+						m.logger.Error("derived field failed", "rule", rule.Name, "target", rule.Target, "key", key, "field", field.Name, "error", err)
+					}
+					if m.metrics != nil {
+						m.metrics.mu.Lock()
+						m.metrics.FailedRules++
+						m.metrics.mu.Unlock()
+					}
+					m.errorHandler(&MappingError{
+						Rule:     rule.Name,
+						Target:   rule.Target,
+						Key:      key,
+						Field:    field.Name,
+						Metadata: rule.Metadata,
+						Err:      fmt.Errorf("%s[%s]: derived field %s: %w", rule.Target, key, field.Name, err),
+					})
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *Mapper) applyDerivedField(field types.CompiledDerivedField, activation map[string]any, obj any) error {
+	val, _, err := field.Value.Eval(activation)
+	if err != nil {
+		return fmt.Errorf("value evaluation failed: %w", err)
+	}
+
+	if err := field.Setter(obj, val.Value()); err != nil {
+		return fmt.Errorf("setter failed: %w", err)
 	}
+
 	return nil
 }
 
@@ -234,6 +486,15 @@ func (m *Mapper) GetMetrics() *Metrics {
 	return m.metrics
 }
 
+func (mt *Metrics) LinesPerSecond() float64 {
+	if mt == nil {
+		return 0
+	}
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+	return linesPerSecond(mt.ProcessedLines, mt.ProcessingTime)
+}
+
 func (m *Mapper) Reset() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -244,11 +505,24 @@ func (m *Mapper) Reset() {
 		m.metrics.ProcessedLines = 0
 		m.metrics.MatchedRules = 0
 		m.metrics.FailedRules = 0
+		m.metrics.SkippedValues = 0
 		m.metrics.ProcessingTime = 0
 		m.metrics.mu.Unlock()
 	}
 }
 
+func (m *Mapper) ResetTarget(target string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := types.ClearTarget(m.store, target); err != nil {
+		if m.logger != nil {
+			m.logger.Error("reset target failed", "target", target, "error", err)
+		}
+		m.errorHandler(err)
+	}
+}
+
 func (m *Mapper) GetRuleNames() []string {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -0,0 +1,41 @@
+package mapper
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
+)
+
+func (m *Mapper) Match(path, value string) ([]string, error) {
+	return m.MatchWithData(path, value, nil)
+}
+
+func (m *Mapper) MatchWithData(path, value string, data map[string]any) ([]string, error) {
+	m.mu.RLock()
+	rules := m.rules
+	m.mu.RUnlock()
+
+	processCtx := types.AcquireProcessContext(path, value)
+	defer types.ReleaseProcessContext(processCtx)
+	seedData(processCtx, data)
+
+	var names []string
+	var errs []error
+	for _, rule := range rules {
+		routeVal, _, err := rule.Route.Eval(processCtx.Activation())
+		if err != nil {
+			errs = append(errs, ruleFailure(rule, fmt.Errorf("route evaluation failed: %w", err)))
+			continue
+		}
+		matched, ok := routeVal.Value().(bool)
+		if !ok {
+			errs = append(errs, ruleFailure(rule, fmt.Errorf("route expression must return boolean, got %T", routeVal.Value())))
+			continue
+		}
+		if matched {
+			names = append(names, rule.Name)
+		}
+	}
+	return names, errors.Join(errs...)
+}
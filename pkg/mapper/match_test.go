@@ -0,0 +1,57 @@
+package mapper
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+)
+
+func TestMatch(t *testing.T) {
+	reg := registry.New()
+	reg.MustRegister("WiFi", func() any { return &TestWifi{} })
+
+	m := New(reg, WithVariable("firmware", cel.StringType))
+	err := m.LoadRulesFromString(`
+version: "1.0"
+rules:
+  - name: new_firmware
+    target: WiFi
+    route: 'firmware.startsWith("2.") && path.endsWith(".SSID")'
+    entity_key: 'path.split(".")[3]'
+    fields:
+      - name: SSID
+        when: 'true'
+        value: 'value'
+  - name: wifi
+    target: WiFi
+    route: 'path.startsWith("Device.WiFi.")'
+    entity_key: 'path.split(".")[3]'
+    fields:
+      - name: SSID
+        when: 'true'
+        value: 'value'
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := m.MatchWithData("Device.WiFi.SSID.1.SSID", "guest", map[string]any{"firmware": "2.4.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(names) != "[new_firmware wifi]" {
+		t.Errorf("MatchWithData = %v", names)
+	}
+
+	names, err = m.Match("Device.WiFi.SSID.1.SSID", "guest")
+	if fmt.Sprint(names) != "[wifi]" {
+		t.Errorf("Match = %v", names)
+	}
+	var mappingErr *MappingError
+	if !errors.As(err, &mappingErr) || mappingErr.Rule != "new_firmware" {
+		t.Errorf("Match error = %v, want failure of new_firmware", err)
+	}
+}
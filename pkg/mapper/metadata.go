@@ -0,0 +1,79 @@
+package mapper
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
+)
+
+type MappingError struct {
+	Rule     string
+	Target   string
+	Key      string
+	Field    string
+	Metadata map[string]string
+	Err      error
+}
+
+func (e *MappingError) Error() string {
+	if len(e.Metadata) == 0 {
+		return fmt.Sprintf("rule %s: %v", e.Rule, e.Err)
+	}
+	return fmt.Sprintf("rule %s (%s): %v", e.Rule, formatMetadata(e.Metadata), e.Err)
+}
+
+func (e *MappingError) Unwrap() error {
+	return e.Err
+}
+
+func formatMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + metadata[k]
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func (r *FastRule) failure(key, field string, err error) error {
+	return &MappingError{
+		Rule:     r.ID,
+		Target:   r.target(),
+		Key:      key,
+		Field:    field,
+		Metadata: r.Metadata,
+		Err:      err,
+	}
+}
+
+func ruleFailure(rule *types.CompiledRule, err error) error {
+	var mappingErr *MappingError
+	if errors.As(err, &mappingErr) {
+		return err
+	}
+	return &MappingError{
+		Rule:     rule.Name,
+		Target:   rule.Target,
+		Metadata: rule.Metadata,
+		Err:      err,
+	}
+}
+
+func copyMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package mapper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+)
+
+func TestMappingErrorFormat(t *testing.T) {
+	cause := errors.New("boom")
+	tests := []struct {
+		name string
+		err  *MappingError
+		want string
+	}{
+		{"plain", &MappingError{Rule: "r", Err: cause}, "rule r: boom"},
+		{"metadata", &MappingError{Rule: "r", Metadata: map[string]string{"team": "wifi", "owner": "ops"}, Err: cause}, "rule r (owner=ops, team=wifi): boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+			if !errors.Is(tt.err, cause) {
+				t.Error("MappingError does not unwrap to its cause")
+			}
+		})
+	}
+}
+
+func TestFastRuleFailureCarriesMetadata(t *testing.T) {
+	var errs []error
+	m := newHostMapper(t, WithFastErrorHandler(func(err error) { errs = append(errs, err) }))
+	rule := hostRule("host_active_flag", "ActiveFlag")
+	rule.Field = "Active"
+	rule.Metadata = map[string]string{"owner": "ops"}
+	if err := m.AddRule(rule); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Process("Device.Hosts.Host.3.ActiveFlag", "maybe"); err != nil {
+		t.Fatal(err)
+	}
+	var mappingErr *MappingError
+	if len(errs) != 1 || !errors.As(errs[0], &mappingErr) {
+		t.Fatalf("errors = %v, want one MappingError", errs)
+	}
+	if mappingErr.Rule != "host_active_flag" || mappingErr.Target != "host" || mappingErr.Key != "3" || mappingErr.Field != "Active" {
+		t.Errorf("MappingError = %+v", mappingErr)
+	}
+	if mappingErr.Metadata["owner"] != "ops" {
+		t.Errorf("Metadata = %v, want owner=ops", mappingErr.Metadata)
+	}
+}
+
+func TestRuleFailureCarriesMetadata(t *testing.T) {
+	var errs []error
+	reg := registry.New()
+	reg.MustRegister("WiFi", func() any { return &TestWifi{} })
+	m := New(reg, WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	err := m.LoadRulesFromString(`
+version: "1.0"
+rules:
+  - name: broken_route
+    target: WiFi
+    route: 'path.endsWith(".Broken") && int(value) > 0'
+    entity_key: 'path.split(".")[3]'
+    metadata:
+      owner: ops
+    fields:
+      - name: SSID
+        when: 'true'
+        value: 'value'
+  - name: wifi
+    target: WiFi
+    route: 'path.endsWith(".Channel")'
+    entity_key: 'path.split(".")[3]'
+    metadata:
+      owner: wifi
+    fields:
+      - name: Channel
+        when: 'true'
+        value: 'value'
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Process("Device.WiFi.Radio.1.Broken", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Process("Device.WiFi.Radio.2.Channel", "auto"); err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2: %v", len(errs), errs)
+	}
+
+	var routeErr, fieldErr *MappingError
+	if !errors.As(errs[0], &routeErr) || routeErr.Rule != "broken_route" || routeErr.Metadata["owner"] != "ops" {
+		t.Errorf("route error = %+v", errs[0])
+	}
+	if !errors.As(errs[1], &fieldErr) || fieldErr.Rule != "wifi" || fieldErr.Key != "2" || fieldErr.Field != "Channel" || fieldErr.Metadata["owner"] != "wifi" {
+		t.Errorf("field error = %+v", errs[1])
+	}
+	var nested *MappingError
+	if errors.As(fieldErr.Err, &nested) {
+		t.Error("field error wrapped in a second MappingError")
+	}
+}
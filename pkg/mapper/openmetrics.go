@@ -0,0 +1,76 @@
+package mapper
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const metricsPrefix = "tr069_mapper_"
+
+type metricsWriter struct {
+	sb strings.Builder
+}
+
+func (w *metricsWriter) counter(name, help string, value int64) {
+	w.family(name, "counter", help)
+	fmt.Fprintf(&w.sb, "%s%s_total %d\n", metricsPrefix, name, value)
+}
+
+func (w *metricsWriter) floatCounter(name, help string, value float64) {
+	w.family(name, "counter", help)
+	fmt.Fprintf(&w.sb, "%s%s_total %s\n", metricsPrefix, name, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (w *metricsWriter) gauge(name, help string, value float64) {
+	w.family(name, "gauge", help)
+	fmt.Fprintf(&w.sb, "%s%s %s\n", metricsPrefix, name, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (w *metricsWriter) family(name, kind, help string) {
+	fmt.Fprintf(&w.sb, "# TYPE %s%s %s\n# HELP %s%s %s\n", metricsPrefix, name, kind, metricsPrefix, name, help)
+}
+
+func (w *metricsWriter) flush(out io.Writer) error {
+	w.sb.WriteString("# EOF\n")
+	if _, err := io.WriteString(out, w.sb.String()); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
+
+func (s *FastStats) WriteOpenMetrics(w io.Writer) error {
+	var mw metricsWriter
+	if s != nil {
+		mw.counter("lines", "Input lines processed.", s.ProcessedLines.Load())
+		mw.counter("matched", "Lines that matched a rule.", s.MatchedRules.Load())
+		mw.counter("unmatched", "Lines that matched no rule.", s.UnmatchedLines.Load())
+		mw.counter("failed", "Lines that failed to apply.", s.FailedRules.Load())
+		mw.counter("skipped_values", "Values skipped after a soft error.", s.SkippedValues.Load())
+		mw.counter("duplicate_lines", "Repeated batch lines skipped by deduplication.", s.DuplicateLines.Load())
+		mw.counter("transform_cache_hits", "Transform cache hits.", s.CacheHits.Load())
+		mw.counter("transform_cache_misses", "Transform cache misses.", s.CacheMisses.Load())
+		mw.counter("pool_allocations", "Entities allocated from a factory.", s.AllocCount.Load())
+		mw.counter("pool_reuses", "Entities reused from the pool.", s.ReuseCount.Load())
+		mw.floatCounter("processing_seconds", "Time spent processing lines.", float64(s.ProcessingNanos.Load())/1e9)
+	}
+	return mw.flush(w)
+}
+
+func (mt *Metrics) WriteOpenMetrics(w io.Writer) error {
+	var mw metricsWriter
+	if mt != nil {
+		mt.mu.RLock()
+		mw.counter("lines", "Input lines processed.", mt.ProcessedLines)
+		mw.counter("matched", "Rules that matched a line.", mt.MatchedRules)
+		mw.counter("failed", "Rules that failed to apply.", mt.FailedRules)
+		mw.counter("skipped_values", "Values skipped after a soft error.", mt.SkippedValues)
+		mw.floatCounter("processing_seconds", "Time spent processing lines.", mt.ProcessingTime.Seconds())
+		if !mt.LastProcessTime.IsZero() {
+			mw.gauge("last_process_timestamp_seconds", "Unix time of the last processed line.", float64(mt.LastProcessTime.UnixNano())/1e9)
+		}
+		mt.mu.RUnlock()
+	}
+	return mw.flush(w)
+}
@@ -0,0 +1,143 @@
+package mapper
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func parseOpenMetrics(t *testing.T, text string) map[string]string {
+	t.Helper()
+	if !strings.HasSuffix(text, "# EOF\n") || strings.Count(text, "# EOF") != 1 {
+		t.Fatalf("output does not end with a single # EOF:\n%s", text)
+	}
+
+	samples := make(map[string]string)
+	kinds := make(map[string]string)
+	helped := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSuffix(text, "# EOF\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		switch {
+		case fields[0] == "#" && fields[1] == "TYPE":
+			kinds[fields[2]] = fields[3]
+		case fields[0] == "#" && fields[1] == "HELP":
+			helped[fields[2]] = len(fields) > 3
+		default:
+			if len(fields) != 2 {
+				t.Fatalf("malformed sample %q", line)
+			}
+			family := fields[0]
+			if kinds[family] == "" {
+				family = strings.TrimSuffix(family, "_total")
+				if kinds[family] != "counter" {
+					t.Errorf("sample %s has no counter family", fields[0])
+				}
+			}
+			if !helped[family] {
+				t.Errorf("family %s has no help text", family)
+			}
+			if !strings.HasPrefix(family, metricsPrefix) {
+				t.Errorf("family %s lacks the %s prefix", family, metricsPrefix)
+			}
+			samples[fields[0]] = fields[1]
+		}
+	}
+	return samples
+}
+
+func TestFastStatsWriteOpenMetrics(t *testing.T) {
+	m := newHostMapper(t, WithFastStats())
+	err := m.ProcessBatch([][2]string{
+		{"Device.Hosts.Host.1.HostName", "laptop"},
+		{"Device.Hosts.Host.1.IPAddress", "10.0.0.1"},
+		{"Device.Hosts.Host.1.Unmapped", "x"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sb strings.Builder
+	if err := m.GetStats().WriteOpenMetrics(&sb); err != nil {
+		t.Fatal(err)
+	}
+	samples := parseOpenMetrics(t, sb.String())
+	for name, want := range map[string]string{
+		"tr069_mapper_lines_total":     "3",
+		"tr069_mapper_matched_total":   "2",
+		"tr069_mapper_unmatched_total": "1",
+		"tr069_mapper_failed_total":    "0",
+	} {
+		if got := samples[name]; got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, ok := samples["tr069_mapper_processing_seconds_total"]; !ok {
+		t.Error("processing time missing")
+	}
+}
+
+func TestMetricsWriteOpenMetrics(t *testing.T) {
+	m := newSerialMapper(t, WithMetrics())
+	var sb strings.Builder
+	if err := m.GetMetrics().WriteOpenMetrics(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := parseOpenMetrics(t, sb.String())["tr069_mapper_last_process_timestamp_seconds"]; ok {
+		t.Error("last process timestamp written before any line was processed")
+	}
+
+	err := m.ProcessBatchWithData(t.Context(), [][2]string{
+		{"Device.WiFi.SSID.1.SSID", "home"},
+		{"Device.Hosts.Host.1.HostName", "laptop"},
+	}, map[string]any{"serial": "SN1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sb.Reset()
+	if err := m.GetMetrics().WriteOpenMetrics(&sb); err != nil {
+		t.Fatal(err)
+	}
+	samples := parseOpenMetrics(t, sb.String())
+	for name, want := range map[string]string{
+		"tr069_mapper_lines_total":   "2",
+		"tr069_mapper_matched_total": "1",
+		"tr069_mapper_failed_total":  "0",
+	} {
+		if got := samples[name]; got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, ok := samples["tr069_mapper_last_process_timestamp_seconds"]; !ok {
+		t.Error("last process timestamp missing")
+	}
+}
+
+func TestWriteOpenMetricsNil(t *testing.T) {
+	var sb strings.Builder
+	if err := (*FastStats)(nil).WriteOpenMetrics(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if err := (*Metrics)(nil).WriteOpenMetrics(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if got := sb.String(); got != "# EOF\n# EOF\n" {
+		t.Errorf("nil stats wrote %q", got)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriteOpenMetricsWriterError(t *testing.T) {
+	err := newHostMapper(t, WithFastStats()).GetStats().WriteOpenMetrics(failingWriter{})
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("err = %v, want the writer error", err)
+	}
+}
@@ -0,0 +1,178 @@
+package mapper
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+func WithOrderedBatches() FastOption {
+	return func(m *FastMapper) {
+		m.ordered = true
+	}
+}
+
+type pretransformed struct {
+	value any
+	hit   bool
+	err   error
+}
+
+type preparedLine struct {
+	line      resolvedLine
+	path      string
+	result    lineResult
+	err       error
+	processed bool
+	missed    bool
+	nanos     int64
+}
+
+func (m *FastMapper) processOrdered(ctx context.Context, items [][2]string, numWorkers int, tally *batchTally) error {
+	prepared := make([]preparedLine, len(items))
+	chunk := (len(items) + numWorkers - 1) / numWorkers
+
+	var wg sync.WaitGroup
+	for from := 0; from < len(items); from += chunk {
+		to := min(from+chunk, len(items))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := from; i < to; i++ {
+				if ctx.Err() != nil {
+					return
+				}
+				prepared[i] = m.prepareLine(ctx, items[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for i := range prepared {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result, err := m.applyPrepared(ctx, &prepared[i])
+		tally.record(result)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *FastMapper) prepareLine(ctx context.Context, item [2]string) (p preparedLine) {
+	if m.recoverPanics {
+		defer m.recoverLine(item[0], nil, &p.result)
+	}
+
+	if m.rejectPath(item[0]) {
+		return preparedLine{result: lineFailed}
+	}
+	path, stripped := m.trimPath(item[0])
+	if m.skipPath(path, item[1]) {
+		return preparedLine{result: lineUnmatched}
+	}
+
+	start := time.Now()
+	p = preparedLine{path: path, processed: true}
+	defer func() {
+		p.nanos = time.Since(start).Nanoseconds()
+	}()
+
+	line, matched, err := m.resolve(ctx, path, nil, item[1], stripped)
+	switch {
+	case err != nil:
+		p.result, p.err = lineFailed, err
+	case !matched:
+		p.result, p.missed = lineUnmatched, true
+	default:
+		line.fields = allowedFields(ctx)
+		if !line.allowsRule() {
+			p.result = lineUnmatched
+			break
+		}
+		line.pre = m.pretransform(line)
+		p.line, p.result = line, lineMatched
+	}
+	return p
+}
+
+func (m *FastMapper) applyPrepared(ctx context.Context, p *preparedLine) (lineResult, error) {
+	return m.applyPreparedWith(ctx, p, m.applyResolved)
+}
+
+func (m *FastMapper) applyPreparedWith(ctx context.Context, p *preparedLine, apply func(resolvedLine) (lineResult, error)) (result lineResult, err error) {
+	if !p.processed {
+		return p.result, nil
+	}
+
+	start := time.Now()
+	if m.stats != nil {
+		defer func() {
+			m.stats.ProcessedLines.Add(1)
+			m.stats.ProcessingNanos.Add(p.nanos + time.Since(start).Nanoseconds())
+		}()
+	}
+
+	if p.err != nil {
+		return lineFailed, p.err
+	}
+	if p.missed {
+		if m.stats != nil {
+			m.stats.UnmatchedLines.Add(1)
+		}
+		m.reportUnmatched(p.path)
+		if m.coverage != nil {
+			m.coverage.miss(p.path)
+		}
+		return lineUnmatched, nil
+	}
+	if p.result != lineMatched {
+		return p.result, nil
+	}
+
+	line := p.line
+	if m.stats != nil {
+		m.stats.MatchedRules.Add(1)
+	}
+	if m.logger != nil {
+		m.logger.Debug("rule matched", "rule", line.rule.ID, "path", p.path, "key", line.key)
+	}
+	if m.coverage != nil {
+		m.coverage.hit(line.rule.ID)
+	}
+
+	result = lineMatched
+	if m.tracer != nil {
+		var span Span
+		_, span = m.tracer.StartRule(ctx, line.rule.ID)
+		defer func() {
+			span.End(result.matched(), result.failed(), nil)
+		}()
+	}
+
+	if m.recoverPanics {
+		result = m.guarded(p.path, func() lineResult {
+			var r lineResult
+			r, err = apply(line)
+			return r
+		})
+		return result, err
+	}
+	result, err = apply(line)
+	return result, err
+}
+
+func (m *FastMapper) pretransform(line resolvedLine) *pretransformed {
+	rule := line.rule
+	if rule.Transform == "" || rule.SetConstant != nil || rule.Precedence > 0 || rule.fansOut() || rule.Counter != CounterOff {
+		return nil
+	}
+	value, hit, err := m.transformer.LookupContext(line.ctx, rule.Transform, line.value)
+	return &pretransformed{value: value, hit: hit, err: err}
+}
@@ -0,0 +1,110 @@
+package mapper
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+)
+
+type countingTracer struct {
+	rules   atomic.Int64
+	matched atomic.Int64
+}
+
+func (t *countingTracer) StartBatch(ctx context.Context, size int) (context.Context, Span) {
+	return ctx, spanFunc(func(matched, failed int64, err error) {})
+}
+
+func (t *countingTracer) StartRule(ctx context.Context, ruleID string) (context.Context, Span) {
+	t.rules.Add(1)
+	return ctx, spanFunc(func(matched, failed int64, err error) {
+		t.matched.Add(matched)
+	})
+}
+
+type spanFunc func(matched, failed int64, err error)
+
+func (f spanFunc) End(matched, failed int64, err error) {
+	f(matched, failed, err)
+}
+
+func TestOrderedBatchLastWriteWins(t *testing.T) {
+	items := make([][2]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		items = append(items, [2]string{fmt.Sprintf("Device.Hosts.Host.%d.HostName", i%37), fmt.Sprintf("name-%d", i)})
+	}
+
+	for run := 0; run < 20; run++ {
+		m := newHostMapper(t, WithOrderedBatches())
+		if err := m.ProcessBatch(items); err != nil {
+			t.Fatal(err)
+		}
+		for key := 0; key < 37; key++ {
+			last := key + (999-key)/37*37
+			want := fmt.Sprintf("name-%d", last)
+			if got := getHost(t, m, fmt.Sprint(key)).HostName; got != want {
+				t.Fatalf("run %d: host %d HostName = %q, want %q", run, key, got, want)
+			}
+		}
+	}
+}
+
+func TestOrderedBatchTracesRules(t *testing.T) {
+	tracer := &countingTracer{}
+	m := newHostMapper(t, WithOrderedBatches(), WithFastTracer(tracer))
+
+	items := make([][2]string, 0, 500)
+	for i := 0; i < 500; i++ {
+		items = append(items, [2]string{fmt.Sprintf("Device.Hosts.Host.%d.HostName", i), "x"})
+	}
+	if err := m.ProcessBatch(items); err != nil {
+		t.Fatal(err)
+	}
+	if got := tracer.rules.Load(); got != 500 {
+		t.Errorf("rule spans = %d, want 500", got)
+	}
+	if got := tracer.matched.Load(); got != 500 {
+		t.Errorf("matched = %d, want 500", got)
+	}
+}
+
+func TestOrderedBatchStatsStopAtError(t *testing.T) {
+	m := newHostMapper(t, WithOrderedBatches(), WithFastStats(), WithCoverage(0))
+	err := m.AddRule(&FastRule{
+		ID:        "ghost",
+		Pattern:   router.CompilePattern("Device.Ghost.*.Name"),
+		Entity:    "ghost",
+		Field:     "Name",
+		Extractor: &extractor.IndexExtractor{Position: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := make([][2]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		items = append(items, [2]string{fmt.Sprintf("Device.Hosts.Host.%d.HostName", i), "x"})
+	}
+	items[500] = [2]string{"Device.Ghost.1.Name", "x"}
+
+	if err := m.ProcessBatch(items); err == nil {
+		t.Fatal("expected error for unregistered entity")
+	}
+
+	stats := m.GetStats()
+	if got := stats.ProcessedLines.Load(); got != 501 {
+		t.Errorf("ProcessedLines = %d, want 501", got)
+	}
+	if got := stats.MatchedRules.Load(); got != 501 {
+		t.Errorf("MatchedRules = %d, want 501", got)
+	}
+	for _, rule := range m.CoverageReport().Rules {
+		if rule.ID == "host_HostName" && rule.Hits != 500 {
+			t.Errorf("host_HostName hits = %d, want 500", rule.Hits)
+		}
+	}
+}
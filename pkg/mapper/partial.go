@@ -0,0 +1,34 @@
+package mapper
+
+import "context"
+
+type fieldAllowlistKey struct{}
+
+func (m *FastMapper) ProcessBatchFields(items [][2]string, fields map[string]bool) error {
+	return m.ProcessBatchFieldsContext(context.Background(), items, fields)
+}
+
+func (m *FastMapper) ProcessBatchFieldsContext(ctx context.Context, items [][2]string, fields map[string]bool) error {
+	return m.ProcessBatchContext(context.WithValue(ctx, fieldAllowlistKey{}, fields), items)
+}
+
+func allowedFields(ctx context.Context) map[string]bool {
+	fields, _ := ctx.Value(fieldAllowlistKey{}).(map[string]bool)
+	return fields
+}
+
+func (l resolvedLine) allows(field string) bool {
+	return l.fields == nil || l.fields[field]
+}
+
+func (l resolvedLine) allowsRule() bool {
+	if l.rule.JSON == nil {
+		return l.allows(l.rule.Field)
+	}
+	for field := range l.rule.JSON.Fields {
+		if l.allows(field) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package mapper
+
+import (
+	"regexp"
+	"strings"
+)
+
+func WithPathPrefixTrim(prefix string) FastOption {
+	return func(m *FastMapper) {
+		m.pathTrim = func(path string) (string, string) {
+			if strings.HasPrefix(path, prefix) {
+				return path[len(prefix):], prefix
+			}
+			return path, ""
+		}
+	}
+}
+
+func WithPathPrefixRegexp(re *regexp.Regexp) FastOption {
+	return func(m *FastMapper) {
+		m.pathTrim = func(path string) (string, string) {
+			loc := re.FindStringIndex(path)
+			if loc == nil || loc[0] != 0 {
+				return path, ""
+			}
+			return path[loc[1]:], path[:loc[1]]
+		}
+	}
+}
+
+func WithTrimmedPrefixAsKey() FastOption {
+	return func(m *FastMapper) {
+		m.trimmedAsKey = true
+	}
+}
+
+func (m *FastMapper) trimPath(path string) (string, string) {
+	if m.pathTrim == nil {
+		return path, ""
+	}
+	return m.pathTrim(path)
+}
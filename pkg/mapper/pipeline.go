@@ -0,0 +1,94 @@
+package mapper
+
+import "context"
+
+type LineProcessor interface {
+	ProcessContext(ctx context.Context, path, value string) error
+}
+
+type LineFunc func(ctx context.Context, path, value string) error
+
+func (f LineFunc) ProcessContext(ctx context.Context, path, value string) error {
+	return f(ctx, path, value)
+}
+
+type Stage interface {
+	Process(ctx context.Context, path, value string, emit func(path, value string) error) error
+}
+
+type StageFunc func(ctx context.Context, path, value string, emit func(path, value string) error) error
+
+func (f StageFunc) Process(ctx context.Context, path, value string, emit func(path, value string) error) error {
+	return f(ctx, path, value, emit)
+}
+
+func MapLines(fn func(path, value string) (string, string, bool)) Stage {
+	return StageFunc(func(ctx context.Context, path, value string, emit func(path, value string) error) error {
+		path, value, ok := fn(path, value)
+		if !ok {
+			return nil
+		}
+		return emit(path, value)
+	})
+}
+
+type batchProcessor interface {
+	ProcessBatchContext(ctx context.Context, items [][2]string) error
+}
+
+type Pipeline struct {
+	stages []Stage
+	sink   LineProcessor
+}
+
+func NewPipeline(sink LineProcessor, stages ...Stage) *Pipeline {
+	return &Pipeline{stages: stages, sink: sink}
+}
+
+func (p *Pipeline) Process(path, value string) error {
+	return p.ProcessContext(context.Background(), path, value)
+}
+
+func (p *Pipeline) ProcessContext(ctx context.Context, path, value string) error {
+	return p.run(ctx, 0, path, value, p.sink.ProcessContext)
+}
+
+func (p *Pipeline) ProcessBatch(items [][2]string) error {
+	return p.ProcessBatchContext(context.Background(), items)
+}
+
+func (p *Pipeline) ProcessBatchContext(ctx context.Context, items [][2]string) error {
+	batch, ok := p.sink.(batchProcessor)
+	if !ok {
+		for _, item := range items {
+			if err := p.ProcessContext(ctx, item[0], item[1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	out := make([][2]string, 0, len(items))
+	collect := func(ctx context.Context, path, value string) error {
+		out = append(out, [2]string{path, value})
+		return nil
+	}
+	for _, item := range items {
+		if err := p.run(ctx, 0, item[0], item[1], collect); err != nil {
+			return err
+		}
+	}
+	return batch.ProcessBatchContext(ctx, out)
+}
+
+func (p *Pipeline) run(ctx context.Context, i int, path, value string, sink func(ctx context.Context, path, value string) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if i == len(p.stages) {
+		return sink(ctx, path, value)
+	}
+	return p.stages[i].Process(ctx, path, value, func(path, value string) error {
+		return p.run(ctx, i+1, path, value, sink)
+	})
+}
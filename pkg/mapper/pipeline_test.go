@@ -0,0 +1,151 @@
+package mapper
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type recordedLines struct {
+	lines [][2]string
+}
+
+func (r *recordedLines) ProcessContext(ctx context.Context, path, value string) error {
+	r.lines = append(r.lines, [2]string{path, value})
+	return nil
+}
+
+func renameStage(from, to string) Stage {
+	return MapLines(func(path, value string) (string, string, bool) {
+		return strings.Replace(path, from, to, 1), value, true
+	})
+}
+
+func TestPipelineStagesRunInOrder(t *testing.T) {
+	sink := &recordedLines{}
+	p := NewPipeline(sink,
+		MapLines(func(path, value string) (string, string, bool) {
+			return path, value, !strings.HasSuffix(path, ".Ignored")
+		}),
+		renameStage("InternetGatewayDevice.", "Device."),
+		renameStage("Device.LANDevice.1.Hosts.", "Device.Hosts."),
+		StageFunc(func(ctx context.Context, path, value string, emit func(path, value string) error) error {
+			if err := emit(path, value); err != nil {
+				return err
+			}
+			if strings.HasSuffix(path, ".HostName") {
+				return emit(strings.TrimSuffix(path, "HostName")+"Active", "true")
+			}
+			return nil
+		}),
+	)
+
+	items := [][2]string{
+		{"InternetGatewayDevice.LANDevice.1.Hosts.Host.1.HostName", "laptop"},
+		{"InternetGatewayDevice.LANDevice.1.Hosts.Host.1.Ignored", "x"},
+		{"Device.Hosts.Host.2.IPAddress", "10.0.0.2"},
+	}
+	if err := p.ProcessBatch(items); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][2]string{
+		{"Device.Hosts.Host.1.HostName", "laptop"},
+		{"Device.Hosts.Host.1.Active", "true"},
+		{"Device.Hosts.Host.2.IPAddress", "10.0.0.2"},
+	}
+	if !reflect.DeepEqual(sink.lines, want) {
+		t.Errorf("sink received %v, want %v", sink.lines, want)
+	}
+}
+
+func TestPipelineFeedsMapperBatch(t *testing.T) {
+	m := newHostMapper(t, WithFastStats())
+	p := NewPipeline(m, renameStage("InternetGatewayDevice.LANDevice.1.", "Device."))
+
+	err := p.ProcessBatch([][2]string{
+		{"InternetGatewayDevice.LANDevice.1.Hosts.Host.1.HostName", "laptop"},
+		{"InternetGatewayDevice.LANDevice.1.Hosts.Host.1.IPAddress", "10.0.0.1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Process("InternetGatewayDevice.LANDevice.1.Hosts.Host.2.HostName", "phone"); err != nil {
+		t.Fatal(err)
+	}
+
+	if host := getHost(t, m, "1"); host.HostName != "laptop" || host.IPAddress != "10.0.0.1" {
+		t.Errorf("host 1 = %+v", host)
+	}
+	if host := getHost(t, m, "2"); host.HostName != "phone" {
+		t.Errorf("host 2 = %+v", host)
+	}
+	if got := m.GetStats().MatchedRules.Load(); got != 3 {
+		t.Errorf("MatchedRules = %d, want 3", got)
+	}
+}
+
+func TestPipelineNested(t *testing.T) {
+	sink := &recordedLines{}
+	inner := NewPipeline(sink, renameStage("B.", "C."))
+	outer := NewPipeline(inner, renameStage("A.", "B."))
+
+	if err := outer.Process("A.x", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if want := [][2]string{{"C.x", "1"}}; !reflect.DeepEqual(sink.lines, want) {
+		t.Errorf("sink received %v, want %v", sink.lines, want)
+	}
+}
+
+func TestPipelineStageError(t *testing.T) {
+	stageErr := errors.New("bad line")
+	sink := &recordedLines{}
+	p := NewPipeline(sink, StageFunc(func(ctx context.Context, path, value string, emit func(path, value string) error) error {
+		if value == "bad" {
+			return stageErr
+		}
+		return emit(path, value)
+	}))
+
+	err := p.ProcessBatch([][2]string{{"a", "ok"}, {"b", "bad"}, {"c", "ok"}})
+	if !errors.Is(err, stageErr) {
+		t.Fatalf("err = %v, want the stage error", err)
+	}
+	if want := [][2]string{{"a", "ok"}}; !reflect.DeepEqual(sink.lines, want) {
+		t.Errorf("sink received %v, want %v", sink.lines, want)
+	}
+
+	m := newHostMapper(t)
+	p = NewPipeline(m, StageFunc(func(ctx context.Context, path, value string, emit func(path, value string) error) error {
+		return stageErr
+	}))
+	if err := p.ProcessBatch([][2]string{{"Device.Hosts.Host.1.HostName", "laptop"}}); !errors.Is(err, stageErr) {
+		t.Fatalf("err = %v, want the stage error", err)
+	}
+	if n := len(m.GetStore().GetAll("host")); n != 0 {
+		t.Errorf("%d hosts stored after a failed batch", n)
+	}
+}
+
+func TestPipelineCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sink := &recordedLines{}
+	p := NewPipeline(LineFunc(func(ctx context.Context, path, value string) error {
+		if err := sink.ProcessContext(ctx, path, value); err != nil {
+			return err
+		}
+		cancel()
+		return nil
+	}), renameStage("A.", "B."))
+
+	err := p.ProcessBatchContext(ctx, [][2]string{{"A.1", "x"}, {"A.2", "y"}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if want := [][2]string{{"B.1", "x"}}; !reflect.DeepEqual(sink.lines, want) {
+		t.Errorf("sink received %v, want %v", sink.lines, want)
+	}
+}
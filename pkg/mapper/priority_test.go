@@ -0,0 +1,124 @@
+package mapper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/builder"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+)
+
+const priorityRules = `
+version: "1.0"
+rules:
+  - name: wifi
+    target: WiFi
+    route: 'path.startsWith("Device.WiFi.SSID.")'
+    entity_key: 'path.split(".")[3]'
+    fields:
+      - name: SSID
+        when: 'path.endsWith(".SSID")'
+        value: 'value'
+  - name: wifi_fallback
+    target: WiFi
+    route: 'path.startsWith("Device.WiFi.")'
+    entity_key: '"fallback"'
+    fields:
+      - name: SSID
+        when: 'true'
+        value: 'value'
+  - name: guest_wifi
+    target: GuestWiFi
+    route: 'path.startsWith("Device.WiFi.SSID.5.")'
+    entity_key: '"guest"'
+    priority: 10
+    fields:
+      - name: SSID
+        when: 'path.endsWith(".SSID")'
+        value: 'value'
+  - name: disabled_wifi
+    target: WiFi
+    route: 'path.startsWith("Device.WiFi.SSID.9.")'
+    entity_key: '"disabled"'
+    priority: -1
+    fields:
+      - name: SSID
+        when: 'true'
+        value: 'value'
+`
+
+func newPriorityRegistry() *registry.Registry {
+	reg := registry.New()
+	reg.MustRegister("WiFi", func() any { return &TestWifi{} })
+	reg.MustRegister("GuestWiFi", func() any { return &TestWifi{} })
+	return reg
+}
+
+func TestRulePriorityOrder(t *testing.T) {
+	m := New(newPriorityRegistry())
+	if err := m.LoadRulesFromString(priorityRules); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"guest_wifi", "wifi", "wifi_fallback", "disabled_wifi"}
+	if got := m.GetRuleNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("rule order %v, want %v", got, want)
+	}
+	for _, info := range m.GetRules() {
+		if info.Name == "guest_wifi" && info.Priority != 10 {
+			t.Errorf("guest_wifi priority = %d, want 10", info.Priority)
+		}
+	}
+
+	err := m.ProcessBatch([][2]string{
+		{"Device.WiFi.SSID.1.SSID", "home"},
+		{"Device.WiFi.SSID.5.SSID", "guest"},
+		{"Device.WiFi.SSID.9.SSID", "off"},
+		{"Device.WiFi.Radio.1.Channel", "6"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := m.GetStore()
+	if obj, ok := store.Get("GuestWiFi", "guest"); !ok || obj.(*TestWifi).SSID != "guest" {
+		t.Errorf("higher priority rule did not win: %v", obj)
+	}
+	if _, ok := store.Get("WiFi", "5"); ok {
+		t.Error("lower priority rule also handled the guest line")
+	}
+	if _, ok := store.Get("WiFi", "9"); !ok {
+		t.Error("equal priority rule in file order did not win over a negative priority")
+	}
+	if _, ok := store.Get("WiFi", "disabled"); ok {
+		t.Error("negative priority rule handled a line")
+	}
+	if obj, ok := store.Get("WiFi", "fallback"); !ok || obj.(*TestWifi).SSID != "6" {
+		t.Errorf("fallback rule did not handle the radio line: %v", obj)
+	}
+}
+
+func TestRulePriorityPrecompiled(t *testing.T) {
+	reg := newPriorityRegistry()
+	rules, err := builder.New(reg).WithStandardVariables().BuildFromString(priorityRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	declared := make([]string, len(rules))
+	for i, rule := range rules {
+		declared[i] = rule.Name
+	}
+
+	m := New(reg)
+	if err := m.LoadRules(rules); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.GetRuleNames(), []string{"guest_wifi", "wifi", "wifi_fallback", "disabled_wifi"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rule order %v, want %v", got, want)
+	}
+	for i, rule := range rules {
+		if rule.Name != declared[i] {
+			t.Fatalf("LoadRules reordered the caller's slice: %s at %d, want %s", rule.Name, i, declared[i])
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package mapper
+
+import "context"
+
+func (m *FastMapper) ProcessMap(params map[string]string) error {
+	return m.ProcessMapContext(context.Background(), params)
+}
+
+func (m *FastMapper) ProcessMapContext(ctx context.Context, params map[string]string) (err error) {
+	if m.closed.Load() {
+		return ErrClosed
+	}
+	var tally *batchTally
+	if m.tracer != nil {
+		var span Span
+		ctx, span = m.tracer.StartBatch(ctx, len(params))
+		tally = &batchTally{}
+		defer func() {
+			span.End(tally.matched.Load(), tally.failed.Load(), err)
+		}()
+	}
+	defer func() {
+		if err == nil {
+			m.reportIncomplete()
+		}
+	}()
+	m.unmatched.begin()
+	defer m.unmatched.end()
+
+	for path, value := range params {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result, err := m.processLine(ctx, path, value)
+		tally.record(result)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Mapper) ProcessMap(params map[string]string) error {
+	return m.ProcessMapWithContext(context.Background(), params)
+}
+
+func (m *Mapper) ProcessMapWithContext(ctx context.Context, params map[string]string) (err error) {
+	if m.closed.Load() {
+		return ErrClosed
+	}
+	var tally *batchTally
+	if m.tracer != nil {
+		var span Span
+		ctx, span = m.tracer.StartBatch(ctx, len(params))
+		tally = &batchTally{}
+		defer func() {
+			span.End(tally.matched.Load(), tally.failed.Load(), err)
+		}()
+	}
+	touched := make(touchedEntities)
+	for path, value := range params {
+		result, err := m.processLine(ctx, path, value, nil, touched)
+		tally.record(result)
+		if err != nil {
+			return err
+		}
+	}
+	if err := m.applyDerived(ctx, touched); err != nil {
+		return err
+	}
+	m.reportIncomplete()
+	return nil
+}
@@ -0,0 +1,54 @@
+package mapper
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+)
+
+type PanicError struct {
+	Path  string
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic while processing %s: %v", e.Path, e.Value)
+}
+
+func WithPanicRecovery() FastOption {
+	return func(m *FastMapper) {
+		m.recoverPanics = true
+	}
+}
+
+func (m *FastMapper) processRecovering(ctx context.Context, path string, parts []string, value string) (line resolvedLine, result lineResult, err error) {
+	defer m.recoverLine(path, parts, &result)
+	return m.processUnguarded(ctx, path, parts, value)
+}
+
+func (m *FastMapper) guarded(path string, fn func() lineResult) (result lineResult) {
+	defer m.recoverLine(path, nil, &result)
+	return fn()
+}
+
+func (m *FastMapper) recoverLine(path string, parts []string, result *lineResult) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if path == "" && parts != nil {
+		path = router.JoinParts(parts)
+	}
+
+	*result = lineFailed
+	if m.logger != nil {
+		m.logger.Error("panic recovered", "path", path, "panic", r)
+	}
+	if m.stats != nil {
+		m.stats.FailedRules.Add(1)
+	}
+	m.errorHandler(&PanicError{Path: path, Value: r, Stack: debug.Stack()})
+}
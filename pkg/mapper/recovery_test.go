@@ -0,0 +1,112 @@
+package mapper
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/transform"
+)
+
+func init() {
+	transform.Register("test_panic", func(value string) (any, error) {
+		if value == "panic" {
+			panic("transform exploded")
+		}
+		return value, nil
+	})
+}
+
+func newPanickingMapper(t *testing.T, opts ...FastOption) *FastMapper {
+	t.Helper()
+	m := newHostMapper(t, opts...)
+	rule := hostRule("host_Layer2", "Layer2Interface")
+	rule.Field = "HostName"
+	rule.Transform = "test_panic"
+	if err := m.AddRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestPanicRecovery(t *testing.T) {
+	var errs []error
+	m := newPanickingMapper(t, WithPanicRecovery(), WithFastStats(), WithFastErrorHandler(func(err error) { errs = append(errs, err) }))
+
+	if err := m.Process("Device.Hosts.Host.1.Layer2Interface", "panic"); err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want 1", len(errs))
+	}
+	var panicErr *PanicError
+	if !errors.As(errs[0], &panicErr) {
+		t.Fatalf("error = %v, want PanicError", errs[0])
+	}
+	if panicErr.Path != "Device.Hosts.Host.1.Layer2Interface" || panicErr.Value != "transform exploded" {
+		t.Errorf("PanicError = %q, %v", panicErr.Path, panicErr.Value)
+	}
+	if len(panicErr.Stack) == 0 {
+		t.Error("PanicError has no stack")
+	}
+	if got := m.GetStats().FailedRules.Load(); got != 1 {
+		t.Errorf("FailedRules = %d, want 1", got)
+	}
+
+	if err := m.ProcessParts([]string{"Device", "Hosts", "Host", "2", "Layer2Interface"}, "panic"); err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 2 || !errors.As(errs[1], &panicErr) || panicErr.Path != "Device.Hosts.Host.2.Layer2Interface" {
+		t.Errorf("ProcessParts errors = %v", errs)
+	}
+
+	if err := m.Process("Device.Hosts.Host.1.Layer2Interface", "eth0"); err != nil {
+		t.Fatal(err)
+	}
+	if got := getHost(t, m, "1").HostName; got != "eth0" {
+		t.Errorf("HostName = %q, want eth0", got)
+	}
+}
+
+func TestPanicRecoveryBatch(t *testing.T) {
+	var mu sync.Mutex
+	var panics int
+	m := newPanickingMapper(t, WithPanicRecovery(), WithFastErrorHandler(func(err error) {
+		var panicErr *PanicError
+		if errors.As(err, &panicErr) {
+			mu.Lock()
+			panics++
+			mu.Unlock()
+		}
+	}))
+
+	items := make([][2]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		value := "eth0"
+		if i%100 == 0 {
+			value = "panic"
+		}
+		items = append(items, [2]string{fmt.Sprintf("Device.Hosts.Host.%d.Layer2Interface", i), value})
+	}
+	if err := m.ProcessBatch(items); err != nil {
+		t.Fatal(err)
+	}
+	if panics != 10 {
+		t.Errorf("recovered %d panics, want 10", panics)
+	}
+	if got := getHost(t, m, "999").HostName; got != "eth0" {
+		t.Errorf("HostName = %q, want eth0", got)
+	}
+}
+
+func TestPanicWithoutRecovery(t *testing.T) {
+	m := newPanickingMapper(t)
+	defer func() {
+		if r := recover(); r != "transform exploded" {
+			t.Errorf("recovered %v, want the transform panic", r)
+		}
+	}()
+	_ = m.Process("Device.Hosts.Host.1.Layer2Interface", "panic")
+	t.Error("Process did not panic without WithPanicRecovery")
+}
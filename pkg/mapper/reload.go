@@ -0,0 +1,138 @@
+package mapper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+)
+
+type ReloadSummary struct {
+	Added     []string
+	Removed   []string
+	Replaced  []string
+	Unchanged []string
+}
+
+func (s ReloadSummary) Changed() bool {
+	return len(s.Added) > 0 || len(s.Removed) > 0 || len(s.Replaced) > 0
+}
+
+func (m *FastMapper) RemoveRule(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rule, ok := m.rules[id]
+	if !ok {
+		return fmt.Errorf("rule %s not found", id)
+	}
+	remover, err := m.remover()
+	if err != nil {
+		return err
+	}
+
+	m.removeRuleLocked(remover, rule)
+	m.targets = make(map[string]string, len(m.rules))
+	for _, r := range m.rules {
+		m.targets[r.target()] = r.Entity
+	}
+	return nil
+}
+
+func (m *FastMapper) ReloadRules(rules []*FastRule) (ReloadSummary, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next := make(map[string]*FastRule, len(rules))
+	targets := make(map[string]string)
+	for _, rule := range rules {
+		if _, ok := next[rule.ID]; ok {
+			return ReloadSummary{}, fmt.Errorf("rule %s: duplicate rule ID", rule.ID)
+		}
+		if entity, ok := targets[rule.target()]; ok && entity != rule.Entity {
+			return ReloadSummary{}, fmt.Errorf("rule %s: store target %s already maps to entity %s", rule.ID, rule.target(), entity)
+		}
+		copied, err := m.validatedCopy(rule)
+		if err != nil {
+			return ReloadSummary{}, err
+		}
+		next[rule.ID] = copied
+		targets[rule.target()] = rule.Entity
+	}
+
+	var summary ReloadSummary
+	for id, current := range m.rules {
+		rule, ok := next[id]
+		switch {
+		case !ok:
+			summary.Removed = append(summary.Removed, id)
+		case !sameRule(current, rule):
+			summary.Replaced = append(summary.Replaced, id)
+		default:
+			summary.Unchanged = append(summary.Unchanged, id)
+		}
+	}
+	for id := range next {
+		if _, ok := m.rules[id]; !ok {
+			summary.Added = append(summary.Added, id)
+		}
+	}
+	sort.Strings(summary.Added)
+	sort.Strings(summary.Removed)
+	sort.Strings(summary.Replaced)
+	sort.Strings(summary.Unchanged)
+
+	if len(summary.Removed) > 0 || len(summary.Replaced) > 0 {
+		remover, err := m.remover()
+		if err != nil {
+			return ReloadSummary{}, err
+		}
+		for _, id := range summary.Removed {
+			m.removeRuleLocked(remover, m.rules[id])
+		}
+		for _, id := range summary.Replaced {
+			m.removeRuleLocked(remover, m.rules[id])
+		}
+	}
+
+	for _, id := range append(summary.Replaced, summary.Added...) {
+		rule := next[id]
+		rule.Pattern.ID = rule.ID
+		m.router.AddPattern(rule.Pattern)
+		m.rules[id] = rule
+	}
+	m.targets = targets
+
+	if m.logger != nil && summary.Changed() {
+		m.logger.Info("rules reloaded", "added", len(summary.Added), "removed", len(summary.Removed), "replaced", len(summary.Replaced), "unchanged", len(summary.Unchanged))
+	}
+	return summary, nil
+}
+
+func (m *FastMapper) remover() (router.RemovableRouter, error) {
+	remover, ok := m.router.(router.RemovableRouter)
+	if !ok {
+		return nil, fmt.Errorf("router %T does not support removing patterns", m.router)
+	}
+	return remover, nil
+}
+
+func (m *FastMapper) removeRuleLocked(remover router.RemovableRouter, rule *FastRule) {
+	remover.RemovePattern(rule.Pattern)
+	delete(m.rules, rule.ID)
+	if m.coverage != nil {
+		m.coverage.forget(rule.ID)
+	}
+}
+
+func sameRule(a, b *FastRule) bool {
+	ra, rb := *a, *b
+	ra.Pattern, rb.Pattern = nil, nil
+	if !reflect.DeepEqual(ra, rb) {
+		return false
+	}
+	pa, pb := *a.Pattern, *b.Pattern
+	pa.ID, pb.ID = "", ""
+	return reflect.DeepEqual(pa, pb)
+}
@@ -0,0 +1,69 @@
+package mapper
+
+import "testing"
+
+func coverageHits(m *FastMapper) map[string]int64 {
+	hits := make(map[string]int64)
+	for _, rule := range m.CoverageReport().Rules {
+		hits[rule.ID] = rule.Hits
+	}
+	return hits
+}
+
+func TestReloadRulesKeepsCoverageForUnchangedRules(t *testing.T) {
+	m := newHostMapper(t, WithCoverage(0))
+	for _, path := range []string{"Device.Hosts.Host.1.HostName", "Device.Hosts.Host.1.IPAddress"} {
+		if err := m.Process(path, "x"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replaced := hostRule("host_IPAddress", "IPAddress")
+	replaced.Transform = "ip_validate"
+	summary, err := m.ReloadRules([]*FastRule{
+		hostRule("host_MACAddress", "MACAddress"),
+		hostRule("host_HostName", "HostName"),
+		hostRule("host_Active", "Active"),
+		replaced,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Replaced) != 1 || summary.Replaced[0] != "host_IPAddress" {
+		t.Errorf("Replaced = %v", summary.Replaced)
+	}
+
+	hits := coverageHits(m)
+	if hits["host_HostName"] != 1 {
+		t.Errorf("host_HostName hits = %d, want 1", hits["host_HostName"])
+	}
+	if hits["host_IPAddress"] != 0 {
+		t.Errorf("host_IPAddress hits = %d, want 0", hits["host_IPAddress"])
+	}
+}
+
+func TestReloadRulesRejectsWithoutMutating(t *testing.T) {
+	m := newHostMapper(t)
+	bySpec := hostRule("by_spec", "HostName")
+	bySpec.Extractor = nil
+	bySpec.ExtractorSpec = "path[3]"
+	bad := hostRule("bad", "IPAddress")
+	bad.Transform = "no_such_transform"
+
+	if _, err := m.ReloadRules([]*FastRule{bySpec, bad}); err == nil {
+		t.Fatal("expected error for unknown transform")
+	}
+	if bySpec.Extractor != nil {
+		t.Error("rejected reload mutated the caller's rule")
+	}
+	if _, ok := m.rules["host_HostName"]; !ok {
+		t.Error("rejected reload removed existing rules")
+	}
+
+	if _, err := m.ReloadRules([]*FastRule{bySpec, hostRule("by_spec", "IPAddress")}); err == nil {
+		t.Error("expected error for duplicate rule ID")
+	}
+	if bySpec.Extractor != nil {
+		t.Error("rejected reload mutated the caller's rule")
+	}
+}
@@ -0,0 +1,144 @@
+package mapper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
+)
+
+type IncompleteEntityError struct {
+	Target  string
+	Key     string
+	Missing []string
+}
+
+func (e *IncompleteEntityError) Error() string {
+	return fmt.Sprintf("%s[%s]: missing required fields %s", e.Target, e.Key, strings.Join(e.Missing, ", "))
+}
+
+type requiredFields struct {
+	fields map[string][]string
+	drop   bool
+}
+
+func (r *requiredFields) add(target string, fields []string) {
+	if r.fields == nil {
+		r.fields = make(map[string][]string)
+	}
+	r.fields[target] = append(r.fields[target], fields...)
+}
+
+func (r requiredFields) clone() requiredFields {
+	c := requiredFields{drop: r.drop}
+	for target, fields := range r.fields {
+		c.add(target, append([]string(nil), fields...))
+	}
+	return c
+}
+
+func WithRequiredFields(target string, fields ...string) Option {
+	return func(m *Mapper) {
+		m.required.add(target, fields)
+	}
+}
+
+func WithDropIncomplete() Option {
+	return func(m *Mapper) {
+		m.required.drop = true
+	}
+}
+
+func WithFastRequiredFields(target string, fields ...string) FastOption {
+	return func(m *FastMapper) {
+		m.required.add(target, fields)
+	}
+}
+
+func WithFastDropIncomplete() FastOption {
+	return func(m *FastMapper) {
+		m.required.drop = true
+	}
+}
+
+func (m *Mapper) CheckRequired() []error {
+	return m.required.check(m.registry.Get, m.store)
+}
+
+func (m *FastMapper) CheckRequired() []error {
+	return m.required.check(m.targetType, m.store)
+}
+
+func (m *Mapper) reportIncomplete() {
+	for _, err := range m.CheckRequired() {
+		m.errorHandler(err)
+	}
+}
+
+func (m *FastMapper) reportIncomplete() {
+	for _, err := range m.CheckRequired() {
+		m.errorHandler(err)
+	}
+}
+
+func (r *requiredFields) check(lookup func(target string) (*registry.TypeInfo, error), store types.Store) []error {
+	if len(r.fields) == 0 {
+		return nil
+	}
+
+	targets := make([]string, 0, len(r.fields))
+	for target := range r.fields {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	var errs []error
+	drop := r.drop
+	for _, target := range targets {
+		info, err := lookup(target)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		entities := store.GetAll(target)
+		keys := make([]string, 0, len(entities))
+		for key := range entities {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			missing := missingFields(info, entities[key], r.fields[target])
+			if len(missing) == 0 {
+				continue
+			}
+			errs = append(errs, &IncompleteEntityError{Target: target, Key: key, Missing: missing})
+			if drop {
+				if err := types.DeleteEntity(store, target, key); err != nil {
+					errs = append(errs, err)
+					drop = false
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func missingFields(info *registry.TypeInfo, obj any, fields []string) []string {
+	var missing []string
+	for _, field := range fields {
+		getter, ok := info.Getters[field]
+		if !ok {
+			missing = append(missing, field)
+			continue
+		}
+		if v := reflect.ValueOf(getter(obj)); !v.IsValid() || v.IsZero() {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,211 @@
+package mapper
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
+)
+
+type incompleteCollector struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (c *incompleteCollector) handle(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errs = append(c.errs, err)
+}
+
+func (c *incompleteCollector) incomplete(t *testing.T) []IncompleteEntityError {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var got []IncompleteEntityError
+	for _, err := range c.errs {
+		var incomplete *IncompleteEntityError
+		if !errors.As(err, &incomplete) {
+			t.Errorf("unexpected error %v", err)
+			continue
+		}
+		got = append(got, *incomplete)
+	}
+	return got
+}
+
+var hostBatch = [][2]string{
+	{"Device.Hosts.Host.1.MACAddress", "aa:bb:cc:dd:ee:01"},
+	{"Device.Hosts.Host.1.IPAddress", "10.0.0.1"},
+	{"Device.Hosts.Host.2.MACAddress", "aa:bb:cc:dd:ee:02"},
+	{"Device.Hosts.Host.3.HostName", "printer"},
+}
+
+func TestRequiredFieldsReported(t *testing.T) {
+	c := &incompleteCollector{}
+	m := newHostMapper(t,
+		WithFastRequiredFields("host", "MACAddress", "IPAddress"),
+		WithFastErrorHandler(c.handle))
+
+	if err := m.ProcessBatch(hostBatch); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []IncompleteEntityError{
+		{Target: "host", Key: "2", Missing: []string{"IPAddress"}},
+		{Target: "host", Key: "3", Missing: []string{"MACAddress", "IPAddress"}},
+	}
+	if got := c.incomplete(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("reported %+v, want %+v", got, want)
+	}
+	if n := len(m.GetStore().GetAll("host")); n != 3 {
+		t.Errorf("%d hosts stored, want all 3 kept", n)
+	}
+	if got := want[1].Error(); got != "host[3]: missing required fields MACAddress, IPAddress" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestRequiredFieldsDropIncomplete(t *testing.T) {
+	c := &incompleteCollector{}
+	m := newHostMapper(t,
+		WithFastRequiredFields("host", "MACAddress"),
+		WithFastRequiredFields("host", "IPAddress"),
+		WithFastDropIncomplete(),
+		WithFastErrorHandler(c.handle))
+
+	if err := m.ProcessBatchGrouped(hostBatch); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(c.incomplete(t)); got != 2 {
+		t.Errorf("%d incomplete entities reported, want 2", got)
+	}
+	var keys []string
+	for key := range m.GetStore().GetAll("host") {
+		keys = append(keys, key)
+	}
+	if !reflect.DeepEqual(keys, []string{"1"}) {
+		t.Errorf("stored hosts %v, want [1]", keys)
+	}
+}
+
+func TestRequiredFieldsZeroValues(t *testing.T) {
+	m := newHostMapper(t, WithFastRequiredFields("host", "Active", "NoSuchField"))
+	if err := m.ProcessBatch([][2]string{{"Device.Hosts.Host.1.Active", "false"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := m.CheckRequired()
+	if len(errs) != 1 {
+		t.Fatalf("CheckRequired = %v, want one error", errs)
+	}
+	var incomplete *IncompleteEntityError
+	if !errors.As(errs[0], &incomplete) {
+		t.Fatalf("error %v is not an IncompleteEntityError", errs[0])
+	}
+	if want := []string{"Active", "NoSuchField"}; !reflect.DeepEqual(incomplete.Missing, want) {
+		t.Errorf("missing %v, want %v", incomplete.Missing, want)
+	}
+}
+
+type nonDeletingStore struct {
+	types.Store
+}
+
+func TestRequiredFieldsDropWithoutDeleter(t *testing.T) {
+	store := nonDeletingStore{types.NewMapStore()}
+	m := newHostMapper(t,
+		WithFastStore(store),
+		WithFastRequiredFields("host", "IPAddress"),
+		WithFastDropIncomplete())
+
+	if err := m.ProcessBatch(hostBatch); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := m.CheckRequired()
+	var deleteErrs int
+	for _, err := range errs {
+		var incomplete *IncompleteEntityError
+		if !errors.As(err, &incomplete) {
+			deleteErrs++
+		}
+	}
+	if len(errs) != 3 || deleteErrs != 1 {
+		t.Errorf("CheckRequired = %v, want two incomplete entities and one delete error", errs)
+	}
+	if n := len(store.GetAll("host")); n != 3 {
+		t.Errorf("%d hosts stored, want 3 kept", n)
+	}
+}
+
+func TestRequiredFieldsUnknownTarget(t *testing.T) {
+	m := newHostMapper(t, WithFastRequiredFields("router", "Serial"))
+	if errs := m.CheckRequired(); len(errs) != 1 {
+		t.Errorf("CheckRequired = %v, want an unknown target error", errs)
+	}
+}
+
+func TestRequiredFieldsStream(t *testing.T) {
+	c := &incompleteCollector{}
+	m := newHostMapper(t,
+		WithFastRequiredFields("host", "IPAddress"),
+		WithFastDropIncomplete(),
+		WithFastErrorHandler(c.handle))
+
+	var lines []string
+	for _, item := range hostBatch {
+		lines = append(lines, item[0]+"\t"+item[1])
+	}
+	var keys []string
+	for _, ev := range collect(m.ProcessStreamEmit(strings.NewReader(strings.Join(lines, "\n")))) {
+		if ev.Err != nil {
+			t.Fatal(ev.Err)
+		}
+		keys = append(keys, ev.Key)
+	}
+
+	if fmt.Sprint(keys) != "[1]" {
+		t.Errorf("emitted %v, want [1]", keys)
+	}
+	if got := len(c.incomplete(t)); got != 2 {
+		t.Errorf("%d incomplete entities reported, want 2", got)
+	}
+}
+
+func TestMapperRequiredFields(t *testing.T) {
+	c := &incompleteCollector{}
+	m := newSerialMapper(t,
+		WithRequiredFields("WiFi", "SSID", "Password"),
+		WithErrorHandler(c.handle))
+
+	err := m.ProcessBatchWithData(t.Context(), [][2]string{
+		{"Device.WiFi.SSID.1.SSID", "home"},
+	}, map[string]any{"serial": "SN1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []IncompleteEntityError{{Target: "WiFi", Key: "SN1/1", Missing: []string{"Password"}}}
+	if got := c.incomplete(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("reported %+v, want %+v", got, want)
+	}
+
+	clone := m.Clone(WithDropIncomplete())
+	if err := clone.ProcessBatchWithData(t.Context(), [][2]string{
+		{"Device.WiFi.SSID.2.SSID", "guest"},
+	}, map[string]any{"serial": "SN1"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(clone.GetStore().GetAll("WiFi")); n != 0 {
+		t.Errorf("%d incomplete WiFi entities kept after drop", n)
+	}
+	if n := len(m.GetStore().GetAll("WiFi")); n != 1 {
+		t.Errorf("Clone option changed the original mapper: %d entities", n)
+	}
+}
@@ -0,0 +1,99 @@
+package mapper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
+)
+
+type RuleInfo struct {
+	Name      string
+	Target    string
+	Route     string
+	EntityKey string
+	Priority  int
+	Metadata  map[string]string
+	Fields    []types.FieldMapping
+	Derived   []types.DerivedField
+}
+
+type FastRuleInfo struct {
+	ID           string
+	Pattern      string
+	Entity       string
+	StoreTarget  string
+	Field        string
+	Transform    string
+	Counter      CounterMode
+	KeyTransform string
+	Extractor    string
+	Precedence   int
+	JSON         bool
+	Split        bool
+	SetConstant  any
+	Metadata     map[string]string
+}
+
+func (m *Mapper) GetRules() []RuleInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	infos := make([]RuleInfo, len(m.rules))
+	for i, rule := range m.rules {
+		src := rule.Source
+		infos[i] = RuleInfo{
+			Name:      rule.Name,
+			Target:    rule.Target,
+			Route:     src.Route,
+			EntityKey: src.EntityKey,
+			Priority:  src.Priority,
+			Metadata:  copyMetadata(rule.Metadata),
+			Fields:    append([]types.FieldMapping(nil), src.Fields...),
+			Derived:   append([]types.DerivedField(nil), src.Derived...),
+		}
+	}
+	return infos
+}
+
+func (m *FastMapper) GetRules() []FastRuleInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	infos := make([]FastRuleInfo, 0, len(m.rules))
+	for _, rule := range m.rules {
+		info := FastRuleInfo{
+			ID:           rule.ID,
+			Entity:       rule.Entity,
+			StoreTarget:  rule.StoreTarget,
+			Field:        rule.Field,
+			Transform:    rule.Transform,
+			Counter:      rule.Counter,
+			KeyTransform: rule.KeyTransform,
+			Extractor:    describeExtractor(rule),
+			Precedence:   rule.Precedence,
+			JSON:         rule.JSON != nil,
+			Split:        rule.Split != nil,
+			SetConstant:  rule.SetConstant,
+			Metadata:     copyMetadata(rule.Metadata),
+		}
+		if rule.Pattern != nil {
+			info.Pattern = rule.Pattern.OriginalPath
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+func describeExtractor(rule *FastRule) string {
+	if rule.ExtractorSpec != "" {
+		return rule.ExtractorSpec
+	}
+	if rule.Extractor == nil {
+		return ""
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", rule.Extractor), "*") +
+		strings.TrimPrefix(fmt.Sprintf("%+v", rule.Extractor), "&")
+}
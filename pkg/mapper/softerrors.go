@@ -0,0 +1,50 @@
+package mapper
+
+import (
+	"errors"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/transform"
+)
+
+func WithSoftErrorHandler(handler func(error)) Option {
+	return func(m *Mapper) {
+		m.softErrorHandler = handler
+	}
+}
+
+func WithFastSoftErrorHandler(handler func(error)) FastOption {
+	return func(m *FastMapper) {
+		m.softErrorHandler = handler
+	}
+}
+
+func IsSoftError(err error) bool {
+	var coercion *registry.CoercionError
+	if errors.As(err, &coercion) {
+		return true
+	}
+
+	var transformErr *transform.TransformError
+	if errors.As(err, &transformErr) {
+		_, compileErr := transform.Compile(transformErr.Name)
+		return compileErr == nil
+	}
+	return false
+}
+
+func (m *FastMapper) valueFailed(err error) lineResult {
+	if m.softErrorHandler != nil && IsSoftError(err) {
+		if m.stats != nil {
+			m.stats.SkippedValues.Add(1)
+		}
+		m.softErrorHandler(err)
+		return lineMatched
+	}
+
+	if m.stats != nil {
+		m.stats.FailedRules.Add(1)
+	}
+	m.errorHandler(err)
+	return lineFailed
+}
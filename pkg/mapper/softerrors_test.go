@@ -0,0 +1,160 @@
+package mapper
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/transform"
+)
+
+func TestIsSoftError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"coercion", &registry.CoercionError{Field: "Active", Value: "maybe", Err: errors.New("invalid syntax")}, true},
+		{"wrapped coercion", fmt.Errorf("field Active: %w", &registry.CoercionError{Field: "Active"}), true},
+		{"known transform", &transform.TransformError{Name: "int", Value: "abc", Err: errors.New("invalid syntax")}, true},
+		{"unknown transform", &transform.TransformError{Name: "no_such_transform", Err: errors.New("unknown")}, false},
+		{"plain", errors.New("boom"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSoftError(tt.err); got != tt.want {
+				t.Errorf("IsSoftError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFastSoftErrorSkipsValue(t *testing.T) {
+	var soft, hard []error
+	m := newHostMapper(t, WithFastStats(),
+		WithFastSoftErrorHandler(func(err error) { soft = append(soft, err) }),
+		WithFastErrorHandler(func(err error) { hard = append(hard, err) }),
+	)
+
+	if err := m.Process("Device.Hosts.Host.1.HostName", "laptop"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Process("Device.Hosts.Host.1.Active", "maybe"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hard) != 0 {
+		t.Errorf("error handler called with %v", hard)
+	}
+	if len(soft) != 1 {
+		t.Fatalf("soft handler called %d times, want 1", len(soft))
+	}
+	var coercion *registry.CoercionError
+	if !errors.As(soft[0], &coercion) || coercion.Field != "Active" {
+		t.Errorf("soft error = %v, want CoercionError for Active", soft[0])
+	}
+	var mappingErr *MappingError
+	if !errors.As(soft[0], &mappingErr) || mappingErr.Rule != "host_Active" || mappingErr.Key != "1" {
+		t.Errorf("soft error = %#v, want MappingError for host_Active[1]", soft[0])
+	}
+
+	stats := m.GetStats()
+	if got := stats.SkippedValues.Load(); got != 1 {
+		t.Errorf("SkippedValues = %d, want 1", got)
+	}
+	if got := stats.FailedRules.Load(); got != 0 {
+		t.Errorf("FailedRules = %d, want 0", got)
+	}
+	if got := getHost(t, m, "1").HostName; got != "laptop" {
+		t.Errorf("HostName = %q, want laptop", got)
+	}
+}
+
+func TestFastSoftErrorTransform(t *testing.T) {
+	var soft []error
+	m := newWifiMapper(t, WithInferNumeric(), WithFastStats(), WithFastSoftErrorHandler(func(err error) { soft = append(soft, err) }))
+
+	if err := m.Process("Device.WiFi.Radio.1.Channel", "auto"); err != nil {
+		t.Fatal(err)
+	}
+	var transformErr *transform.TransformError
+	if len(soft) != 1 || !errors.As(soft[0], &transformErr) || transformErr.Name != "int" {
+		t.Errorf("soft errors = %v, want one int TransformError", soft)
+	}
+	if got := m.GetStats().SkippedValues.Load(); got != 1 {
+		t.Errorf("SkippedValues = %d, want 1", got)
+	}
+}
+
+func TestFastHardErrorWithoutSoftHandler(t *testing.T) {
+	var hard []error
+	m := newHostMapper(t, WithFastStats(), WithFastErrorHandler(func(err error) { hard = append(hard, err) }))
+
+	if err := m.Process("Device.Hosts.Host.1.Active", "maybe"); err != nil {
+		t.Fatal(err)
+	}
+
+	var coercion *registry.CoercionError
+	if len(hard) != 1 || !errors.As(hard[0], &coercion) {
+		t.Fatalf("error handler got %v, want one CoercionError", hard)
+	}
+	stats := m.GetStats()
+	if got := stats.FailedRules.Load(); got != 1 {
+		t.Errorf("FailedRules = %d, want 1", got)
+	}
+	if got := stats.SkippedValues.Load(); got != 0 {
+		t.Errorf("SkippedValues = %d, want 0", got)
+	}
+}
+
+func TestSoftErrorHandlerSkipsField(t *testing.T) {
+	var soft, hard []error
+	reg := registry.New()
+	reg.MustRegister("WiFi", func() any { return &TestWifi{} })
+	m := New(reg, WithMetrics(),
+		WithSoftErrorHandler(func(err error) { soft = append(soft, err) }),
+		WithErrorHandler(func(err error) { hard = append(hard, err) }),
+	)
+	err := m.LoadRulesFromString(`
+version: "1.0"
+rules:
+  - name: wifi
+    target: WiFi
+    route: 'path.startsWith("Device.WiFi.")'
+    entity_key: 'path.split(".")[3]'
+    fields:
+      - name: Channel
+        when: 'path.endsWith(".Channel")'
+        value: 'value'
+      - name: SSID
+        when: 'true'
+        value: '"guest"'
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Process("Device.WiFi.Radio.1.Channel", "auto"); err != nil {
+		t.Fatal(err)
+	}
+	if len(hard) != 0 {
+		t.Errorf("error handler called with %v", hard)
+	}
+	var coercion *registry.CoercionError
+	if len(soft) != 1 || !errors.As(soft[0], &coercion) {
+		t.Fatalf("soft errors = %v, want one CoercionError", soft)
+	}
+	if got := m.GetMetrics().SkippedValues; got != 1 {
+		t.Errorf("SkippedValues = %d, want 1", got)
+	}
+
+	obj, ok := m.GetStore().Get("WiFi", "1")
+	if !ok {
+		t.Fatal("wifi 1 not stored")
+	}
+	if got := obj.(*TestWifi).SSID; got != "guest" {
+		t.Errorf("SSID = %q, want guest", got)
+	}
+}
@@ -0,0 +1,70 @@
+package mapper
+
+import "sync"
+
+func WithSourceLines() FastOption {
+	return func(m *FastMapper) {
+		m.sources = &sourceTracker{}
+	}
+}
+
+type sourceKey struct {
+	target string
+	key    string
+}
+
+type sourceTracker struct {
+	mu    sync.Mutex
+	lines map[sourceKey][][2]string
+}
+
+func (t *sourceTracker) record(target, key string, line resolvedLine) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.lines == nil {
+		t.lines = make(map[sourceKey][][2]string)
+	}
+	sk := sourceKey{target: target, key: key}
+	t.lines[sk] = append(t.lines[sk], [2]string{line.stripped + line.path, line.value})
+}
+
+func (t *sourceTracker) get(target, key string) [][2]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines := t.lines[sourceKey{target: target, key: key}]
+	if len(lines) == 0 {
+		return nil
+	}
+	return append([][2]string(nil), lines...)
+}
+
+func (t *sourceTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lines = nil
+}
+
+func (t *sourceTracker) resetTarget(target string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for k := range t.lines {
+		if k.target == target {
+			delete(t.lines, k)
+		}
+	}
+}
+
+func (t *sourceTracker) resetKey(target, key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.lines, sourceKey{target: target, key: key})
+}
+
+func (m *FastMapper) SourceLines(target, key string) [][2]string {
+	if m.sources == nil {
+		return nil
+	}
+	return m.sources.get(target, key)
+}
@@ -0,0 +1,81 @@
+package mapper
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type SplitFanOut struct {
+	Sep        string
+	KeyByValue bool
+}
+
+func (m *FastMapper) validateSplit(rule *FastRule) error {
+	if rule.JSON != nil {
+		return fmt.Errorf("rule %s: Split and JSON are mutually exclusive", rule.ID)
+	}
+	if rule.SetConstant != nil {
+		return fmt.Errorf("rule %s: Split and SetConstant are mutually exclusive", rule.ID)
+	}
+	if rule.Precedence > 0 {
+		return fmt.Errorf("rule %s: Split rules cannot be coalesced", rule.ID)
+	}
+	return nil
+}
+
+func (m *FastMapper) applySplit(line resolvedLine) lineResult {
+	sep := line.rule.Split.Sep
+	if sep == "" {
+		sep = ","
+	}
+
+	result := lineMatched
+	for i, token := range strings.Split(line.value, sep) {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+
+		key := line.key + "." + strconv.Itoa(i+1)
+		if line.rule.Split.KeyByValue {
+			key = line.prefix + token
+		}
+		if m.applyToken(line, key, token) == lineFailed {
+			result = lineFailed
+		}
+	}
+	return result
+}
+
+func (m *FastMapper) applyToken(line resolvedLine, key, token string) lineResult {
+	rule := line.rule
+	if m.locker != nil {
+		unlock := m.locker.LockEntity(rule.target(), key)
+		defer unlock()
+	}
+
+	obj, err := m.acquire(rule, key)
+	if err != nil {
+		if !errors.Is(err, ErrEntityLimit) {
+			err = rule.failure(key, "", err)
+		}
+		return m.fanOutFailed(err)
+	}
+	if m.sources != nil {
+		m.sources.record(rule.target(), key, line)
+	}
+	return m.applyValue(line.ctx, rule, key, obj, token)
+}
+
+func (r *FastRule) fansOut() bool {
+	return r.JSON != nil || r.Split != nil
+}
+
+func (m *FastMapper) applyFanOut(line resolvedLine) lineResult {
+	if line.rule.Split != nil {
+		return m.applySplit(line)
+	}
+	return m.applyJSON(line)
+}
@@ -0,0 +1,102 @@
+package mapper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+)
+
+func dnsRule(split *SplitFanOut) *FastRule {
+	return &FastRule{
+		ID:        "dns_servers",
+		Pattern:   router.CompilePattern("Device.DNS.Client.*.Servers"),
+		Entity:    "host",
+		Field:     "IPAddress",
+		Split:     split,
+		Extractor: &extractor.IndexExtractor{Position: 3},
+	}
+}
+
+func storedAddresses(m *FastMapper) string {
+	var got []string
+	for key, obj := range m.GetStore().GetAll("host") {
+		got = append(got, key+"="+obj.(*TestHost).IPAddress)
+	}
+	sort.Strings(got)
+	return strings.Join(got, " ")
+}
+
+func TestSplitFanOutKeysByIndex(t *testing.T) {
+	m := newHostMapper(t)
+	if err := m.AddRule(dnsRule(&SplitFanOut{})); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Process("Device.DNS.Client.1.Servers", " 10.0.0.1,, 10.0.0.2 ,"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := storedAddresses(m), "1.1=10.0.0.1 1.3=10.0.0.2"; got != want {
+		t.Errorf("stored %q, want %q", got, want)
+	}
+}
+
+func TestSplitFanOutKeysByValue(t *testing.T) {
+	m := newHostMapper(t, WithFastKeyPrefix("cpe1/"))
+	if err := m.AddRule(dnsRule(&SplitFanOut{Sep: ";", KeyByValue: true})); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Process("Device.DNS.Client.1.Servers", "10.0.0.1;10.0.0.2,10.0.0.3"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := storedAddresses(m), "cpe1/10.0.0.1=10.0.0.1 cpe1/10.0.0.2,10.0.0.3=10.0.0.2,10.0.0.3"; got != want {
+		t.Errorf("stored %q, want %q", got, want)
+	}
+}
+
+func TestSplitFanOutValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*FastRule)
+		errMsg string
+	}{
+		{"json", func(r *FastRule) { r.JSON = &JSONFanOut{Fields: map[string]string{"IPAddress": "ip"}} }, "Split and JSON"},
+		{"constant", func(r *FastRule) { r.SetConstant = "x" }, "Split and SetConstant"},
+		{"precedence", func(r *FastRule) { r.Precedence = 1 }, "cannot be coalesced"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newHostMapper(t)
+			rule := dnsRule(&SplitFanOut{})
+			tt.modify(rule)
+			err := m.AddRule(rule)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("AddRule error = %v, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestSplitFanOutReportsFailedTokens(t *testing.T) {
+	var errs []error
+	m := newHostMapper(t, WithFastErrorHandler(func(err error) { errs = append(errs, err) }))
+	rule := dnsRule(&SplitFanOut{})
+	rule.Field = "Active"
+	if err := m.AddRule(rule); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Process("Device.DNS.Client.1.Servers", "true,maybe,false"); err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want 1: %v", len(errs), errs)
+	}
+	if got := fmt.Sprint(getHost(t, m, "1.1").Active, getHost(t, m, "1.3").Active); got != "true false" {
+		t.Errorf("Active = %s, want true false", got)
+	}
+}
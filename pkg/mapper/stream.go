@@ -0,0 +1,165 @@
+package mapper
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
+)
+
+const maxStreamLine = 1024 * 1024
+
+type EntityEvent struct {
+	Target string
+	Key    string
+	Entity any
+	Err    error
+}
+
+func (m *FastMapper) ProcessStreamEmit(r io.Reader) <-chan EntityEvent {
+	return m.ProcessStreamEmitContext(context.Background(), r)
+}
+
+func (m *FastMapper) ProcessStreamEmitContext(ctx context.Context, r io.Reader) <-chan EntityEvent {
+	events := make(chan EntityEvent, 64)
+	go func() {
+		defer close(events)
+		m.streamEmit(ctx, r, events)
+	}()
+	return events
+}
+
+func (m *FastMapper) streamEmit(ctx context.Context, r io.Reader, events chan<- EntityEvent) {
+	if m.closed.Load() {
+		sendEvent(ctx, events, EntityEvent{Err: ErrClosed})
+		return
+	}
+	m.unmatched.begin()
+	defer m.unmatched.end()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+
+	open := make(map[string]string)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			offerErr(events, err)
+			return
+		}
+		path, value, ok := parseStreamLine(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		line, result, err := m.processResolved(ctx, path, nil, value)
+		if err != nil {
+			if m.flushOpen(ctx, events, open) {
+				sendEvent(ctx, events, EntityEvent{Err: err})
+			}
+			return
+		}
+		if result == lineUnmatched || line.rule == nil || line.rule.fansOut() {
+			continue
+		}
+
+		target := line.rule.target()
+		if prev, ok := open[target]; ok && prev != line.key {
+			if !m.emitEntity(ctx, events, target, prev) {
+				return
+			}
+		}
+		open[target] = line.key
+	}
+
+	if !m.flushOpen(ctx, events, open) {
+		return
+	}
+	if err := scanner.Err(); err != nil {
+		sendEvent(ctx, events, EntityEvent{Err: fmt.Errorf("failed to read stream: %w", err)})
+	}
+}
+
+func (m *FastMapper) flushOpen(ctx context.Context, events chan<- EntityEvent, open map[string]string) bool {
+	targets := make([]string, 0, len(open))
+	for target := range open {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		if !m.emitEntity(ctx, events, target, open[target]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *FastMapper) emitEntity(ctx context.Context, events chan<- EntityEvent, target, key string) bool {
+	if err := ctx.Err(); err != nil {
+		offerErr(events, err)
+		return false
+	}
+	obj, ok := m.store.Get(target, key)
+	if !ok {
+		return true
+	}
+	if err := types.DeleteEntity(m.store, target, key); err == nil {
+		m.entityDeleted(target)
+	}
+	m.candidates.resetKey(target, key)
+	if m.sources != nil {
+		m.sources.resetKey(target, key)
+	}
+
+	if fields := m.required.fields[target]; len(fields) > 0 {
+		info, err := m.targetType(target)
+		if err != nil {
+			m.errorHandler(err)
+			return true
+		}
+		if missing := missingFields(info, obj, fields); len(missing) > 0 {
+			m.errorHandler(&IncompleteEntityError{Target: target, Key: key, Missing: missing})
+			if m.required.drop {
+				return true
+			}
+		}
+	}
+
+	return sendEvent(ctx, events, EntityEvent{Target: target, Key: key, Entity: obj})
+}
+
+func sendEvent(ctx context.Context, events chan<- EntityEvent, ev EntityEvent) bool {
+	select {
+	case events <- ev:
+		return true
+	case <-ctx.Done():
+		offerErr(events, ctx.Err())
+		return false
+	}
+}
+
+func offerErr(events chan<- EntityEvent, err error) {
+	select {
+	case events <- EntityEvent{Err: err}:
+	default:
+	}
+}
+
+func parseStreamLine(text string) (string, string, bool) {
+	text = strings.TrimSuffix(text, "\r")
+	if text == "" || text[0] == '#' {
+		return "", "", false
+	}
+
+	sep := strings.IndexByte(text, '\t')
+	if sep < 0 {
+		sep = strings.IndexByte(text, ' ')
+	}
+	if sep < 0 {
+		return text, "", true
+	}
+	return text[:sep], text[sep+1:], true
+}
@@ -0,0 +1,134 @@
+package mapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+)
+
+func collect(events <-chan EntityEvent) []EntityEvent {
+	var got []EntityEvent
+	for ev := range events {
+		got = append(got, ev)
+	}
+	return got
+}
+
+func emitted(events []EntityEvent) []string {
+	var keys []string
+	for _, ev := range events {
+		if ev.Err != nil {
+			keys = append(keys, "err")
+			continue
+		}
+		keys = append(keys, ev.Key+"="+ev.Entity.(*TestHost).HostName)
+	}
+	return keys
+}
+
+func TestProcessStreamEmitCompletesOnKeyChange(t *testing.T) {
+	m := newHostMapper(t)
+	input := strings.Join([]string{
+		"# hosts",
+		"Device.Hosts.Host.1.HostName\ta",
+		"Device.Hosts.Host.1.IPAddress 10.0.0.1",
+		"",
+		"Device.Hosts.Host.2.HostName\tb",
+		"Device.Unknown.Param\tx",
+		"Device.Hosts.Host.1.HostName\tc",
+	}, "\n")
+
+	got := emitted(collect(m.ProcessStreamEmit(strings.NewReader(input))))
+	want := []string{"1=a", "2=b", "1=c"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("emitted %v, want %v", got, want)
+	}
+	if _, ok := m.GetStore().Get("host", "1"); ok {
+		t.Error("emitted entity left in store")
+	}
+}
+
+func TestProcessStreamEmitFlushesBeforeReadError(t *testing.T) {
+	m := newHostMapper(t)
+	boom := errors.New("boom")
+	r := io.MultiReader(
+		strings.NewReader("Device.Hosts.Host.1.HostName\ta\nDevice.Hosts.Host.2.HostName\tb\n"),
+		iotest.ErrReader(boom),
+	)
+
+	events := collect(m.ProcessStreamEmit(r))
+	if got := emitted(events); fmt.Sprint(got) != "[1=a 2=b err]" {
+		t.Fatalf("emitted %v", got)
+	}
+	if err := events[len(events)-1].Err; !errors.Is(err, boom) {
+		t.Errorf("err = %v, want %v", err, boom)
+	}
+}
+
+func TestProcessStreamEmitFlushesBeforeProcessingError(t *testing.T) {
+	m := newHostMapper(t)
+	err := m.AddRule(&FastRule{
+		ID:        "ghost",
+		Pattern:   router.CompilePattern("Device.Ghost.*.Name"),
+		Entity:    "ghost",
+		Field:     "Name",
+		Extractor: &extractor.IndexExtractor{Position: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "Device.Hosts.Host.1.HostName\ta\nDevice.Ghost.1.Name\tx\nDevice.Hosts.Host.2.HostName\tb\n"
+	if got := emitted(collect(m.ProcessStreamEmit(strings.NewReader(input)))); fmt.Sprint(got) != "[1=a err]" {
+		t.Errorf("emitted %v", got)
+	}
+}
+
+type endlessHosts struct {
+	n   int
+	buf []byte
+}
+
+func (r *endlessHosts) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		r.n++
+		r.buf = fmt.Appendf(nil, "Device.Hosts.Host.%d.HostName\th%d\n", r.n, r.n)
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func TestProcessStreamEmitContextStopsWhenCanceled(t *testing.T) {
+	m := newHostMapper(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	events := m.ProcessStreamEmitContext(ctx, &endlessHosts{})
+
+	if ev := <-events; ev.Err != nil {
+		t.Fatal(ev.Err)
+	}
+	cancel()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if ev.Err != nil && !errors.Is(ev.Err, context.Canceled) {
+				t.Fatalf("unexpected error %v", ev.Err)
+			}
+		case <-deadline:
+			t.Fatal("stream did not stop after cancel")
+		}
+	}
+}
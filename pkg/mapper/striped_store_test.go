@@ -0,0 +1,57 @@
+package mapper
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
+)
+
+func TestFastMapperStripedStoreParallelBatch(t *testing.T) {
+	reg := registry.New()
+	reg.MustRegister("host", func() any { return &TestHost{} })
+
+	m := NewFast(reg, WithFastStore(types.NewStripedStore(8)))
+	for _, field := range []string{"MACAddress", "IPAddress", "HostName"} {
+		err := m.AddRule(&FastRule{
+			ID:        "host_" + field,
+			Pattern:   router.CompilePattern("Device.Hosts.Host.*." + field),
+			Entity:    "host",
+			Field:     field,
+			Extractor: &extractor.IndexExtractor{Position: 3},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	items := make([][2]string, 0, 3000)
+	for round := 0; round < 10; round++ {
+		for host := 0; host < 100; host++ {
+			items = append(items,
+				[2]string{fmt.Sprintf("Device.Hosts.Host.%d.MACAddress", host), fmt.Sprintf("mac-%d", host)},
+				[2]string{fmt.Sprintf("Device.Hosts.Host.%d.IPAddress", host), fmt.Sprintf("ip-%d", host)},
+				[2]string{fmt.Sprintf("Device.Hosts.Host.%d.HostName", host), fmt.Sprintf("name-%d", host)},
+			)
+		}
+	}
+
+	if err := m.ProcessBatch(items); err != nil {
+		t.Fatal(err)
+	}
+
+	all := m.GetStore().GetAll("host")
+	if len(all) != 100 {
+		t.Fatalf("expected 100 hosts, got %d", len(all))
+	}
+	for key, obj := range all {
+		host := obj.(*TestHost)
+		want := TestHost{MACAddress: "mac-" + key, IPAddress: "ip-" + key, HostName: "name-" + key}
+		if *host != want {
+			t.Errorf("host %s: got %+v, want %+v", key, *host, want)
+		}
+	}
+}
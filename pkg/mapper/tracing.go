@@ -0,0 +1,66 @@
+package mapper
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type Tracer interface {
+	StartBatch(ctx context.Context, size int) (context.Context, Span)
+	StartRule(ctx context.Context, ruleID string) (context.Context, Span)
+}
+
+type Span interface {
+	End(matched, failed int64, err error)
+}
+
+func WithTracer(tracer Tracer) Option {
+	return func(m *Mapper) {
+		m.tracer = tracer
+	}
+}
+
+func WithFastTracer(tracer Tracer) FastOption {
+	return func(m *FastMapper) {
+		m.tracer = tracer
+	}
+}
+
+type lineResult int
+
+const (
+	lineUnmatched lineResult = iota
+	lineMatched
+	lineFailed
+)
+
+func (r lineResult) matched() int64 {
+	if r == lineMatched {
+		return 1
+	}
+	return 0
+}
+
+func (r lineResult) failed() int64 {
+	if r == lineFailed {
+		return 1
+	}
+	return 0
+}
+
+type batchTally struct {
+	matched atomic.Int64
+	failed  atomic.Int64
+}
+
+func (t *batchTally) record(result lineResult) {
+	if t == nil {
+		return
+	}
+	switch result {
+	case lineMatched:
+		t.matched.Add(1)
+	case lineFailed:
+		t.failed.Add(1)
+	}
+}
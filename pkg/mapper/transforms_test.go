@@ -0,0 +1,70 @@
+package mapper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/transform"
+)
+
+func TestFieldTransforms(t *testing.T) {
+	var errs []error
+	reg := registry.New()
+	reg.MustRegister("Host", func() any { return &TestHost{} })
+	reg.MustRegister("WiFi", func() any { return &TestWifi{} })
+	m := New(reg, WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	err := m.LoadRulesFromString(`
+version: "1.0"
+rules:
+  - name: hosts
+    target: Host
+    route: 'path.startsWith("Device.Hosts.Host.")'
+    entity_key: 'path.split(".")[3]'
+    fields:
+      - name: MACAddress
+        when: 'path.endsWith(".PhysAddress")'
+        value: 'value'
+        transforms: [trim, mac_normalize]
+      - name: IPAddress
+        when: 'path.endsWith(".IPAddress")'
+        value: 'value'
+        transforms: [trim, ipv4_canonical]
+  - name: wifi
+    target: WiFi
+    route: 'path.startsWith("Device.WiFi.Radio.")'
+    entity_key: 'path.split(".")[3]'
+    fields:
+      - name: Channel
+        when: 'path.endsWith(".Channel")'
+        value: 'toInt(value) * 2'
+        transforms: [int]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, value := range map[string]string{
+		"Device.Hosts.Host.1.PhysAddress": "  AA-BB-CC-DD-EE-FF ",
+		"Device.WiFi.Radio.1.Channel":     "6",
+		"Device.Hosts.Host.2.IPAddress":   " 300.1.1.1",
+	} {
+		if err := m.Process(path, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	host, _ := m.GetStore().Get("Host", "1")
+	if got := host.(*TestHost).MACAddress; got != "aa:bb:cc:dd:ee:ff" {
+		t.Errorf("MACAddress = %q, want aa:bb:cc:dd:ee:ff", got)
+	}
+	wifi, _ := m.GetStore().Get("WiFi", "1")
+	if got := wifi.(*TestWifi).Channel; got != 12 {
+		t.Errorf("Channel = %d, want 12 from the formatted CEL result", got)
+	}
+
+	var transformErr *transform.TransformError
+	if len(errs) != 1 || !errors.As(errs[0], &transformErr) || transformErr.Name != "ipv4_canonical" {
+		t.Errorf("errors = %v, want one ipv4_canonical TransformError", errs)
+	}
+}
@@ -0,0 +1,80 @@
+package mapper
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+)
+
+func WithTypeCheck(handler func(error)) Option {
+	return func(m *Mapper) {
+		m.typeCheck = &typeChecker{handler: handler}
+	}
+}
+
+func WithFastTypeCheck(handler func(error)) FastOption {
+	return func(m *FastMapper) {
+		m.typeCheck = &typeChecker{handler: handler}
+	}
+}
+
+type typeChecker struct {
+	handler func(error)
+	seen    sync.Map
+}
+
+type typeCheckKey struct {
+	rule      string
+	field     string
+	transform string
+}
+
+func (c *typeChecker) check(info *registry.TypeInfo, rule, field, transform string, value any) {
+	if _, seen := c.seen.LoadOrStore(typeCheckKey{rule: rule, field: field, transform: transform}, struct{}{}); seen {
+		return
+	}
+	if info == nil {
+		return
+	}
+	t, ok := info.FieldType(field)
+	if !ok || compatibleKinds(t, value) {
+		return
+	}
+	c.handler(&LintWarning{
+		Rule:    rule,
+		Field:   field,
+		Message: fmt.Sprintf("transform %s returned %T but the field type is %s; the setter will coerce it", transform, value, t),
+	})
+}
+
+func compatibleKinds(t reflect.Type, value any) bool {
+	if value == nil {
+		return true
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	vt := reflect.TypeOf(value)
+	if vt.AssignableTo(t) || t.Kind() == reflect.Interface {
+		return true
+	}
+	if vt.Kind() == reflect.String && registry.HasConverter(t) {
+		return true
+	}
+	return kindClass(vt.Kind()) == kindClass(t.Kind())
+}
+
+func kindClass(k reflect.Kind) string {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	default:
+		return k.String()
+	}
+}
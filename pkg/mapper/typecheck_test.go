@@ -0,0 +1,203 @@
+package mapper
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+)
+
+type warningCollector struct {
+	mu       sync.Mutex
+	warnings []LintWarning
+}
+
+func (c *warningCollector) handle(err error) {
+	var warning *LintWarning
+	if !errors.As(err, &warning) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warnings = append(c.warnings, *warning)
+}
+
+func (c *warningCollector) fields() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fields := make([]string, len(c.warnings))
+	for i, w := range c.warnings {
+		fields[i] = w.Rule + "." + w.Field
+	}
+	return fields
+}
+
+func TestFastTypeCheck(t *testing.T) {
+	c := &warningCollector{}
+	reg := registry.New()
+	reg.MustRegister("wifi", func() any { return &TestWifi{} })
+	m := NewFast(reg, WithFastTypeCheck(c.handle))
+	err := m.AddRules([]*FastRule{
+		wifiRule("wifi_SSID", "SSID", "bool"),
+		wifiRule("wifi_Password", "Password", "trim"),
+		wifiRule("wifi_Channel", "Channel", "float"),
+		wifiRule("wifi_Enabled", "Enabled", "lower"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		err := m.ProcessBatch([][2]string{
+			{"Device.WiFi.Radio.1.SSID", "true"},
+			{"Device.WiFi.Radio.1.Password", " secret "},
+			{"Device.WiFi.Radio.1.Channel", "6"},
+			{"Device.WiFi.Radio.1.Enabled", "TRUE"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, want := c.fields(), []string{"wifi_SSID.SSID", "wifi_Enabled.Enabled"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("warnings for %v, want %v", got, want)
+	}
+	want := "rule wifi_SSID field SSID: transform bool returned bool but the field type is string; the setter will coerce it"
+	if got := c.warnings[0].Error(); got != want {
+		t.Errorf("warning = %q, want %q", got, want)
+	}
+}
+
+func TestFastTypeCheckSkipsUntransformedAndConverterFields(t *testing.T) {
+	c := &warningCollector{}
+	reg := registry.New()
+	reg.MustRegister("wifi", func() any { return &TestWifi{} })
+	reg.MustRegister("radio", func() any { return &testRadio{} })
+	m := NewFast(reg, WithFastTypeCheck(c.handle))
+	err := m.AddRules([]*FastRule{
+		wifiRule("wifi_SSID", "SSID", ""),
+		{
+			ID:        "radio_Mode",
+			Pattern:   router.CompilePattern("Device.WiFi.Radio.*.Mode"),
+			Entity:    "radio",
+			Field:     "Mode",
+			Transform: "trim",
+			Extractor: &extractor.IndexExtractor{Position: 3},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.ProcessBatch([][2]string{
+		{"Device.WiFi.Radio.1.SSID", "home"},
+		{"Device.WiFi.Radio.1.Mode", " Auto "},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.fields(); len(got) != 0 {
+		t.Errorf("unexpected warnings for %v", got)
+	}
+}
+
+func TestTypeCheck(t *testing.T) {
+	c := &warningCollector{}
+	reg := registry.New()
+	reg.MustRegister("WiFi", func() any { return &TestWifi{} })
+	m := New(reg, WithTypeCheck(c.handle))
+	err := m.LoadRulesFromString(`
+version: "1.0"
+rules:
+  - name: wifi
+    target: WiFi
+    route: 'path.startsWith("Device.WiFi.SSID.")'
+    entity_key: 'path.split(".")[3]'
+    fields:
+      - name: SSID
+        when: 'path.endsWith(".SSID")'
+        value: 'value'
+        transforms: [trim, int]
+      - name: Channel
+        when: 'path.endsWith(".Channel")'
+        value: 'value'
+        transforms: [int]
+      - name: Password
+        when: 'path.endsWith(".KeyPassphrase")'
+        value: 'value'
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := [][2]string{
+		{"Device.WiFi.SSID.1.SSID", " 42 "},
+		{"Device.WiFi.SSID.1.Channel", "6"},
+		{"Device.WiFi.SSID.1.KeyPassphrase", "secret"},
+		{"Device.WiFi.SSID.2.SSID", "7"},
+	}
+	if err := m.ProcessBatch(items); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.fields(), []string{"wifi.SSID"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("warnings for %v, want %v", got, want)
+	}
+	if got := c.warnings[0].Message; got != "transform trim,int returned int64 but the field type is string; the setter will coerce it" {
+		t.Errorf("message = %q", got)
+	}
+
+	clone := m.Clone()
+	if err := clone.ProcessBatch(items); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(c.fields()); got != 2 {
+		t.Errorf("clone reported %d warnings in total, want its own first-use warning", got)
+	}
+}
+
+func TestCompatibleKinds(t *testing.T) {
+	var (
+		str     string
+		strPtr  *string
+		number  int32
+		float   float64
+		flag    bool
+		list    []string
+		anyVal  any
+		mode    radioMode
+		modePtr *radioMode
+	)
+	tests := []struct {
+		name  string
+		field any
+		value any
+		want  bool
+	}{
+		{"nil value", &str, nil, true},
+		{"same type", &str, "x", true},
+		{"pointer field", &strPtr, "x", true},
+		{"int to int32", &number, int64(1), true},
+		{"float to int32", &number, 1.5, true},
+		{"int to float", &float, 1, true},
+		{"bool to string", &str, true, false},
+		{"string to bool", &flag, "true", false},
+		{"string to int", &number, "1", false},
+		{"array to slice", &list, [2]string{"a", "b"}, true},
+		{"anything to interface", &anyVal, 1, true},
+		{"string to converter type", &mode, "Auto", true},
+		{"string to converter pointer", &modePtr, "Auto", true},
+		{"bool to converter type", &mode, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fieldType := reflect.TypeOf(tt.field).Elem()
+			if got := compatibleKinds(fieldType, tt.value); got != tt.want {
+				t.Errorf("compatibleKinds(%s, %T) = %v, want %v", fieldType, tt.value, got, tt.want)
+			}
+		})
+	}
+}
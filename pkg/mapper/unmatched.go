@@ -0,0 +1,108 @@
+package mapper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/tr069keys"
+)
+
+var ErrUnmatched = errors.New("no rule matched")
+
+type UnmatchedError struct {
+	Path  string
+	Shape string
+}
+
+func (e *UnmatchedError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, ErrUnmatched)
+}
+
+func (e *UnmatchedError) Unwrap() error {
+	return ErrUnmatched
+}
+
+func WithStrictUnmatched() FastOption {
+	return func(m *FastMapper) {
+		m.unmatched.enabled = true
+	}
+}
+
+func WithUnmatchedSampling(n int) FastOption {
+	return func(m *FastMapper) {
+		m.unmatched.enabled = true
+		m.unmatched.every = int64(n)
+	}
+}
+
+type unmatchedReporter struct {
+	enabled bool
+	every   int64
+	count   atomic.Int64
+
+	mu     sync.Mutex
+	active int
+	seen   map[string]struct{}
+}
+
+func (r *unmatchedReporter) begin() {
+	if !r.enabled {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.active == 0 {
+		r.seen = make(map[string]struct{})
+	}
+	r.active++
+}
+
+func (r *unmatchedReporter) end() {
+	if !r.enabled {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.active--
+	if r.active == 0 {
+		r.seen = nil
+	}
+}
+
+func (r *unmatchedReporter) sample(shape string) bool {
+	r.mu.Lock()
+	if r.seen != nil {
+		if _, ok := r.seen[shape]; ok {
+			r.mu.Unlock()
+			return false
+		}
+		r.seen[shape] = struct{}{}
+	}
+	r.mu.Unlock()
+
+	n := r.count.Add(1)
+	return r.every <= 1 || (n-1)%r.every == 0
+}
+
+func (m *FastMapper) reportUnmatched(path string) {
+	if !m.unmatched.enabled {
+		return
+	}
+	shape := pathShape(path)
+	if m.unmatched.sample(shape) {
+		m.errorHandler(&UnmatchedError{Path: path, Shape: shape})
+	}
+}
+
+func pathShape(path string) string {
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		if tr069keys.IsInstance(part) {
+			parts[i] = "*"
+		}
+	}
+	return strings.Join(parts, ".")
+}
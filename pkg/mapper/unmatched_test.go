@@ -0,0 +1,147 @@
+package mapper
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type unmatchedCollector struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (c *unmatchedCollector) handle(err error) {
+	var unmatched *UnmatchedError
+	if !errors.As(err, &unmatched) || !errors.Is(err, ErrUnmatched) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paths = append(c.paths, unmatched.Path)
+}
+
+func (c *unmatchedCollector) reported() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.paths...)
+}
+
+func TestStrictUnmatchedReportsEveryLine(t *testing.T) {
+	c := &unmatchedCollector{}
+	m := newHostMapper(t, WithStrictUnmatched(), WithFastErrorHandler(c.handle))
+
+	for _, path := range []string{"Device.Hosts.Host.1.X", "Device.Hosts.Host.1.X", "Device.Hosts.Host.1.HostName"} {
+		if err := m.Process(path, "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"Device.Hosts.Host.1.X", "Device.Hosts.Host.1.X"}; !reflect.DeepEqual(c.reported(), want) {
+		t.Errorf("reported %v, want %v", c.reported(), want)
+	}
+}
+
+func TestUnmatchedDedupedWithinBatch(t *testing.T) {
+	c := &unmatchedCollector{}
+	m := newHostMapper(t, WithStrictUnmatched(), WithFastErrorHandler(c.handle))
+
+	items := [][2]string{
+		{"Device.Hosts.Host.1.X", "v"},
+		{"Device.Hosts.Host.2.X", "v"},
+		{"Device.Hosts.Host.1.Y", "v"},
+		{"Device.Hosts.Host.1.HostName", "laptop"},
+	}
+	if err := m.ProcessBatch(items); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Device.Hosts.Host.1.X", "Device.Hosts.Host.1.Y"}; !reflect.DeepEqual(c.reported(), want) {
+		t.Errorf("first batch reported %v, want %v", c.reported(), want)
+	}
+
+	if err := m.ProcessBatch(items); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(c.reported()); got != 4 {
+		t.Errorf("%d reports after a second batch, want 4", got)
+	}
+}
+
+func TestUnmatchedDedupedAcrossWorkers(t *testing.T) {
+	c := &unmatchedCollector{}
+	m := newHostMapper(t, WithStrictUnmatched(), WithFastErrorHandler(c.handle))
+
+	items := make([][2]string, 0, 600)
+	for i := 0; i < 300; i++ {
+		items = append(items,
+			[2]string{fmt.Sprintf("Device.Hosts.Host.%d.X", i), "v"},
+			[2]string{fmt.Sprintf("Device.Hosts.Host.%d.Y", i), "v"},
+		)
+	}
+	if err := m.ProcessBatch(items); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(c.reported()); got != 2 {
+		t.Errorf("%d reports for two shapes, want 2: %v", got, c.reported())
+	}
+}
+
+func TestUnmatchedSampling(t *testing.T) {
+	c := &unmatchedCollector{}
+	m := newHostMapper(t, WithUnmatchedSampling(3), WithFastErrorHandler(c.handle))
+
+	for i := 0; i < 7; i++ {
+		if err := m.Process(fmt.Sprintf("Device.Unknown%d", i), "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"Device.Unknown0", "Device.Unknown3", "Device.Unknown6"}
+	if !reflect.DeepEqual(c.reported(), want) {
+		t.Errorf("reported %v, want %v", c.reported(), want)
+	}
+
+	m.Reset()
+	if err := m.Process("Device.Unknown7", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.reported(); got[len(got)-1] != "Device.Unknown7" {
+		t.Errorf("sampling did not restart after Reset: %v", got)
+	}
+}
+
+func TestUnmatchedSamplingOfOneReportsAll(t *testing.T) {
+	for _, n := range []int{0, 1} {
+		c := &unmatchedCollector{}
+		m := newHostMapper(t, WithUnmatchedSampling(n), WithFastErrorHandler(c.handle))
+		for i := 0; i < 3; i++ {
+			if err := m.Process(fmt.Sprintf("Device.Unknown%d", i), "v"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := len(c.reported()); got != 3 {
+			t.Errorf("sampling %d reported %d of 3 lines", n, got)
+		}
+	}
+}
+
+func TestUnmatchedDisabledByDefault(t *testing.T) {
+	c := &unmatchedCollector{}
+	m := newHostMapper(t, WithFastErrorHandler(c.handle))
+	if err := m.Process("Device.Unknown", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.reported(); len(got) != 0 {
+		t.Errorf("reported %v without WithStrictUnmatched", got)
+	}
+}
+
+func TestUnmatchedErrorShape(t *testing.T) {
+	err := &UnmatchedError{Path: "Device.Hosts.Host.3.X", Shape: pathShape("Device.Hosts.Host.3.X")}
+	if err.Shape != "Device.Hosts.Host.*.X" {
+		t.Errorf("shape = %q", err.Shape)
+	}
+	if got := err.Error(); got != "Device.Hosts.Host.3.X: no rule matched" {
+		t.Errorf("Error() = %q", got)
+	}
+}
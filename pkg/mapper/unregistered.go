@@ -0,0 +1,70 @@
+package mapper
+
+import (
+	"fmt"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/builder"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/types"
+)
+
+type UnregisteredTargetError struct {
+	Rule   string
+	Target string
+}
+
+func (e *UnregisteredTargetError) Error() string {
+	return fmt.Sprintf("rule %s: target type %s not registered", e.Rule, e.Target)
+}
+
+func WithSkipUnregisteredTargets() Option {
+	return func(m *Mapper) {
+		m.skipUnregistered = true
+	}
+}
+
+func (m *Mapper) registeredRules(rules []*types.CompiledRule) ([]*types.CompiledRule, error) {
+	kept := make([]*types.CompiledRule, 0, len(rules))
+	for _, rule := range rules {
+		if m.registry.Has(rule.Target) {
+			kept = append(kept, rule)
+			continue
+		}
+		err := &UnregisteredTargetError{Rule: rule.Name, Target: rule.Target}
+		if !m.skipUnregistered {
+			return nil, err
+		}
+		m.reportSkippedRule(err)
+	}
+	return kept, nil
+}
+
+func (m *Mapper) loadConfig(config *types.RulesConfig) error {
+	if m.skipUnregistered {
+		kept := make([]types.RuleConfig, 0, len(config.Rules))
+		for _, rule := range config.Rules {
+			if m.registry.Has(rule.Target) {
+				kept = append(kept, rule)
+				continue
+			}
+			m.reportSkippedRule(&UnregisteredTargetError{Rule: rule.Name, Target: rule.Target})
+		}
+		config = &types.RulesConfig{Version: config.Version, Rules: kept}
+	}
+
+	b := builder.New(m.registry).WithStandardVariables()
+	for name, celType := range m.variables {
+		b.WithVariable(name, celType)
+	}
+	rules, err := b.BuildFromConfig(config)
+	if err != nil {
+		return err
+	}
+	return m.LoadRules(rules)
+}
+
+func (m *Mapper) reportSkippedRule(err *UnregisteredTargetError) {
+	if m.logger != nil {
+		m.logger.Warn("rule skipped", "rule", err.Rule, "target", err.Target)
+	}
+	m.errorHandler(err)
+}
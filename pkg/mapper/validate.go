@@ -0,0 +1,44 @@
+package mapper
+
+import (
+	"fmt"
+	"sort"
+)
+
+func (m *FastMapper) Validate() []error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make([]string, 0, len(m.rules))
+	for id := range m.rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var errs []error
+	for _, id := range ids {
+		rule := m.rules[id]
+		info, err := m.registry.Get(rule.Entity)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %s: %w", id, err))
+			continue
+		}
+		if rule.JSON != nil {
+			fields := make([]string, 0, len(rule.JSON.Fields))
+			for field := range rule.JSON.Fields {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			for _, field := range fields {
+				if _, ok := info.Setters[field]; !ok {
+					errs = append(errs, fmt.Errorf("rule %s: entity %s has no field %s", id, rule.Entity, field))
+				}
+			}
+			continue
+		}
+		if _, ok := info.Setters[rule.Field]; !ok {
+			errs = append(errs, fmt.Errorf("rule %s: entity %s has no field %s", id, rule.Entity, rule.Field))
+		}
+	}
+	return errs
+}
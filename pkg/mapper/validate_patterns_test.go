@@ -0,0 +1,42 @@
+package mapper
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/datamodel"
+)
+
+func TestValidatePatterns(t *testing.T) {
+	dm, err := datamodel.Parse(strings.NewReader(`
+<document>
+  <model name="Device:2.15">
+    <object name="Device.Hosts.Host.{i}.">
+      <parameter name="HostName"/>
+      <parameter name="IPAddress"/>
+      <parameter name="Active"/>
+    </object>
+    <object name="Device.IP.Interface.{i}.">
+      <parameter name="MACAddress"/>
+    </object>
+  </model>
+</document>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := newHostMapper(t)
+	typo := hostRule("host_typo", "HostNme")
+	typo.Field = "HostName"
+	if err := m.AddRule(typo); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := m.ValidatePatterns(dm)
+	want := "[rule host_MACAddress: pattern Device.Hosts.Host.*.MACAddress matches no parameter in data model Device:2.15" +
+		" rule host_typo: parameter HostNme does not exist in data model Device:2.15]"
+	if got := fmt.Sprint(errs); got != want {
+		t.Errorf("ValidatePatterns = %s, want %s", got, want)
+	}
+}
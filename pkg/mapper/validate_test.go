@@ -0,0 +1,45 @@
+package mapper
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+)
+
+func TestValidate(t *testing.T) {
+	m := newHostMapper(t)
+	if errs := m.Validate(); len(errs) != 0 {
+		t.Fatalf("Validate() = %v, want no errors", errs)
+	}
+
+	err := m.AddRules([]*FastRule{
+		{
+			ID:        "ghost",
+			Pattern:   router.CompilePattern("Device.Ghost.*.Name"),
+			Entity:    "ghost",
+			Field:     "Name",
+			Extractor: &extractor.IndexExtractor{Position: 2},
+		},
+		{
+			ID:        "host_typo",
+			Pattern:   router.CompilePattern("Device.Hosts.Host.*.Layer2Interface"),
+			Entity:    "host",
+			Field:     "Layer2Interface",
+			Extractor: &extractor.IndexExtractor{Position: 3},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := m.Validate()
+	want := []string{
+		"rule ghost: type ghost not registered",
+		"rule host_typo: entity host has no field Layer2Interface",
+	}
+	if fmt.Sprint(errs) != fmt.Sprint(want) {
+		t.Errorf("Validate() = %q, want %q", errs, want)
+	}
+}
@@ -0,0 +1,87 @@
+package otel
+
+import (
+	"context"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/mapper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/metalgrid/tr069-cel-mapper"
+
+type Tracer struct {
+	tracer    trace.Tracer
+	ruleSpans bool
+}
+
+type Option func(*Tracer)
+
+func WithRuleSpans() Option {
+	return func(t *Tracer) {
+		t.ruleSpans = true
+	}
+}
+
+func New(provider trace.TracerProvider, opts ...Option) *Tracer {
+	t := &Tracer{
+		tracer: provider.Tracer(instrumentationName),
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+func (t *Tracer) StartBatch(ctx context.Context, size int) (context.Context, mapper.Span) {
+	ctx, span := t.tracer.Start(ctx, "mapper.ProcessBatch",
+		trace.WithAttributes(attribute.Int("mapper.batch.size", size)))
+	return ctx, &batchSpan{span: span}
+}
+
+func (t *Tracer) StartRule(ctx context.Context, ruleID string) (context.Context, mapper.Span) {
+	if !t.ruleSpans {
+		return ctx, noopSpan{}
+	}
+	ctx, span := t.tracer.Start(ctx, "mapper.ApplyRule",
+		trace.WithAttributes(attribute.String("mapper.rule.id", ruleID)))
+	return ctx, &ruleSpan{span: span}
+}
+
+type batchSpan struct {
+	span trace.Span
+}
+
+func (s *batchSpan) End(matched, failed int64, err error) {
+	s.span.SetAttributes(
+		attribute.Int64("mapper.batch.matched", matched),
+		attribute.Int64("mapper.batch.failed", failed),
+	)
+	endSpan(s.span, err)
+}
+
+type ruleSpan struct {
+	span trace.Span
+}
+
+func (s *ruleSpan) End(matched, failed int64, err error) {
+	if failed > 0 {
+		s.span.SetStatus(codes.Error, "rule failed")
+	}
+	endSpan(s.span, err)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) End(matched, failed int64, err error) {}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
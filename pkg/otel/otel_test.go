@@ -0,0 +1,292 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/mapper"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type recordedSpan struct {
+	name   string
+	attrs  map[attribute.Key]attribute.Value
+	status codes.Code
+	errs   []error
+	ended  bool
+}
+
+type recorder struct {
+	noop.TracerProvider
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (r *recorder) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return &recordingTracer{recorder: r}
+}
+
+func (r *recorder) named(name string) []*recordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var spans []*recordedSpan
+	for _, span := range r.spans {
+		if span.name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+type recordingTracer struct {
+	noop.Tracer
+	recorder *recorder
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := &recordingSpan{
+		recorder: t.recorder,
+		span:     &recordedSpan{name: name, attrs: make(map[attribute.Key]attribute.Value)},
+	}
+	config := trace.NewSpanStartConfig(opts...)
+	span.SetAttributes(config.Attributes()...)
+	t.recorder.mu.Lock()
+	t.recorder.spans = append(t.recorder.spans, span.span)
+	t.recorder.mu.Unlock()
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+type recordingSpan struct {
+	noop.Span
+	recorder *recorder
+	span     *recordedSpan
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...attribute.KeyValue) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	for _, attr := range attrs {
+		s.span.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordingSpan) SetStatus(code codes.Code, _ string) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.span.status = code
+}
+
+func (s *recordingSpan) RecordError(err error, _ ...trace.EventOption) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.span.errs = append(s.span.errs, err)
+}
+
+func (s *recordingSpan) End(...trace.SpanEndOption) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.span.ended = true
+}
+
+type host struct {
+	HostName string
+	Active   bool
+}
+
+func newFastMapper(t *testing.T, tracer mapper.Tracer) *mapper.FastMapper {
+	t.Helper()
+	reg := registry.New()
+	reg.MustRegister("host", func() any { return &host{} })
+
+	m := mapper.NewFast(reg, mapper.WithFastTracer(tracer))
+	for field, transform := range map[string]string{"HostName": "", "Active": "bool"} {
+		err := m.AddRule(&mapper.FastRule{
+			ID:        "host_" + field,
+			Pattern:   router.CompilePattern("Device.Hosts.Host.*." + field),
+			Entity:    "host",
+			Field:     field,
+			Transform: transform,
+			Extractor: &extractor.IndexExtractor{Position: 3},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func onlySpan(t *testing.T, r *recorder, name string) *recordedSpan {
+	t.Helper()
+	spans := r.named(name)
+	if len(spans) != 1 {
+		t.Fatalf("%d %s spans, want 1", len(spans), name)
+	}
+	if !spans[0].ended {
+		t.Fatalf("%s span not ended", name)
+	}
+	return spans[0]
+}
+
+func checkInt(t *testing.T, span *recordedSpan, key string, want int64) {
+	t.Helper()
+	value, ok := span.attrs[attribute.Key(key)]
+	if !ok {
+		t.Fatalf("%s span has no %s attribute", span.name, key)
+	}
+	if got := value.AsInt64(); got != want {
+		t.Errorf("%s = %d, want %d", key, got, want)
+	}
+}
+
+func TestBatchSpan(t *testing.T) {
+	r := &recorder{}
+	m := newFastMapper(t, New(r))
+
+	err := m.ProcessBatchContext(context.Background(), [][2]string{
+		{"Device.Hosts.Host.1.HostName", "laptop"},
+		{"Device.Hosts.Host.1.Active", "maybe"},
+		{"Device.Hosts.Host.1.Unmapped", "x"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	span := onlySpan(t, r, "mapper.ProcessBatch")
+	checkInt(t, span, "mapper.batch.size", 3)
+	checkInt(t, span, "mapper.batch.matched", 1)
+	checkInt(t, span, "mapper.batch.failed", 1)
+	if span.status != codes.Unset {
+		t.Errorf("status = %v, want Unset", span.status)
+	}
+	if spans := r.named("mapper.ApplyRule"); len(spans) != 0 {
+		t.Errorf("%d rule spans without WithRuleSpans", len(spans))
+	}
+}
+
+func TestBatchSpanError(t *testing.T) {
+	r := &recorder{}
+	m := newFastMapper(t, New(r))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := m.ProcessBatchContext(ctx, [][2]string{
+		{"Device.Hosts.Host.1.HostName", "laptop"},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+
+	span := onlySpan(t, r, "mapper.ProcessBatch")
+	if span.status != codes.Error {
+		t.Errorf("status = %v, want Error", span.status)
+	}
+	if len(span.errs) != 1 || !errors.Is(span.errs[0], context.Canceled) {
+		t.Errorf("recorded errors %v, want [context.Canceled]", span.errs)
+	}
+}
+
+func TestRuleSpans(t *testing.T) {
+	r := &recorder{}
+	m := newFastMapper(t, New(r, WithRuleSpans()))
+
+	err := m.ProcessBatchContext(context.Background(), [][2]string{
+		{"Device.Hosts.Host.1.HostName", "laptop"},
+		{"Device.Hosts.Host.2.HostName", "phone"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	span := onlySpan(t, r, "mapper.ProcessBatch")
+	checkInt(t, span, "mapper.batch.matched", 2)
+	checkInt(t, span, "mapper.batch.failed", 0)
+	if span.status != codes.Unset {
+		t.Errorf("status = %v, want Unset", span.status)
+	}
+
+	rules := r.named("mapper.ApplyRule")
+	if len(rules) != 2 {
+		t.Fatalf("%d rule spans, want 2", len(rules))
+	}
+	for _, rule := range rules {
+		if got := rule.attrs["mapper.rule.id"].AsString(); got != "host_HostName" {
+			t.Errorf("rule id = %q, want host_HostName", got)
+		}
+		if !rule.ended || rule.status != codes.Unset {
+			t.Errorf("rule span ended=%v status=%v", rule.ended, rule.status)
+		}
+	}
+}
+
+func TestRuleSpanFailure(t *testing.T) {
+	r := &recorder{}
+	m := newFastMapper(t, New(r, WithRuleSpans()))
+
+	err := m.ProcessBatchContext(context.Background(), [][2]string{
+		{"Device.Hosts.Host.1.Active", "maybe"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rule := onlySpan(t, r, "mapper.ApplyRule")
+	if rule.status != codes.Error {
+		t.Errorf("status = %v, want Error", rule.status)
+	}
+}
+
+func TestMapperSpans(t *testing.T) {
+	r := &recorder{}
+	reg := registry.New()
+	reg.MustRegister("host", func() any { return &host{} })
+
+	m := mapper.New(reg, mapper.WithTracer(New(r, WithRuleSpans())))
+	err := m.LoadRulesFromString(`
+version: "1.0"
+rules:
+  - name: hosts
+    target: host
+    route: 'path.startsWith("Device.Hosts.Host.")'
+    entity_key: 'path.split(".")[3]'
+    fields:
+      - name: HostName
+        when: 'path.endsWith(".HostName")'
+        value: 'value'
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.ProcessBatch([][2]string{
+		{"Device.Hosts.Host.1.HostName", "laptop"},
+		{"Device.WiFi.SSID.1.SSID", "home"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	span := onlySpan(t, r, "mapper.ProcessBatch")
+	checkInt(t, span, "mapper.batch.size", 2)
+	checkInt(t, span, "mapper.batch.matched", 1)
+	checkInt(t, span, "mapper.batch.failed", 0)
+
+	rule := onlySpan(t, r, "mapper.ApplyRule")
+	if got := rule.attrs["mapper.rule.id"].AsString(); got != "hosts" {
+		t.Errorf("rule id = %q, want hosts", got)
+	}
+
+	if err := m.ProcessMap(map[string]string{"Device.Hosts.Host.2.HostName": "phone"}); err != nil {
+		t.Fatal(err)
+	}
+	if spans := r.named("mapper.ProcessBatch"); len(spans) != 2 {
+		t.Errorf("%d batch spans after ProcessMap, want 2", len(spans))
+	}
+}
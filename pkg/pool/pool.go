@@ -26,6 +26,18 @@ func (p *ObjectPool) Register(typeName string, factory func() any) {
 	}
 }
 
+func (p *ObjectPool) RegisterAlias(alias, typeName string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pool, ok := p.pools[typeName]
+	if !ok {
+		return false
+	}
+	p.pools[alias] = pool
+	return true
+}
+
 func (p *ObjectPool) Get(typeName string) (any, bool) {
 	p.mu.RLock()
 	pool, ok := p.pools[typeName]
@@ -51,6 +63,19 @@ func (p *ObjectPool) Put(typeName string, obj any) {
 	pool.Put(obj)
 }
 
+func (p *ObjectPool) Drain() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fresh := make(map[*sync.Pool]*sync.Pool, len(p.pools))
+	for name, pool := range p.pools {
+		if _, ok := fresh[pool]; !ok {
+			fresh[pool] = &sync.Pool{New: pool.New}
+		}
+		p.pools[name] = fresh[pool]
+	}
+}
+
 func (p *ObjectPool) resetObject(obj any) {
 	v := reflect.ValueOf(obj)
 	if v.Kind() == reflect.Ptr {
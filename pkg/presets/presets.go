@@ -0,0 +1,125 @@
+package presets
+
+import (
+	"strings"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/mapper"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/router"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/tr069keys"
+)
+
+type Host struct {
+	MACAddress    string
+	IPAddress     string
+	HostName      string
+	Active        bool
+	InterfaceType string
+}
+
+type WiFi struct {
+	SSID     string
+	Password string
+	Channel  int
+	Band     string
+	Enabled  bool
+}
+
+type WAN struct {
+	Enable           bool
+	ConnectionStatus string
+	ConnectionType   string
+	Name             string
+	Username         string
+	ExternalIP       string
+	DNSServers       []string
+	Uptime           int
+}
+
+type spec struct {
+	path      string
+	field     string
+	transform string
+}
+
+var hostSpecs = []spec{
+	{"InternetGatewayDevice.LANDevice.*.Hosts.Host.*.MACAddress", "MACAddress", "mac_normalize"},
+	{"InternetGatewayDevice.LANDevice.*.Hosts.Host.*.IPAddress", "IPAddress", "ip_validate"},
+	{"InternetGatewayDevice.LANDevice.*.Hosts.Host.*.HostName", "HostName", ""},
+	{"InternetGatewayDevice.LANDevice.*.Hosts.Host.*.Active", "Active", "bool"},
+	{"InternetGatewayDevice.LANDevice.*.Hosts.Host.*.InterfaceType", "InterfaceType", ""},
+	{"Device.Hosts.Host.*.PhysAddress", "MACAddress", "mac_normalize"},
+	{"Device.Hosts.Host.*.IPAddress", "IPAddress", "ip_validate"},
+	{"Device.Hosts.Host.*.HostName", "HostName", ""},
+	{"Device.Hosts.Host.*.Active", "Active", "bool"},
+}
+
+var wifiSpecs = []spec{
+	{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.SSID", "SSID", ""},
+	{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.KeyPassphrase", "Password", ""},
+	{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.Channel", "Channel", "int"},
+	{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.OperatingFrequencyBand", "Band", "band_normalize_lenient"},
+	{"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.Enable", "Enabled", "bool"},
+	{"Device.WiFi.SSID.*.SSID", "SSID", ""},
+	{"Device.WiFi.SSID.*.Enable", "Enabled", "bool"},
+	{"Device.WiFi.AccessPoint.*.Security.KeyPassphrase", "Password", ""},
+	{"Device.WiFi.Radio.*.Channel", "Channel", "int"},
+	{"Device.WiFi.Radio.*.OperatingFrequencyBand", "Band", "band_normalize_lenient"},
+}
+
+var wanSpecs = []spec{
+	{"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANPPPConnection.*.Enable", "Enable", "bool"},
+	{"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANPPPConnection.*.ConnectionStatus", "ConnectionStatus", ""},
+	{"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANPPPConnection.*.ConnectionType", "ConnectionType", ""},
+	{"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANPPPConnection.*.Name", "Name", ""},
+	{"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANPPPConnection.*.Username", "Username", ""},
+	{"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANPPPConnection.*.ExternalIPAddress", "ExternalIP", "ip_validate"},
+	{"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANPPPConnection.*.DNSServers", "DNSServers", "dns_servers_lenient"},
+	{"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANPPPConnection.*.Uptime", "Uptime", "int"},
+	{"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANIPConnection.*.Enable", "Enable", "bool"},
+	{"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANIPConnection.*.ConnectionStatus", "ConnectionStatus", ""},
+	{"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANIPConnection.*.ConnectionType", "ConnectionType", ""},
+	{"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANIPConnection.*.Name", "Name", ""},
+	{"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANIPConnection.*.ExternalIPAddress", "ExternalIP", "ip_validate"},
+	{"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANIPConnection.*.DNSServers", "DNSServers", "dns_servers_lenient"},
+	{"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANIPConnection.*.Uptime", "Uptime", "int"},
+	{"Device.PPP.Interface.*.Enable", "Enable", "bool"},
+	{"Device.PPP.Interface.*.Status", "ConnectionStatus", ""},
+	{"Device.PPP.Interface.*.Name", "Name", ""},
+	{"Device.PPP.Interface.*.Username", "Username", ""},
+	{"Device.IP.Interface.*.Enable", "Enable", "bool"},
+	{"Device.IP.Interface.*.Status", "ConnectionStatus", ""},
+	{"Device.IP.Interface.*.Name", "Name", ""},
+	{"Device.IP.Interface.*.IPv4Address.*.IPAddress", "ExternalIP", "ip_validate"},
+}
+
+func HostRules(entity string) []*mapper.FastRule {
+	return build(entity, &tr069keys.HostExtractor{Prefix: entity + ":"}, hostSpecs)
+}
+
+func WiFiRules(entity string) []*mapper.FastRule {
+	return build(entity, &tr069keys.WLANExtractor{Prefix: entity + ":"}, wifiSpecs)
+}
+
+func WANRules(entity string) []*mapper.FastRule {
+	return build(entity, &tr069keys.WANExtractor{Prefix: entity + ":"}, wanSpecs)
+}
+
+func build(entity string, key extractor.KeyExtractor, specs []spec) []*mapper.FastRule {
+	rules := make([]*mapper.FastRule, 0, len(specs))
+	for _, s := range specs {
+		pattern := router.CompilePattern(s.path)
+		pattern.Entity = entity
+		pattern.Field = s.field
+
+		rules = append(rules, &mapper.FastRule{
+			ID:        entity + ":" + strings.ReplaceAll(s.path, ".*", ""),
+			Pattern:   pattern,
+			Entity:    entity,
+			Field:     s.field,
+			Transform: s.transform,
+			Extractor: key,
+		})
+	}
+	return rules
+}
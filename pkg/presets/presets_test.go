@@ -0,0 +1,97 @@
+package presets
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/mapper"
+	"github.com/metalgrid/tr069-cel-mapper/pkg/registry"
+)
+
+func newMapper(t *testing.T) *mapper.FastMapper {
+	t.Helper()
+	reg := registry.New()
+	reg.Register("host", func() any { return &Host{} })
+	reg.Register("wifi", func() any { return &WiFi{} })
+	reg.Register("wan", func() any { return &WAN{} })
+
+	m := mapper.NewFast(reg)
+	for _, rules := range [][]*mapper.FastRule{HostRules("host"), WiFiRules("wifi"), WANRules("wan")} {
+		if err := m.AddRules(rules); err != nil {
+			t.Fatalf("AddRules: %v", err)
+		}
+	}
+	if errs := m.Validate(); len(errs) > 0 {
+		t.Fatalf("Validate: %v", errs)
+	}
+	return m
+}
+
+func TestPresetsTR098(t *testing.T) {
+	m := newMapper(t)
+	err := m.ProcessBatch([][2]string{
+		{"InternetGatewayDevice.LANDevice.1.Hosts.Host.2.MACAddress", "AA-BB-CC-DD-EE-FF"},
+		{"InternetGatewayDevice.LANDevice.1.Hosts.Host.2.Active", "1"},
+		{"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.SSID", "home"},
+		{"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.Channel", "6"},
+		{"InternetGatewayDevice.WANDevice.1.WANConnectionDevice.1.WANPPPConnection.1.Username", "user"},
+		{"InternetGatewayDevice.WANDevice.1.WANConnectionDevice.1.WANPPPConnection.1.DNSServers", "8.8.8.8,1.1.1.1"},
+	})
+	if err != nil {
+		t.Fatalf("ProcessBatch: %v", err)
+	}
+
+	store := m.GetStore()
+	host, _ := store.Get("host", "host:2")
+	if got := host.(*Host); got.MACAddress != "aa:bb:cc:dd:ee:ff" || !got.Active {
+		t.Errorf("host = %+v", got)
+	}
+	wifi, _ := store.Get("wifi", "wifi:1")
+	if got := wifi.(*WiFi); got.SSID != "home" || got.Channel != 6 {
+		t.Errorf("wifi = %+v", got)
+	}
+	wan, _ := store.Get("wan", "wan:1.1.ppp.1")
+	got := wan.(*WAN)
+	if got.Username != "user" || !reflect.DeepEqual(got.DNSServers, []string{"8.8.8.8", "1.1.1.1"}) {
+		t.Errorf("wan = %+v", got)
+	}
+}
+
+func TestPresetsTR181(t *testing.T) {
+	m := newMapper(t)
+	err := m.ProcessBatch([][2]string{
+		{"Device.Hosts.Host.3.PhysAddress", "aabbccddeeff"},
+		{"Device.WiFi.AccessPoint.1.Security.KeyPassphrase", "secret"},
+		{"Device.WiFi.Radio.1.OperatingFrequencyBand", "5GHz"},
+		{"Device.IP.Interface.2.IPv4Address.1.IPAddress", "203.0.113.7"},
+	})
+	if err != nil {
+		t.Fatalf("ProcessBatch: %v", err)
+	}
+
+	store := m.GetStore()
+	host, _ := store.Get("host", "host:3")
+	if got := host.(*Host); got.MACAddress != "aa:bb:cc:dd:ee:ff" {
+		t.Errorf("host = %+v", got)
+	}
+	wifi, _ := store.Get("wifi", "wifi:1")
+	if got := wifi.(*WiFi); got.Password != "secret" || got.Band == "" {
+		t.Errorf("wifi = %+v", got)
+	}
+	wan, _ := store.Get("wan", "wan:ip.2")
+	if got := wan.(*WAN); got.ExternalIP != "203.0.113.7" {
+		t.Errorf("wan = %+v", got)
+	}
+}
+
+func TestPresetRuleIDsUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, rules := range [][]*mapper.FastRule{HostRules("host"), WiFiRules("wifi"), WANRules("wan")} {
+		for _, rule := range rules {
+			if seen[rule.ID] {
+				t.Errorf("duplicate rule ID %s", rule.ID)
+			}
+			seen[rule.ID] = true
+		}
+	}
+}
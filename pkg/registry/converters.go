@@ -0,0 +1,64 @@
+package registry
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	converters    sync.Map
+	anyConverters atomic.Bool
+)
+
+func RegisterConverter(targetType reflect.Type, fn func(string) (any, error)) {
+	converters.Store(targetType, fn)
+	anyConverters.Store(true)
+}
+
+func HasConverter(t reflect.Type) bool {
+	_, ok := lookupConverter(t)
+	return ok
+}
+
+func lookupConverter(t reflect.Type) (func(string) (any, error), bool) {
+	if !anyConverters.Load() {
+		return nil, false
+	}
+	fn, ok := converters.Load(t)
+	if !ok {
+		return nil, false
+	}
+	return fn.(func(string) (any, error)), true
+}
+
+func convertString(fieldValue reflect.Value, fieldType reflect.Type, s string, fieldName string) (bool, error) {
+	target := fieldType
+	convert, ok := lookupConverter(target)
+	if !ok && fieldType.Kind() == reflect.Ptr {
+		target = fieldType.Elem()
+		convert, ok = lookupConverter(target)
+	}
+	if !ok {
+		return false, nil
+	}
+
+	converted, err := convert(s)
+	if err != nil {
+		return true, &CoercionError{Field: fieldName, Value: s, Err: err}
+	}
+	rv := reflect.ValueOf(converted)
+	if !rv.IsValid() || (!rv.Type().AssignableTo(target) && (rv.Kind() != target.Kind() || !rv.Type().ConvertibleTo(target))) {
+		return true, &CoercionError{Field: fieldName, Value: s, Err: fmt.Errorf("converter for %s returned %T", target, converted)}
+	}
+	rv = rv.Convert(target)
+
+	if target != fieldType {
+		ptr := reflect.New(target)
+		ptr.Elem().Set(rv)
+		rv = ptr
+	}
+	fieldValue.Set(rv)
+	return true, nil
+}
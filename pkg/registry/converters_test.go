@@ -0,0 +1,151 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type linkStatus int
+
+const (
+	linkDown linkStatus = iota
+	linkUp
+	linkDormant
+)
+
+type band string
+
+type link struct {
+	Status   linkStatus
+	Previous *linkStatus
+	Band     band
+	Code     int
+}
+
+var errUnknownStatus = errors.New("unknown status")
+
+func init() {
+	RegisterConverter(reflect.TypeOf(linkDown), func(s string) (any, error) {
+		switch s {
+		case "Up":
+			return linkUp, nil
+		case "Down":
+			return linkDown, nil
+		case "Dormant":
+			return 2, nil
+		case "Broken":
+			return "broken", nil
+		}
+		return nil, fmt.Errorf("%w %q", errUnknownStatus, s)
+	})
+	RegisterConverter(reflect.TypeOf(band("")), func(s string) (any, error) {
+		switch s {
+		case "2.4GHz", "2.4":
+			return band("2g"), nil
+		case "5GHz", "5":
+			return band("5g"), nil
+		}
+		return nil, fmt.Errorf("unknown band %q", s)
+	})
+}
+
+func newLinkInfo(t *testing.T) *TypeInfo {
+	t.Helper()
+	reg := New()
+	reg.MustRegister("link", func() any { return &link{} })
+	info, err := reg.Get("link")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func TestConverterSetsNamedTypes(t *testing.T) {
+	info := newLinkInfo(t)
+	l := &link{}
+
+	for field, value := range map[string]any{
+		"Status":   "Up",
+		"Previous": "Dormant",
+		"Band":     "5GHz",
+		"Code":     "7",
+	} {
+		if err := info.Setters[field](l, value); err != nil {
+			t.Fatalf("set %s: %v", field, err)
+		}
+	}
+
+	if l.Status != linkUp || l.Previous == nil || *l.Previous != linkDormant || l.Band != "5g" || l.Code != 7 {
+		t.Errorf("link = %+v", l)
+	}
+}
+
+func TestConverterSkippedForTypedValues(t *testing.T) {
+	info := newLinkInfo(t)
+	l := &link{}
+
+	if err := info.Setters["Status"](l, linkDormant); err != nil {
+		t.Fatal(err)
+	}
+	if err := info.Setters["Band"](l, band("6g")); err != nil {
+		t.Fatal(err)
+	}
+	if l.Status != linkDormant || l.Band != "6g" {
+		t.Errorf("link = %+v", l)
+	}
+}
+
+func TestConverterErrors(t *testing.T) {
+	info := newLinkInfo(t)
+
+	tests := []struct {
+		field string
+		value string
+		want  string
+	}{
+		{"Status", "Flapping", `field Status: unknown status "Flapping"`},
+		{"Status", "Broken", "field Status: converter for registry.linkStatus returned string"},
+		{"Previous", "Flapping", `field Previous: unknown status "Flapping"`},
+		{"Band", "60GHz", `field Band: unknown band "60GHz"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field+"="+tt.value, func(t *testing.T) {
+			l := &link{Status: linkUp}
+			err := info.Setters[tt.field](l, tt.value)
+			var coercion *CoercionError
+			if !errors.As(err, &coercion) {
+				t.Fatalf("err = %v, want a CoercionError", err)
+			}
+			if coercion.Field != tt.field || coercion.Value != tt.value {
+				t.Errorf("CoercionError = %+v", coercion)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("err = %q, want %q", err, tt.want)
+			}
+			if l.Status != linkUp || l.Previous != nil || l.Band != "" {
+				t.Errorf("failed conversion modified the entity: %+v", l)
+			}
+		})
+	}
+
+	err := info.Setters["Status"](&link{}, "Flapping")
+	if !errors.Is(err, errUnknownStatus) {
+		t.Errorf("err = %v does not wrap the converter error", err)
+	}
+}
+
+func TestHasConverter(t *testing.T) {
+	for typ, want := range map[reflect.Type]bool{
+		reflect.TypeOf(linkUp):          true,
+		reflect.TypeOf(band("")):        true,
+		reflect.TypeOf(new(linkStatus)): false,
+		reflect.TypeOf(0):               false,
+		reflect.TypeOf(""):              false,
+	} {
+		if got := HasConverter(typ); got != want {
+			t.Errorf("HasConverter(%s) = %v, want %v", typ, got, want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package registry
+
+import (
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+func fastSetter(t reflect.Type, field reflect.StructField, fallback func(any, any) error) func(any, any) error {
+	ptrType := reflect.PointerTo(t)
+	offset := field.Offset
+
+	fieldPtr := func(obj any) (unsafe.Pointer, bool) {
+		if reflect.TypeOf(obj) != ptrType {
+			return nil, false
+		}
+		ptr := reflect.ValueOf(obj).UnsafePointer()
+		if ptr == nil {
+			return nil, false
+		}
+		return unsafe.Add(ptr, offset), true
+	}
+
+	switch field.Type.Kind() {
+	case reflect.String:
+		return func(obj any, value any) error {
+			s, ok := value.(string)
+			if !ok {
+				return fallback(obj, value)
+			}
+			if _, convert := lookupConverter(field.Type); convert {
+				return fallback(obj, value)
+			}
+			p, ok := fieldPtr(obj)
+			if !ok {
+				return fallback(obj, value)
+			}
+			*(*string)(p) = s
+			return nil
+		}
+
+	case reflect.Bool:
+		return func(obj any, value any) error {
+			b, ok := value.(bool)
+			if !ok {
+				return fallback(obj, value)
+			}
+			p, ok := fieldPtr(obj)
+			if !ok {
+				return fallback(obj, value)
+			}
+			*(*bool)(p) = b
+			return nil
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := field.Type.Bits()
+		minInt, maxInt := int64(-1)<<(bits-1), int64(1)<<(bits-1)-1
+		return func(obj any, value any) error {
+			i, ok := value.(int64)
+			if !ok || i < minInt || i > maxInt {
+				return fallback(obj, value)
+			}
+			p, ok := fieldPtr(obj)
+			if !ok {
+				return fallback(obj, value)
+			}
+			switch bits {
+			case 8:
+				*(*int8)(p) = int8(i)
+			case 16:
+				*(*int16)(p) = int16(i)
+			case 32:
+				*(*int32)(p) = int32(i)
+			default:
+				*(*int64)(p) = i
+			}
+			return nil
+		}
+
+	case reflect.Float32, reflect.Float64:
+		bits := field.Type.Bits()
+		return func(obj any, value any) error {
+			f, ok := value.(float64)
+			if !ok || (bits == 32 && math.Abs(f) > math.MaxFloat32 && !math.IsInf(f, 0)) {
+				return fallback(obj, value)
+			}
+			p, ok := fieldPtr(obj)
+			if !ok {
+				return fallback(obj, value)
+			}
+			if bits == 32 {
+				*(*float32)(p) = float32(f)
+			} else {
+				*(*float64)(p) = f
+			}
+			return nil
+		}
+	}
+
+	return fallback
+}
@@ -1,26 +1,49 @@
 package registry
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 type TypeInfo struct {
 	Type    reflect.Type
 	Factory func() any
 	Setters map[string]func(any, any) error
+	Getters map[string]func(any) any
+
+	LastSeen func(obj any, t time.Time)
+}
+
+type CoercionError struct {
+	Field string
+	Value any
+	Err   error
+}
+
+func (e *CoercionError) Error() string {
+	return fmt.Sprintf("field %s: %v", e.Field, e.Err)
+}
+
+func (e *CoercionError) Unwrap() error {
+	return e.Err
 }
 
 type Registry struct {
-	mu    sync.RWMutex
-	types map[string]*TypeInfo
+	mu     sync.RWMutex
+	types  map[string]*TypeInfo
+	byType map[reflect.Type]string
 }
 
 func New() *Registry {
 	return &Registry{
-		types: make(map[string]*TypeInfo),
+		types:  make(map[string]*TypeInfo),
+		byType: make(map[reflect.Type]string),
 	}
 }
 
@@ -42,16 +65,49 @@ func (r *Registry) Register(name string, factory func() any) error {
 	if err != nil {
 		return fmt.Errorf("failed to build setters for %s: %w", name, err)
 	}
+	lastSeen, err := buildLastSeen(t)
+	if err != nil {
+		return fmt.Errorf("failed to build last_seen for %s: %w", name, err)
+	}
 
 	r.types[name] = &TypeInfo{
 		Type:    t,
 		Factory: factory,
 		Setters: setters,
+		Getters: buildGetters(t),
+
+		LastSeen: lastSeen,
+	}
+	if _, exists := r.byType[t]; !exists {
+		r.byType[t] = name
 	}
 
 	return nil
 }
 
+func (r *Registry) RegisterAlias(alias, existing string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.types[alias]; exists {
+		return fmt.Errorf("type %s already registered", alias)
+	}
+
+	info, ok := r.types[existing]
+	if !ok {
+		return fmt.Errorf("type %s not registered", existing)
+	}
+
+	r.types[alias] = info
+	return nil
+}
+
+func (r *Registry) MustRegisterAlias(alias, existing string) {
+	if err := r.RegisterAlias(alias, existing); err != nil {
+		panic(err)
+	}
+}
+
 func (r *Registry) MustRegister(name string, factory func() any) {
 	if err := r.Register(name, factory); err != nil {
 		panic(err)
@@ -69,6 +125,25 @@ func (r *Registry) Get(name string) (*TypeInfo, error) {
 	return info, nil
 }
 
+func (r *Registry) Lookup(obj any) (string, *TypeInfo, bool) {
+	t := reflect.TypeOf(obj)
+	if t == nil {
+		return "", nil, false
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	name, ok := r.byType[t]
+	if !ok {
+		return "", nil, false
+	}
+	return name, r.types[name], true
+}
+
 func (r *Registry) Has(name string) bool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -84,6 +159,7 @@ func (r *Registry) List() []string {
 	for name := range r.types {
 		names = append(names, name)
 	}
+	sort.Strings(names)
 	return names
 }
 
@@ -121,6 +197,14 @@ func buildSetters(t reflect.Type) (map[string]func(any, any) error, error) {
 
 			return setFieldValue(fieldValue, fieldType, value, fieldName)
 		}
+		setters[fieldName] = fastSetter(t, field, setters[fieldName])
+
+		if hasTagOption(field, "accumulate") {
+			if !isNumericKind(fieldType.Kind()) {
+				return nil, fmt.Errorf("field %s: accumulate requires a numeric field, got %s", fieldName, fieldType.Kind())
+			}
+			setters[fieldName] = accumulateSetter(fieldIndex, fieldType, fieldName)
+		}
 
 		if tag := field.Tag.Get("json"); tag != "" {
 			setters[tag] = setters[fieldName]
@@ -133,13 +217,156 @@ func buildSetters(t reflect.Type) (map[string]func(any, any) error, error) {
 	return setters, nil
 }
 
+var timeType = reflect.TypeOf(time.Time{})
+
+func buildLastSeen(t reflect.Type) (func(any, time.Time), error) {
+	fieldIndex := -1
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || !hasTagOption(field, "last_seen") {
+			continue
+		}
+		if field.Type != timeType {
+			return nil, fmt.Errorf("field %s: last_seen requires a time.Time field, got %s", field.Name, field.Type)
+		}
+		if fieldIndex >= 0 {
+			return nil, fmt.Errorf("field %s: only one field can be tagged last_seen", field.Name)
+		}
+		fieldIndex = i
+	}
+	if fieldIndex < 0 {
+		return nil, nil
+	}
+
+	return func(obj any, seen time.Time) {
+		rv := reflect.ValueOf(obj)
+		if rv.Kind() == reflect.Ptr {
+			rv = rv.Elem()
+		}
+		if rv.IsValid() && rv.Kind() == reflect.Struct {
+			rv.Field(fieldIndex).Set(reflect.ValueOf(seen))
+		}
+	}, nil
+}
+
+func hasTagOption(field reflect.StructField, option string) bool {
+	for _, opt := range strings.Split(field.Tag.Get("tr069"), ",") {
+		if strings.TrimSpace(opt) == option {
+			return true
+		}
+	}
+	return false
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func accumulateSetter(fieldIndex int, fieldType reflect.Type, fieldName string) func(any, any) error {
+	return func(obj any, value any) error {
+		rv := reflect.ValueOf(obj)
+		if rv.Kind() == reflect.Ptr {
+			rv = rv.Elem()
+		}
+		if !rv.IsValid() || rv.Kind() != reflect.Struct {
+			return fmt.Errorf("invalid object for field %s", fieldName)
+		}
+
+		delta := reflect.New(fieldType).Elem()
+		if err := setFieldValue(delta, fieldType, value, fieldName); err != nil {
+			return err
+		}
+
+		fieldValue := rv.Field(fieldIndex)
+		switch fieldType.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			sum := fieldValue.Int() + delta.Int()
+			if fieldValue.OverflowInt(sum) {
+				return &CoercionError{Field: fieldName, Value: value, Err: errors.New("integer overflow")}
+			}
+			fieldValue.SetInt(sum)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			sum := fieldValue.Uint() + delta.Uint()
+			if fieldValue.OverflowUint(sum) {
+				return &CoercionError{Field: fieldName, Value: value, Err: errors.New("unsigned integer overflow")}
+			}
+			fieldValue.SetUint(sum)
+		default:
+			fieldValue.SetFloat(fieldValue.Float() + delta.Float())
+		}
+		return nil
+	}
+}
+
+func buildGetters(t reflect.Type) map[string]func(any) any {
+	getters := make(map[string]func(any) any)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldIndex := i
+		getters[field.Name] = func(obj any) any {
+			rv := reflect.ValueOf(obj)
+			if rv.Kind() == reflect.Ptr {
+				rv = rv.Elem()
+			}
+			if !rv.IsValid() || rv.Kind() != reflect.Struct {
+				return nil
+			}
+			return rv.Field(fieldIndex).Interface()
+		}
+
+		if tag := field.Tag.Get("json"); tag != "" {
+			getters[tag] = getters[field.Name]
+		}
+		if tag := field.Tag.Get("yaml"); tag != "" {
+			getters[tag] = getters[field.Name]
+		}
+	}
+
+	return getters
+}
+
+func (info *TypeInfo) Fields(obj any) map[string]any {
+	fields := make(map[string]any, info.Type.NumField())
+	for i := 0; i < info.Type.NumField(); i++ {
+		field := info.Type.Field(i)
+		if getter, ok := info.Getters[field.Name]; ok {
+			fields[field.Name] = getter(obj)
+		}
+	}
+	return fields
+}
+
+func (info *TypeInfo) FieldType(name string) (reflect.Type, bool) {
+	for i := 0; i < info.Type.NumField(); i++ {
+		field := info.Type.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Name == name || field.Tag.Get("json") == name || field.Tag.Get("yaml") == name {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
 func setFieldValue(fieldValue reflect.Value, fieldType reflect.Type, value any, fieldName string) error {
 	if value == nil {
 		if fieldType.Kind() == reflect.Ptr {
 			fieldValue.Set(reflect.Zero(fieldType))
 			return nil
 		}
-		return fmt.Errorf("cannot set nil to non-pointer field %s", fieldName)
+		return &CoercionError{Field: fieldName, Err: errors.New("cannot set nil to a non-pointer field")}
 	}
 
 	valueType := reflect.TypeOf(value)
@@ -153,6 +380,12 @@ func setFieldValue(fieldValue reflect.Value, fieldType reflect.Type, value any,
 		}
 	}
 
+	if s, ok := value.(string); ok {
+		if converted, err := convertString(fieldValue, fieldType, s, fieldName); converted {
+			return err
+		}
+	}
+
 	if valueType.AssignableTo(fieldType) {
 		fieldValue.Set(reflect.ValueOf(value))
 		return nil
@@ -162,44 +395,44 @@ func setFieldValue(fieldValue reflect.Value, fieldType reflect.Type, value any,
 	case reflect.String:
 		str, err := toString(value)
 		if err != nil {
-			return fmt.Errorf("field %s: %w", fieldName, err)
+			return &CoercionError{Field: fieldName, Value: value, Err: err}
 		}
 		fieldValue.SetString(str)
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		i, err := toInt64(value)
 		if err != nil {
-			return fmt.Errorf("field %s: %w", fieldName, err)
+			return &CoercionError{Field: fieldName, Value: value, Err: err}
 		}
 		if fieldValue.OverflowInt(i) {
-			return fmt.Errorf("field %s: integer overflow", fieldName)
+			return &CoercionError{Field: fieldName, Value: value, Err: errors.New("integer overflow")}
 		}
 		fieldValue.SetInt(i)
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		u, err := toUint64(value)
 		if err != nil {
-			return fmt.Errorf("field %s: %w", fieldName, err)
+			return &CoercionError{Field: fieldName, Value: value, Err: err}
 		}
 		if fieldValue.OverflowUint(u) {
-			return fmt.Errorf("field %s: unsigned integer overflow", fieldName)
+			return &CoercionError{Field: fieldName, Value: value, Err: errors.New("unsigned integer overflow")}
 		}
 		fieldValue.SetUint(u)
 
 	case reflect.Float32, reflect.Float64:
 		f, err := toFloat64(value)
 		if err != nil {
-			return fmt.Errorf("field %s: %w", fieldName, err)
+			return &CoercionError{Field: fieldName, Value: value, Err: err}
 		}
 		if fieldValue.OverflowFloat(f) {
-			return fmt.Errorf("field %s: float overflow", fieldName)
+			return &CoercionError{Field: fieldName, Value: value, Err: errors.New("float overflow")}
 		}
 		fieldValue.SetFloat(f)
 
 	case reflect.Bool:
 		b, err := toBool(value)
 		if err != nil {
-			return fmt.Errorf("field %s: %w", fieldName, err)
+			return &CoercionError{Field: fieldName, Value: value, Err: err}
 		}
 		fieldValue.SetBool(b)
 
@@ -223,7 +456,7 @@ func setFieldValue(fieldValue reflect.Value, fieldType reflect.Type, value any,
 func setSliceValue(fieldValue reflect.Value, fieldType reflect.Type, value any, fieldName string) error {
 	rv := reflect.ValueOf(value)
 	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
-		return fmt.Errorf("field %s: expected slice or array, got %T", fieldName, value)
+		return &CoercionError{Field: fieldName, Value: value, Err: fmt.Errorf("expected slice or array, got %T", value)}
 	}
 
 	elemType := fieldType.Elem()
@@ -244,7 +477,7 @@ func setSliceValue(fieldValue reflect.Value, fieldType reflect.Type, value any,
 func setMapValue(fieldValue reflect.Value, fieldType reflect.Type, value any, fieldName string) error {
 	rv := reflect.ValueOf(value)
 	if rv.Kind() != reflect.Map {
-		return fmt.Errorf("field %s: expected map, got %T", fieldName, value)
+		return &CoercionError{Field: fieldName, Value: value, Err: fmt.Errorf("expected map, got %T", value)}
 	}
 
 	keyType := fieldType.Key()
@@ -0,0 +1,74 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type seenHost struct {
+	Name string
+	Seen time.Time `tr069:"last_seen"`
+}
+
+type seenString struct {
+	Seen string `tr069:"last_seen"`
+}
+
+type seenTwice struct {
+	First  time.Time `tr069:"last_seen"`
+	Second time.Time `tr069:"last_seen"`
+}
+
+func TestRegisterLastSeen(t *testing.T) {
+	reg := New()
+	reg.MustRegister("host", func() any { return &seenHost{} })
+	info, _ := reg.Get("host")
+	if info.LastSeen == nil {
+		t.Fatal("last_seen field not wired")
+	}
+
+	host := &seenHost{}
+	now := time.Now()
+	info.LastSeen(host, now)
+	if !host.Seen.Equal(now) {
+		t.Errorf("Seen = %v, want %v", host.Seen, now)
+	}
+}
+
+func TestRegisterLastSeenInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		factory func() any
+		errMsg  string
+	}{
+		{"not time.Time", func() any { return &seenString{} }, "last_seen requires a time.Time field, got string"},
+		{"two fields", func() any { return &seenTwice{} }, "field Second: only one field can be tagged last_seen"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := New()
+			err := reg.Register("entity", tt.factory)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.HasPrefix(err.Error(), "failed to build last_seen for entity: ") || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %q, want last_seen error containing %q", err, tt.errMsg)
+			}
+			if reg.Has("entity") {
+				t.Error("invalid type was registered")
+			}
+		})
+	}
+}
+
+func TestListSorted(t *testing.T) {
+	reg := New()
+	for _, name := range []string{"wifi", "host", "wan"} {
+		reg.MustRegister(name, func() any { return &seenHost{} })
+	}
+	if got := reg.List(); fmt.Sprint(got) != "[host wan wifi]" {
+		t.Errorf("List() = %v", got)
+	}
+}
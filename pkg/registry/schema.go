@@ -0,0 +1,98 @@
+package registry
+
+import (
+	"fmt"
+	"go/token"
+	"reflect"
+	"strings"
+)
+
+type SchemaField struct {
+	Name string `yaml:"name" json:"name"`
+	Type string `yaml:"type" json:"type"`
+	Tag  string `yaml:"tag,omitempty" json:"tag,omitempty"`
+}
+
+var schemaTypes = map[string]reflect.Type{
+	"string":  reflect.TypeOf(""),
+	"bool":    reflect.TypeOf(false),
+	"int":     reflect.TypeOf(int(0)),
+	"int8":    reflect.TypeOf(int8(0)),
+	"int16":   reflect.TypeOf(int16(0)),
+	"int32":   reflect.TypeOf(int32(0)),
+	"int64":   reflect.TypeOf(int64(0)),
+	"uint":    reflect.TypeOf(uint(0)),
+	"uint8":   reflect.TypeOf(uint8(0)),
+	"uint16":  reflect.TypeOf(uint16(0)),
+	"uint32":  reflect.TypeOf(uint32(0)),
+	"uint64":  reflect.TypeOf(uint64(0)),
+	"float32": reflect.TypeOf(float32(0)),
+	"float64": reflect.TypeOf(float64(0)),
+	"time":    timeType,
+	"any":     reflect.TypeOf((*any)(nil)).Elem(),
+}
+
+func (r *Registry) RegisterFromSchema(name string, fields []SchemaField) error {
+	t, err := SchemaType(fields)
+	if err != nil {
+		return fmt.Errorf("invalid schema for %s: %w", name, err)
+	}
+	return r.Register(name, func() any { return reflect.New(t).Interface() })
+}
+
+func (r *Registry) MustRegisterFromSchema(name string, fields []SchemaField) {
+	if err := r.RegisterFromSchema(name, fields); err != nil {
+		panic(err)
+	}
+}
+
+func SchemaType(fields []SchemaField) (reflect.Type, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("schema has no fields")
+	}
+
+	seen := make(map[string]bool, len(fields))
+	structFields := make([]reflect.StructField, 0, len(fields))
+	for _, f := range fields {
+		if !token.IsIdentifier(f.Name) || !token.IsExported(f.Name) {
+			return nil, fmt.Errorf("field %q: name must be an exported Go identifier", f.Name)
+		}
+		if seen[f.Name] {
+			return nil, fmt.Errorf("field %s: duplicate field", f.Name)
+		}
+		seen[f.Name] = true
+
+		ft, err := parseSchemaType(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		structFields = append(structFields, reflect.StructField{
+			Name: f.Name,
+			Type: ft,
+			Tag:  reflect.StructTag(f.Tag),
+		})
+	}
+	return reflect.StructOf(structFields), nil
+}
+
+func parseSchemaType(name string) (reflect.Type, error) {
+	name = strings.TrimSpace(name)
+	if elem, ok := strings.CutPrefix(name, "[]"); ok {
+		t, err := parseSchemaType(elem)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(t), nil
+	}
+	if elem, ok := strings.CutPrefix(name, "map[string]"); ok {
+		t, err := parseSchemaType(elem)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.MapOf(schemaTypes["string"], t), nil
+	}
+	if t, ok := schemaTypes[name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("unsupported type %q", name)
+}
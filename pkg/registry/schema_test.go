@@ -0,0 +1,130 @@
+package registry
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterFromSchemaFieldKinds(t *testing.T) {
+	seen := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		typ   string
+		want  reflect.Type
+		value any
+	}{
+		{"string", reflect.TypeOf(""), "x"},
+		{"bool", reflect.TypeOf(false), "true"},
+		{"int", reflect.TypeOf(int(0)), "-7"},
+		{"int8", reflect.TypeOf(int8(0)), "-8"},
+		{"int16", reflect.TypeOf(int16(0)), "16"},
+		{"int32", reflect.TypeOf(int32(0)), "32"},
+		{"int64", reflect.TypeOf(int64(0)), "64"},
+		{"uint", reflect.TypeOf(uint(0)), "1"},
+		{"uint8", reflect.TypeOf(uint8(0)), "8"},
+		{"uint16", reflect.TypeOf(uint16(0)), "16"},
+		{"uint32", reflect.TypeOf(uint32(0)), "32"},
+		{"uint64", reflect.TypeOf(uint64(0)), "64"},
+		{"float32", reflect.TypeOf(float32(0)), "1.5"},
+		{"float64", reflect.TypeOf(float64(0)), "2.5"},
+		{"time", reflect.TypeOf(time.Time{}), seen},
+		{"any", reflect.TypeOf((*any)(nil)).Elem(), 42},
+		{" []string ", reflect.TypeOf([]string{}), []string{"a", "b"}},
+		{"[]int", reflect.TypeOf([]int{}), []any{"1", 2}},
+		{"map[string]string", reflect.TypeOf(map[string]string{}), map[string]any{"k": "v"}},
+		{"map[string][]uint8", reflect.TypeOf(map[string][]uint8{}), map[string]any{"k": []any{"1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			reg := New()
+			if err := reg.RegisterFromSchema("entity", []SchemaField{{Name: "Field", Type: tt.typ, Tag: `json:"field"`}}); err != nil {
+				t.Fatal(err)
+			}
+			info, err := reg.Get("entity")
+			if err != nil {
+				t.Fatal(err)
+			}
+			field := info.Type.Field(0)
+			if field.Type != tt.want {
+				t.Errorf("field type = %s, want %s", field.Type, tt.want)
+			}
+			if got := field.Tag.Get("json"); got != "field" {
+				t.Errorf("json tag = %q, want field", got)
+			}
+
+			obj := info.Factory()
+			if err := info.Setters["field"](obj, tt.value); err != nil {
+				t.Fatalf("set %v: %v", tt.value, err)
+			}
+			if got := info.Getters["Field"](obj); reflect.ValueOf(got).IsZero() {
+				t.Errorf("Field is still zero after setting %v", tt.value)
+			}
+		})
+	}
+}
+
+func TestRegisterFromSchemaLastSeen(t *testing.T) {
+	reg := New()
+	err := reg.RegisterFromSchema("host", []SchemaField{
+		{Name: "Name", Type: "string"},
+		{Name: "Seen", Type: "time", Tag: `tr069:"last_seen"`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := reg.Get("host")
+	if info.LastSeen == nil {
+		t.Fatal("last_seen field not wired")
+	}
+
+	obj := info.Factory()
+	now := time.Now()
+	info.LastSeen(obj, now)
+	if got := info.Getters["Seen"](obj); !got.(time.Time).Equal(now) {
+		t.Errorf("Seen = %v, want %v", got, now)
+	}
+}
+
+func TestRegisterFromSchemaInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []SchemaField
+		errMsg string
+	}{
+		{"no fields", nil, "schema has no fields"},
+		{"unexported name", []SchemaField{{Name: "name", Type: "string"}}, "exported Go identifier"},
+		{"invalid identifier", []SchemaField{{Name: "Mac-Address", Type: "string"}}, "exported Go identifier"},
+		{"empty name", []SchemaField{{Name: "", Type: "string"}}, "exported Go identifier"},
+		{"duplicate field", []SchemaField{{Name: "A", Type: "string"}, {Name: "A", Type: "int"}}, "duplicate field"},
+		{"unsupported type", []SchemaField{{Name: "A", Type: "complex128"}}, `unsupported type "complex128"`},
+		{"unsupported slice element", []SchemaField{{Name: "A", Type: "[]chan"}}, `unsupported type "chan"`},
+		{"non-string map key", []SchemaField{{Name: "A", Type: "map[int]string"}}, `unsupported type "map[int]string"`},
+		{"accumulate on string", []SchemaField{{Name: "A", Type: "string", Tag: `tr069:"accumulate"`}}, "accumulate requires a numeric field"},
+		{"last_seen on string", []SchemaField{{Name: "A", Type: "string", Tag: `tr069:"last_seen"`}}, "last_seen requires a time.Time field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := New()
+			err := reg.RegisterFromSchema("entity", tt.fields)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.errMsg)
+			}
+			if reg.Has("entity") {
+				t.Error("invalid schema was registered")
+			}
+		})
+	}
+}
+
+func TestRegisterFromSchemaDuplicateName(t *testing.T) {
+	reg := New()
+	fields := []SchemaField{{Name: "A", Type: "string"}}
+	reg.MustRegisterFromSchema("entity", fields)
+	if err := reg.RegisterFromSchema("entity", fields); err == nil {
+		t.Error("expected error registering the same name twice")
+	}
+}
@@ -0,0 +1,135 @@
+package router
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func benchRouter(patterns int) (*FastRouter, []string) {
+	r := New()
+	compiled := make([]*Pattern, 0, patterns)
+	for i := 0; i < patterns; i++ {
+		p := CompilePattern(fmt.Sprintf("Device.Vendor%d.Table.*.Param%d", i, i%7))
+		p.ID = fmt.Sprintf("rule-%d", i)
+		compiled = append(compiled, p)
+	}
+	r.AddPatterns(compiled)
+
+	rng := rand.New(rand.NewSource(1))
+	paths := make([]string, 10000)
+	for i := range paths {
+		n := rng.Intn(patterns)
+		paths[i] = fmt.Sprintf("Device.Vendor%d.Table.%d.Param%d", n, rng.Intn(64)+1, n%7)
+	}
+	return r, paths
+}
+
+func BenchmarkRoute(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("patterns=%d", n), func(b *testing.B) {
+			r, paths := benchRouter(n)
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, ok := r.Route(paths[i%len(paths)]); !ok {
+					b.Fatalf("no match for %s", paths[i%len(paths)])
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRouteWithCaptures(b *testing.B) {
+	r, paths := benchRouter(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, captures, ok := r.RouteWithCaptures(paths[i%len(paths)]); !ok || len(captures) != 1 {
+			b.Fatalf("no match for %s", paths[i%len(paths)])
+		}
+	}
+}
+
+func BenchmarkRouteParts(b *testing.B) {
+	r, paths := benchRouter(1000)
+	parts := make([][]string, len(paths))
+	for i, path := range paths {
+		parts[i] = splitPathFast(path)
+	}
+
+	b.Run("joined", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, ok := r.RouteParts(strings.Join(parts[i%len(parts)], "."), parts[i%len(parts)]); !ok {
+				b.Fatalf("no match for %s", paths[i%len(paths)])
+			}
+		}
+	})
+	b.Run("split", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, ok := r.RouteParts("", parts[i%len(parts)]); !ok {
+				b.Fatalf("no match for %s", paths[i%len(paths)])
+			}
+		}
+	})
+}
+
+func BenchmarkRouteWildcardFallback(b *testing.B) {
+	const patterns = 500
+
+	r := New()
+	compiled := make([]*Pattern, 0, patterns)
+	for i := 0; i < patterns; i++ {
+		p := CompilePattern(fmt.Sprintf("*.Vendor%d.Table.*", i))
+		p.ID = fmt.Sprintf("rule-%d", i)
+		compiled = append(compiled, p)
+	}
+	r.AddPatterns(compiled)
+
+	rng := rand.New(rand.NewSource(1))
+	paths := make([]string, 10000)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("Device.Vendor%d.Table.%d", rng.Intn(patterns), rng.Intn(64)+1)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, ok := r.Route(paths[i%len(paths)]); !ok {
+			b.Fatalf("no match for %s", paths[i%len(paths)])
+		}
+	}
+}
+
+func TestRoutePrefixMatchedLatency(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping latency guard in short mode")
+	}
+	if raceEnabled {
+		t.Skip("skipping latency guard under the race detector")
+	}
+
+	const limit = time.Microsecond
+
+	for _, n := range []int{10, 100, 1000} {
+		r, paths := benchRouter(n)
+		result := testing.Benchmark(func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				r.Route(paths[i%len(paths)])
+			}
+		})
+
+		perOp := time.Duration(result.NsPerOp())
+		t.Logf("patterns=%d: %v/op", n, perOp)
+		if perOp > limit {
+			t.Errorf("patterns=%d: routing took %v/op, want < %v", n, perOp, limit)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package router
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var fuzzSeeds = []string{
+	"",
+	".",
+	"...",
+	"*",
+	"**.*",
+	"[1-",
+	"*.",
+	".*",
+	"a.*.",
+	"Device.Hosts.Host.*.PhysAddress",
+	"InternetGatewayDevice.LANDevice.*.Hosts.*.MACAddress",
+	"Device.X_Vendor.*.Some\\.Name",
+	"Device.*\\",
+	"\\",
+	"a\\.*.b",
+	"a*b.*",
+}
+
+func FuzzSplitPathFast(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, path string) {
+		segments := splitSegments(path)
+		if joined := strings.Join(segments, "."); joined != path {
+			t.Fatalf("splitSegments(%q) = %q, rejoins to %q", path, segments, joined)
+		}
+
+		parts := splitPathFast(path)
+		want := len(segments)
+		if segments[len(segments)-1] == "" {
+			want--
+		}
+		if len(parts) != want {
+			t.Fatalf("splitPathFast(%q) = %q, want %d parts", path, parts, want)
+		}
+
+		p := &Pattern{Parts: parts}
+		if !matchPathParts(path, p, nil) {
+			t.Fatalf("matchPathParts(%q) rejects its own split %q", path, parts)
+		}
+		Captures(path, &Pattern{WildcardPos: []int{0, len(parts)}})
+	})
+}
+
+func FuzzCompilePattern(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed, "x")
+	}
+
+	f.Fuzz(func(t *testing.T, pattern, fill string) {
+		p := CompilePattern(pattern)
+		if p.OriginalPath != pattern {
+			t.Fatalf("OriginalPath = %q, want %q", p.OriginalPath, pattern)
+		}
+		if !strings.HasPrefix(pattern, p.Prefix) {
+			t.Fatalf("Prefix %q is not a prefix of %q", p.Prefix, pattern)
+		}
+		if !strings.HasSuffix(pattern, p.Suffix) {
+			t.Fatalf("Suffix %q is not a suffix of %q", p.Suffix, pattern)
+		}
+		if p.WildcardPos == nil {
+			r := New()
+			r.AddPattern(p)
+			if _, ok := r.Route(pattern + "Other"); ok {
+				t.Fatalf("exact pattern %q matched %q", pattern, pattern+"Other")
+			}
+			return
+		}
+
+		if len(p.Parts) != p.MinParts || len(p.Parts) != p.MaxParts {
+			t.Fatalf("Parts %q do not match MinParts %d / MaxParts %d", p.Parts, p.MinParts, p.MaxParts)
+		}
+		wildcards := 0
+		for i, part := range p.Parts {
+			if part == "*" {
+				if wildcards >= len(p.WildcardPos) || p.WildcardPos[wildcards] != i {
+					t.Fatalf("WildcardPos %v misses wildcard at %d in %q", p.WildcardPos, i, p.Parts)
+				}
+				wildcards++
+			}
+		}
+		if wildcards != len(p.WildcardPos) {
+			t.Fatalf("WildcardPos %v lists non-wildcard parts of %q", p.WildcardPos, p.Parts)
+		}
+
+		if fill == "" || strings.ContainsAny(fill, ".\\*") {
+			return
+		}
+		concrete := splitSegments(pattern)
+		for i, part := range concrete {
+			if part == "*" {
+				concrete[i] = fill
+			}
+		}
+		path := strings.Join(concrete, ".")
+
+		r := New()
+		r.AddPattern(p)
+		got, ok := r.Route(path)
+		if !ok || got != p {
+			t.Fatalf("Route(%q) did not match pattern %q", path, pattern)
+		}
+		captures := Captures(path, p)
+		if len(captures) != len(p.WildcardPos) {
+			t.Fatalf("Captures(%q) = %q, want %d captures", path, captures, len(p.WildcardPos))
+		}
+		if _, walked, _ := r.RouteWithCaptures(path); !reflect.DeepEqual(walked, captures) {
+			t.Fatalf("RouteWithCaptures(%q) = %q, Captures = %q", path, walked, captures)
+		}
+	})
+}
@@ -0,0 +1,232 @@
+package router
+
+import "strings"
+
+type segmentNode struct {
+	children map[string]*segmentNode
+	pattern  *Pattern
+}
+
+func (n *segmentNode) insert(path string, p *Pattern) {
+	node := n
+	for _, segment := range splitSegments(path) {
+		segment = UnescapeSegment(segment)
+		next, ok := node.children[segment]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[string]*segmentNode)
+			}
+			next = &segmentNode{}
+			node.children[segment] = next
+		}
+		node = next
+	}
+	node.pattern = p
+}
+
+func (n *segmentNode) remove(path string, p *Pattern) {
+	node := n
+	for _, segment := range splitSegments(path) {
+		if node = node.children[UnescapeSegment(segment)]; node == nil {
+			return
+		}
+	}
+	if node.pattern == p {
+		node.pattern = nil
+	}
+}
+
+func (n *segmentNode) find(parts []string) *Pattern {
+	node := n
+	for _, part := range parts {
+		if node = node.children[part]; node == nil {
+			return nil
+		}
+	}
+	return node.pattern
+}
+
+func JoinParts(parts []string) string {
+	size := len(parts)
+	for _, part := range parts {
+		size += len(part)
+	}
+	var sb strings.Builder
+	sb.Grow(size)
+	for n, part := range parts {
+		if n > 0 {
+			sb.WriteByte('.')
+		}
+		if strings.IndexAny(part, ".\\") < 0 {
+			sb.WriteString(part)
+			continue
+		}
+		for i := 0; i < len(part); i++ {
+			if c := part[i]; c == '.' || c == '\\' {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(part[i])
+		}
+	}
+	return sb.String()
+}
+
+func appendSegment(b []byte, segment string) []byte {
+	for i := 0; i < len(segment); i++ {
+		if c := segment[i]; c == '.' || c == '\\' {
+			b = append(b, '\\')
+		}
+		b = append(b, segment[i])
+	}
+	return b
+}
+
+func partsHasPrefix(parts []string, prefix string) bool {
+	i := 0
+	for n, part := range parts {
+		if n > 0 {
+			if i == len(prefix) {
+				return true
+			}
+			if prefix[i] != '.' {
+				return false
+			}
+			i++
+		}
+		for j := 0; j < len(part); j++ {
+			c := part[j]
+			if c == '.' || c == '\\' {
+				if i == len(prefix) {
+					return true
+				}
+				if prefix[i] != '\\' {
+					return false
+				}
+				i++
+			}
+			if i == len(prefix) {
+				return true
+			}
+			if prefix[i] != c {
+				return false
+			}
+			i++
+		}
+	}
+	return i == len(prefix)
+}
+
+func partsHasSuffix(parts []string, suffix string) bool {
+	i := len(suffix)
+	for n := len(parts) - 1; n >= 0; n-- {
+		part := parts[n]
+		for j := len(part) - 1; j >= 0; j-- {
+			c := part[j]
+			if i == 0 {
+				return true
+			}
+			if suffix[i-1] != c {
+				return false
+			}
+			i--
+			if c == '.' || c == '\\' {
+				if i == 0 {
+					return true
+				}
+				if suffix[i-1] != '\\' {
+					return false
+				}
+				i--
+			}
+		}
+		if n > 0 {
+			if i == 0 {
+				return true
+			}
+			if suffix[i-1] != '.' {
+				return false
+			}
+			i--
+		}
+	}
+	return i == 0
+}
+
+func segmentEquals(escaped, raw string) bool {
+	if strings.IndexByte(escaped, '\\') < 0 {
+		return escaped == raw
+	}
+	j := 0
+	for i := 0; i < len(escaped); i++ {
+		if escaped[i] == '\\' && i+1 < len(escaped) {
+			i++
+		}
+		if j == len(raw) || raw[j] != escaped[i] {
+			return false
+		}
+		j++
+	}
+	return j == len(raw)
+}
+
+type lazyPath struct {
+	parts []string
+	path  string
+	done  bool
+}
+
+func (l *lazyPath) String() string {
+	if !l.done {
+		l.path = JoinParts(l.parts)
+		l.done = true
+	}
+	return l.path
+}
+
+func (r *FastRouter) routeParts(parts []string) (*Pattern, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if pattern := r.exactParts.find(parts); pattern != nil {
+		return pattern, true
+	}
+
+	joined := &lazyPath{parts: parts}
+	if p := r.prefixTree.FindParts(parts, func(p *Pattern) bool {
+		return r.matchPatternParts(parts, joined, p)
+	}); p != nil {
+		return p, true
+	}
+
+	if n := len(parts); n > 1 && (n > 2 || parts[0] != "") {
+		var buf [64]byte
+		suffix := appendSegment(append(buf[:0], '.'), parts[n-1])
+		for _, p := range r.suffixIndex[string(suffix)] {
+			if r.matchPatternParts(parts, joined, p) {
+				return p, true
+			}
+		}
+	}
+
+	for _, p := range r.patterns {
+		if r.matchPatternParts(parts, joined, p) {
+			return p, true
+		}
+	}
+
+	return nil, false
+}
+
+func (r *FastRouter) matchPatternParts(parts []string, joined *lazyPath, p *Pattern) bool {
+	if len(p.Parts) == 0 || len(p.Contains) > 0 {
+		path := joined.String()
+		return r.matchPatternFast(path, nil, unsafeStringToBytes(path), len(path), p, nil)
+	}
+	if p.Prefix != "" && !partsHasPrefix(parts, p.Prefix) {
+		return false
+	}
+	if p.Suffix != "" && !partsHasSuffix(parts, p.Suffix) {
+		return false
+	}
+	return matchParts(parts, p)
+}
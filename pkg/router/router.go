@@ -7,22 +7,41 @@ import (
 )
 
 type Pattern struct {
-	ID           string
-	OriginalPath string
-	Prefix       string
-	Suffix       string
-	Contains     []string
-	Parts        []string
-	MinParts     int
-	MaxParts     int
-	WildcardPos  []int
-	Entity       string
-	Field        string
-	Priority     int
+	ID              string
+	OriginalPath    string
+	Prefix          string
+	Suffix          string
+	Contains        []string
+	OrderedContains bool
+	Parts           []string
+	MinParts        int
+	MaxParts        int
+	WildcardPos     []int
+	Entity          string
+	Field           string
+	Priority        int
+}
+
+type Router interface {
+	AddPattern(p *Pattern)
+	Route(path string) (*Pattern, bool)
+}
+
+type BatchRouter interface {
+	AddPatterns(patterns []*Pattern)
+}
+
+type RemovableRouter interface {
+	RemovePattern(p *Pattern)
+}
+
+type PartsRouter interface {
+	RouteParts(path string, parts []string) (*Pattern, bool)
 }
 
 type FastRouter struct {
 	exactMatches map[string]*Pattern
+	exactParts   segmentNode
 	prefixTree   *Trie
 	suffixIndex  map[string][]*Pattern
 	patterns     []*Pattern
@@ -42,8 +61,22 @@ func (r *FastRouter) AddPattern(p *Pattern) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if p.Prefix != "" && p.WildcardPos == nil {
+	r.addPatternLocked(p)
+}
+
+func (r *FastRouter) AddPatterns(patterns []*Pattern) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range patterns {
+		r.addPatternLocked(p)
+	}
+}
+
+func (r *FastRouter) addPatternLocked(p *Pattern) {
+	if p.WildcardPos == nil && (p.Prefix != "" || isEmptyPattern(p)) {
 		r.exactMatches[p.OriginalPath] = p
+		r.exactParts.insert(p.OriginalPath, p)
 		return
 	}
 
@@ -58,7 +91,51 @@ func (r *FastRouter) AddPattern(p *Pattern) {
 	r.patterns = append(r.patterns, p)
 }
 
+func (r *FastRouter) RemovePattern(p *Pattern) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.exactMatches[p.OriginalPath]; ok && existing == p {
+		delete(r.exactMatches, p.OriginalPath)
+		r.exactParts.remove(p.OriginalPath, p)
+	}
+
+	if p.Prefix != "" && len(p.WildcardPos) > 0 {
+		r.prefixTree.Remove(p.Prefix, p)
+	}
+
+	if p.Suffix != "" {
+		if patterns := withoutPattern(r.suffixIndex[p.Suffix], p); len(patterns) > 0 {
+			r.suffixIndex[p.Suffix] = patterns
+		} else {
+			delete(r.suffixIndex, p.Suffix)
+		}
+	}
+
+	r.patterns = withoutPattern(r.patterns, p)
+}
+
+func withoutPattern(patterns []*Pattern, p *Pattern) []*Pattern {
+	for i, existing := range patterns {
+		if existing == p {
+			return append(patterns[:i:i], patterns[i+1:]...)
+		}
+	}
+	return patterns
+}
+
 func (r *FastRouter) Route(path string) (*Pattern, bool) {
+	return r.route(path, nil, nil)
+}
+
+func (r *FastRouter) RouteParts(path string, parts []string) (*Pattern, bool) {
+	if path == "" && len(parts) > 0 {
+		return r.routeParts(parts)
+	}
+	return r.route(path, parts, nil)
+}
+
+func (r *FastRouter) route(path string, parts []string, captures *[]string) (*Pattern, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -69,20 +146,18 @@ func (r *FastRouter) Route(path string) (*Pattern, bool) {
 	pathLen := len(path)
 	pathBytes := unsafeStringToBytes(path)
 
-	if patterns := r.prefixTree.Search(path); len(patterns) > 0 {
-		for _, p := range patterns {
-			if r.matchPatternFast(pathBytes, pathLen, p) {
-				return p, true
-			}
-		}
+	if p := r.prefixTree.Find(path, func(p *Pattern) bool {
+		return r.matchPatternFast(path, parts, pathBytes, pathLen, p, captures)
+	}); p != nil {
+		return p, true
 	}
 
-	lastDot := strings.LastIndexByte(path, '.')
+	lastDot := lastSeparator(path)
 	if lastDot > 0 {
 		suffix := path[lastDot:]
 		if patterns, ok := r.suffixIndex[suffix]; ok {
 			for _, p := range patterns {
-				if r.matchPatternFast(pathBytes, pathLen, p) {
+				if r.matchPatternFast(path, parts, pathBytes, pathLen, p, captures) {
 					return p, true
 				}
 			}
@@ -90,7 +165,7 @@ func (r *FastRouter) Route(path string) (*Pattern, bool) {
 	}
 
 	for _, p := range r.patterns {
-		if r.matchPatternFast(pathBytes, pathLen, p) {
+		if r.matchPatternFast(path, parts, pathBytes, pathLen, p, captures) {
 			return p, true
 		}
 	}
@@ -98,7 +173,68 @@ func (r *FastRouter) Route(path string) (*Pattern, bool) {
 	return nil, false
 }
 
-func (r *FastRouter) matchPatternFast(pathBytes []byte, pathLen int, p *Pattern) bool {
+func (r *FastRouter) RouteExact(path string) (*Pattern, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pattern, ok := r.exactMatches[path]
+	return pattern, ok
+}
+
+func (r *FastRouter) RouteWithCaptures(path string) (*Pattern, []string, bool) {
+	var captures []string
+	pattern, ok := r.route(path, nil, &captures)
+	if !ok {
+		return nil, nil, false
+	}
+	if len(pattern.WildcardPos) == 0 {
+		return pattern, nil, true
+	}
+	return pattern, captures, true
+}
+
+func PartsCaptures(parts []string, p *Pattern) []string {
+	if len(p.WildcardPos) == 0 {
+		return nil
+	}
+
+	captures := make([]string, 0, len(p.WildcardPos))
+	for _, pos := range p.WildcardPos {
+		if pos < len(parts) {
+			captures = append(captures, parts[pos])
+		}
+	}
+	return captures
+}
+
+func Captures(path string, p *Pattern) []string {
+	if len(p.WildcardPos) == 0 {
+		return nil
+	}
+
+	captures := make([]string, 0, len(p.WildcardPos))
+	next := 0
+	part := 0
+	start := 0
+	for i := 0; i <= len(path) && next < len(p.WildcardPos); i++ {
+		if i < len(path) && path[i] == '\\' {
+			i++
+			continue
+		}
+		if i < len(path) && path[i] != '.' {
+			continue
+		}
+		if part == p.WildcardPos[next] {
+			captures = append(captures, path[start:i])
+			next++
+		}
+		part++
+		start = i + 1
+	}
+	return captures
+}
+
+func (r *FastRouter) matchPatternFast(path string, parts []string, pathBytes []byte, pathLen int, p *Pattern, captures *[]string) bool {
 	if p.Prefix != "" {
 		prefixLen := len(p.Prefix)
 		if pathLen < prefixLen || !bytesHasPrefix(pathBytes, p.Prefix) {
@@ -114,15 +250,27 @@ func (r *FastRouter) matchPatternFast(pathBytes []byte, pathLen int, p *Pattern)
 	}
 
 	if len(p.Contains) > 0 {
+		from := 0
 		for _, contains := range p.Contains {
-			if !bytesContains(pathBytes, pathLen, contains) {
+			if !p.OrderedContains {
+				if !bytesContains(pathBytes, pathLen, contains) {
+					return false
+				}
+				continue
+			}
+			i := bytesIndexFrom(pathBytes, pathLen, from, contains)
+			if i < 0 {
 				return false
 			}
+			from = i + len(contains)
 		}
 	}
 
 	if len(p.Parts) > 0 {
-		return r.matchParts(string(pathBytes[:pathLen]), p)
+		if parts == nil {
+			return matchPathParts(path, p, captures)
+		}
+		return matchParts(parts, p)
 	}
 
 	if p.MinParts > 0 || p.MaxParts > 0 {
@@ -138,15 +286,16 @@ func (r *FastRouter) matchPatternFast(pathBytes []byte, pathLen int, p *Pattern)
 	return true
 }
 
-func (r *FastRouter) matchParts(path string, p *Pattern) bool {
-	parts := splitPathFast(path)
-
+func matchParts(parts []string, p *Pattern) bool {
+	if n := len(parts); n > 0 && parts[n-1] == "" {
+		parts = parts[:n-1]
+	}
 	if len(parts) != len(p.Parts) {
 		return false
 	}
 
 	for i, expectedPart := range p.Parts {
-		if expectedPart != "*" && expectedPart != parts[i] {
+		if expectedPart != "*" && !segmentEquals(expectedPart, parts[i]) {
 			return false
 		}
 	}
@@ -154,6 +303,55 @@ func (r *FastRouter) matchParts(path string, p *Pattern) bool {
 	return true
 }
 
+func matchPathParts(path string, p *Pattern, captures *[]string) bool {
+	if captures != nil {
+		*captures = (*captures)[:0]
+	}
+	part := 0
+	start := 0
+	for i := 0; ; i++ {
+		atEnd := i >= len(path)
+		if !atEnd {
+			if path[i] == '\\' {
+				i++
+				continue
+			}
+			if path[i] != '.' {
+				continue
+			}
+		}
+
+		end := min(i, len(path))
+		segment := path[start:end]
+		if atEnd && segment == "" {
+			break
+		}
+		if part >= len(p.Parts) {
+			return false
+		}
+		expected := p.Parts[part]
+		if expected == "*" {
+			if captures != nil {
+				*captures = append(*captures, segment)
+			}
+		} else if expected != segment {
+			return false
+		}
+		part++
+		start = end + 1
+
+		if atEnd {
+			break
+		}
+	}
+	return part == len(p.Parts)
+}
+
+func isEmptyPattern(p *Pattern) bool {
+	return p.OriginalPath == "" && p.Suffix == "" && len(p.Contains) == 0 &&
+		len(p.Parts) == 0 && p.MinParts == 0 && p.MaxParts == 0
+}
+
 func CompilePattern(path string) *Pattern {
 	p := &Pattern{
 		OriginalPath: path,
@@ -165,7 +363,11 @@ func CompilePattern(path string) *Pattern {
 		return p
 	}
 
-	parts := strings.Split(path, ".")
+	parts := splitSegments(path)
+	trailingDot := parts[len(parts)-1] == ""
+	if trailingDot {
+		parts = parts[:len(parts)-1]
+	}
 	p.Parts = parts
 	p.MinParts = len(parts)
 	p.MaxParts = len(parts)
@@ -201,14 +403,28 @@ func CompilePattern(path string) *Pattern {
 	if lastWildcard >= 0 && lastWildcard < len(parts)-1 {
 		p.Suffix = "." + strings.Join(parts[lastWildcard+1:], ".")
 	}
+	if trailingDot {
+		p.Suffix += "."
+	}
 
 	return p
 }
 
 func splitPathFast(path string) []string {
+	parts := splitSegments(path)
+	if len(parts) > 0 && parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+	return parts
+}
+
+func splitSegments(path string) []string {
 	n := 1
 	for i := 0; i < len(path); i++ {
-		if path[i] == '.' {
+		switch path[i] {
+		case '\\':
+			i++
+		case '.':
 			n++
 		}
 	}
@@ -216,15 +432,44 @@ func splitPathFast(path string) []string {
 	parts := make([]string, 0, n)
 	start := 0
 	for i := 0; i < len(path); i++ {
-		if path[i] == '.' {
+		switch path[i] {
+		case '\\':
+			i++
+		case '.':
 			parts = append(parts, path[start:i])
 			start = i + 1
 		}
 	}
-	if start < len(path) {
-		parts = append(parts, path[start:])
+	return append(parts, path[start:])
+}
+
+func lastSeparator(path string) int {
+	last := -1
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case '.':
+			last = i
+		}
 	}
-	return parts
+	return last
+}
+
+func UnescapeSegment(segment string) string {
+	if strings.IndexByte(segment, '\\') < 0 {
+		return segment
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(segment))
+	for i := 0; i < len(segment); i++ {
+		if segment[i] == '\\' && i+1 < len(segment) {
+			i++
+		}
+		sb.WriteByte(segment[i])
+	}
+	return sb.String()
 }
 
 func bytesHasPrefix(b []byte, prefix string) bool {
@@ -254,16 +499,20 @@ func bytesHasSuffix(b []byte, bLen int, suffix string) bool {
 }
 
 func bytesContains(b []byte, bLen int, substr string) bool {
+	return bytesIndexFrom(b, bLen, 0, substr) >= 0
+}
+
+func bytesIndexFrom(b []byte, bLen, from int, substr string) int {
 	subLen := len(substr)
-	if bLen < subLen {
-		return false
+	if bLen-from < subLen {
+		return -1
 	}
 	if subLen == 0 {
-		return true
+		return from
 	}
 
 	first := substr[0]
-	for i := 0; i <= bLen-subLen; i++ {
+	for i := from; i <= bLen-subLen; i++ {
 		if b[i] != first {
 			continue
 		}
@@ -275,10 +524,10 @@ func bytesContains(b []byte, bLen int, substr string) bool {
 			}
 		}
 		if match {
-			return true
+			return i
 		}
 	}
-	return false
+	return -1
 }
 
 func countDots(b []byte, length int) int {
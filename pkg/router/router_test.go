@@ -0,0 +1,335 @@
+package router
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitPathEscapedDots(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"Device.X_Vendor.Some\\.Name", []string{"Device", "X_Vendor", "Some\\.Name"}},
+		{"Device.X_Vendor.Some\\.Name.", []string{"Device", "X_Vendor", "Some\\.Name"}},
+		{"Device.X_Vendor.1.Some\\.Name", []string{"Device", "X_Vendor", "1", "Some\\.Name"}},
+		{"Device.Hosts.Host.1.HostName", []string{"Device", "Hosts", "Host", "1", "HostName"}},
+	}
+
+	for _, tt := range tests {
+		if got := splitPathFast(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitPathFast(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCompilePatternEscapedDots(t *testing.T) {
+	p := CompilePattern("Device.X_Vendor.*.Some\\.Name")
+
+	wantParts := []string{"Device", "X_Vendor", "*", "Some\\.Name"}
+	if !reflect.DeepEqual(p.Parts, wantParts) {
+		t.Fatalf("Parts = %q, want %q", p.Parts, wantParts)
+	}
+	if p.Prefix != "Device.X_Vendor." {
+		t.Errorf("Prefix = %q, want %q", p.Prefix, "Device.X_Vendor.")
+	}
+	if p.Suffix != ".Some\\.Name" {
+		t.Errorf("Suffix = %q, want %q", p.Suffix, ".Some\\.Name")
+	}
+	if !reflect.DeepEqual(p.WildcardPos, []int{2}) {
+		t.Errorf("WildcardPos = %v, want [2]", p.WildcardPos)
+	}
+}
+
+func TestRouteEscapedDots(t *testing.T) {
+	r := New()
+	escaped := CompilePattern("Device.X_Vendor.*.Some\\.Name")
+	escaped.ID = "escaped"
+	plain := CompilePattern("Device.X_Vendor.*.Some.Name")
+	plain.ID = "plain"
+	r.AddPatterns([]*Pattern{escaped, plain})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"Device.X_Vendor.1.Some\\.Name", "escaped"},
+		{"Device.X_Vendor.1.Some.Name", "plain"},
+	}
+
+	for _, tt := range tests {
+		p, captures, ok := r.RouteWithCaptures(tt.path)
+		if !ok {
+			t.Fatalf("Route(%q) did not match", tt.path)
+		}
+		if p.ID != tt.want {
+			t.Errorf("Route(%q) = %s, want %s", tt.path, p.ID, tt.want)
+		}
+		if !reflect.DeepEqual(captures, []string{"1"}) {
+			t.Errorf("captures for %q = %q, want [1]", tt.path, captures)
+		}
+	}
+
+	if _, ok := r.Route("Device.X_Vendor.1.Some\\.Other"); ok {
+		t.Error("unexpected match for Device.X_Vendor.1.Some\\.Other")
+	}
+}
+
+func TestRouteWithCaptures(t *testing.T) {
+	r := New()
+	for id, path := range map[string]string{
+		"radio_ssid":  "Device.WiFi.*.*.SSID",
+		"radio_other": "Device.WiFi.Radio.*.Channel",
+		"host":        "Device.Hosts.Host.*.HostName",
+		"ap_security": "Device.WiFi.AccessPoint.*.Security.*",
+		"trailing":    "Device.IP.Interface.*.",
+		"info":        "Device.DeviceInfo.SerialNumber",
+	} {
+		p := CompilePattern(path)
+		p.ID = id
+		r.AddPattern(p)
+	}
+
+	tests := []struct {
+		path     string
+		want     string
+		captures []string
+	}{
+		{"Device.WiFi.Radio.2.SSID", "radio_ssid", []string{"Radio", "2"}},
+		{"Device.WiFi.Radio.2.Channel", "radio_other", []string{"2"}},
+		{"Device.Hosts.Host.17.HostName", "host", []string{"17"}},
+		{"Device.WiFi.AccessPoint.1.Security.KeyPassphrase", "ap_security", []string{"1", "KeyPassphrase"}},
+		{"Device.IP.Interface.3.", "trailing", []string{"3"}},
+		{"Device.Hosts.Host.eth\\.0.HostName", "host", []string{"eth\\.0"}},
+		{"Device.DeviceInfo.SerialNumber", "info", nil},
+	}
+	for _, tt := range tests {
+		p, captures, ok := r.RouteWithCaptures(tt.path)
+		if !ok {
+			t.Errorf("RouteWithCaptures(%q) did not match", tt.path)
+			continue
+		}
+		if p.ID != tt.want {
+			t.Errorf("RouteWithCaptures(%q) = %s, want %s", tt.path, p.ID, tt.want)
+		}
+		if !reflect.DeepEqual(captures, tt.captures) {
+			t.Errorf("captures for %q = %q, want %q", tt.path, captures, tt.captures)
+		}
+		if want := Captures(tt.path, p); !reflect.DeepEqual(captures, want) {
+			t.Errorf("captures for %q = %q, Captures = %q", tt.path, captures, want)
+		}
+	}
+
+	if p, captures, ok := r.RouteWithCaptures("Device.WiFi.Radio.2.Unknown"); ok || p != nil || captures != nil {
+		t.Errorf("unmatched path returned %v, %q, %v", p, captures, ok)
+	}
+}
+
+func TestCapturesEscapedWildcardSegment(t *testing.T) {
+	p := CompilePattern("Device.X_Vendor.*.Value")
+	got := Captures("Device.X_Vendor.eth\\.0.Value", p)
+	if !reflect.DeepEqual(got, []string{"eth\\.0"}) {
+		t.Fatalf("Captures = %q, want [eth\\.0]", got)
+	}
+	if seg := UnescapeSegment(got[0]); seg != "eth.0" {
+		t.Errorf("UnescapeSegment = %q, want eth.0", seg)
+	}
+}
+
+func TestRouteExact(t *testing.T) {
+	r := New()
+	exact := CompilePattern("Device.DeviceInfo.SerialNumber")
+	wildcard := CompilePattern("Device.Hosts.Host.*.HostName")
+	r.AddPatterns([]*Pattern{exact, wildcard})
+
+	if p, ok := r.RouteExact("Device.DeviceInfo.SerialNumber"); !ok || p != exact {
+		t.Errorf("RouteExact(exact) = %v, %v; want exact pattern", p, ok)
+	}
+	if _, ok := r.RouteExact("Device.Hosts.Host.1.HostName"); ok {
+		t.Error("RouteExact matched a wildcard pattern")
+	}
+	if p, ok := r.Route("Device.Hosts.Host.1.HostName"); !ok || p != wildcard {
+		t.Errorf("Route(wildcard) = %v, %v; want wildcard pattern", p, ok)
+	}
+}
+
+func TestRemovePattern(t *testing.T) {
+	r := New()
+	exact := CompilePattern("Device.DeviceInfo.SerialNumber")
+	host := CompilePattern("Device.Hosts.Host.*.HostName")
+	other := CompilePattern("Device.Hosts.Host.*.IPAddress")
+	generic := CompilePattern("Device.*.Host.*.HostName")
+	r.AddPatterns([]*Pattern{exact, host, other, generic})
+
+	r.RemovePattern(exact)
+	r.RemovePattern(host)
+
+	if _, ok := r.Route("Device.DeviceInfo.SerialNumber"); ok {
+		t.Error("removed exact pattern still routes")
+	}
+	if p, ok := r.Route("Device.Hosts.Host.1.HostName"); !ok || p != generic {
+		t.Errorf("Route(HostName) = %v, %v; want the remaining generic pattern", p, ok)
+	}
+	if p, ok := r.Route("Device.Hosts.Host.1.IPAddress"); !ok || p != other {
+		t.Errorf("Route(IPAddress) = %v, %v; want the untouched sibling pattern", p, ok)
+	}
+
+	r.RemovePattern(host)
+	r.RemovePattern(generic)
+	r.AddPattern(host)
+	if p, ok := r.Route("Device.Hosts.Host.1.HostName"); !ok || p != host {
+		t.Errorf("Route after re-adding = %v, %v; want host pattern", p, ok)
+	}
+}
+
+func TestRouteParts(t *testing.T) {
+	r := New()
+	p := CompilePattern("Device.Hosts.Host.*.HostName")
+	r.AddPattern(p)
+
+	path := "Device.Hosts.Host.4.HostName"
+	parts := []string{"Device", "Hosts", "Host", "4", "HostName"}
+	if got, ok := r.RouteParts(path, parts); !ok || got != p {
+		t.Fatalf("RouteParts = %v, %v; want pattern", got, ok)
+	}
+	if got := PartsCaptures(parts, p); !reflect.DeepEqual(got, Captures(path, p)) {
+		t.Errorf("PartsCaptures = %q, want %q", got, Captures(path, p))
+	}
+	if _, ok := r.RouteParts("Device.Hosts.Host.4.Extra.HostName", []string{"Device", "Hosts", "Host", "4", "Extra", "HostName"}); ok {
+		t.Error("RouteParts matched a path with a different segment count")
+	}
+}
+
+func TestRoutePartsWithoutPath(t *testing.T) {
+	r := New()
+	for _, pattern := range []string{
+		"Device.Hosts.Host.*.HostName",
+		"Device.Hosts.Host.*.",
+		"Device.X_Vendor.*.Some\\.Name",
+		"Device.DeviceInfo.SerialNumber",
+		"Device.X_Vendor.Odd\\.Leaf",
+		"*.Stats.*",
+	} {
+		p := CompilePattern(pattern)
+		p.ID = pattern
+		r.AddPattern(p)
+	}
+	r.AddPattern(&Pattern{ID: "contains", Contains: []string{"WANDevice"}})
+
+	for _, parts := range [][]string{
+		{"Device", "Hosts", "Host", "4", "HostName"},
+		{"Device", "Hosts", "Host", "4", ""},
+		{"Device", "Hosts", "Host", "4"},
+		{"Device", "X_Vendor", "2", "Some.Name"},
+		{"Device", "X_Vendor", "2", "Some", "Name"},
+		{"Device", "DeviceInfo", "SerialNumber"},
+		{"Device", "X_Vendor", "Odd.Leaf"},
+		{"Device", "Stats", "Bytes"},
+		{"InternetGatewayDevice", "WANDevice", "1", "Enable"},
+		{"Device", "Unknown"},
+	} {
+		path := JoinParts(parts)
+		want, wantOK := r.Route(path)
+		got, ok := r.RouteParts("", parts)
+		if got != want || ok != wantOK {
+			t.Errorf("RouteParts(%q) = %v, %v; Route(%q) = %v, %v", parts, got, ok, path, want, wantOK)
+		}
+	}
+
+	parts := []string{"Device", "Hosts", "Host", "4", "HostName"}
+	if allocs := testing.AllocsPerRun(100, func() { r.RouteParts("", parts) }); allocs != 0 {
+		t.Errorf("RouteParts allocated %v times per call", allocs)
+	}
+}
+
+func TestJoinParts(t *testing.T) {
+	parts := []string{"Device", "X_Vendor", "Some.Name", "back\\slash", ""}
+	path := JoinParts(parts)
+	if want := "Device.X_Vendor.Some\\.Name.back\\\\slash."; path != want {
+		t.Fatalf("JoinParts = %q, want %q", path, want)
+	}
+	split := splitSegments(path)
+	for i := range split {
+		split[i] = UnescapeSegment(split[i])
+	}
+	if !reflect.DeepEqual(split, parts) {
+		t.Errorf("split(JoinParts) = %q, want %q", split, parts)
+	}
+}
+
+func TestMatchPathPartsAgreesWithSplit(t *testing.T) {
+	patterns := []string{
+		"Device.Hosts.Host.*.HostName",
+		"*.Vendor.*",
+		"Device.X_Vendor.*.Some\\.Name",
+		"Device.*.",
+	}
+	paths := []string{
+		"Device.Hosts.Host.1.HostName",
+		"Device.Hosts.Host.1.HostName.",
+		"Device.Hosts.Host..HostName",
+		"Device.Hosts.Host.1",
+		"Device.Vendor.7",
+		"Device.Vendor.7.Extra",
+		"Device.X_Vendor.3.Some\\.Name",
+		"Device.X_Vendor.3.Some.Name",
+		"Device.Hosts",
+		"Device.Hosts.",
+		"",
+		"Device\\",
+	}
+
+	for _, pattern := range patterns {
+		p := CompilePattern(pattern)
+		for _, path := range paths {
+			parts := splitPathFast(path)
+			for i := range parts {
+				parts[i] = UnescapeSegment(parts[i])
+			}
+			want := matchParts(parts, p)
+			if got := matchPathParts(path, p, nil); got != want {
+				t.Errorf("matchPathParts(%q, %q) = %v, want %v", path, pattern, got, want)
+			}
+		}
+	}
+}
+
+func TestRouteOrderedContains(t *testing.T) {
+	contains := []string{"WANDevice", "WANPPPConnection"}
+	paths := []struct {
+		path      string
+		unordered bool
+		ordered   bool
+	}{
+		{"InternetGatewayDevice.WANDevice.1.WANConnectionDevice.1.WANPPPConnection.1.Enable", true, true},
+		{"X_Vendor.WANPPPConnection.1.WANDevice.1.Enable", true, false},
+		{"InternetGatewayDevice.WANDevice.1.Enable", false, false},
+	}
+
+	for _, ordered := range []bool{false, true} {
+		r := New()
+		r.AddPattern(&Pattern{ID: "wan", Contains: contains, OrderedContains: ordered})
+
+		for _, tt := range paths {
+			want := tt.unordered
+			if ordered {
+				want = tt.ordered
+			}
+			if _, ok := r.Route(tt.path); ok != want {
+				t.Errorf("ordered=%v Route(%q) = %v, want %v", ordered, tt.path, ok, want)
+			}
+		}
+	}
+}
+
+func TestOrderedContainsDoesNotOverlap(t *testing.T) {
+	r := New()
+	r.AddPattern(&Pattern{ID: "aa", Contains: []string{"aa", "aa"}, OrderedContains: true})
+
+	if _, ok := r.Route("x.aa.y"); ok {
+		t.Error("one occurrence satisfied two ordered substrings")
+	}
+	if _, ok := r.Route("x.aa.aa"); !ok {
+		t.Error("two occurrences did not match")
+	}
+}
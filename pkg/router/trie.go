@@ -5,11 +5,21 @@ import (
 )
 
 type TrieNode struct {
-	children map[byte]*TrieNode
+	labels   []byte
+	children []*TrieNode
 	patterns []*Pattern
 	isEnd    bool
 }
 
+func (n *TrieNode) child(c byte) *TrieNode {
+	for i, label := range n.labels {
+		if label == c {
+			return n.children[i]
+		}
+	}
+	return nil
+}
+
 type Trie struct {
 	root *TrieNode
 	mu   sync.RWMutex
@@ -18,7 +28,6 @@ type Trie struct {
 func NewTrie() *Trie {
 	return &Trie{
 		root: &TrieNode{
-			children: make(map[byte]*TrieNode),
 			patterns: make([]*Pattern, 0),
 		},
 	}
@@ -31,18 +40,34 @@ func (t *Trie) Insert(prefix string, pattern *Pattern) {
 	node := t.root
 	for i := 0; i < len(prefix); i++ {
 		char := prefix[i]
-		if _, ok := node.children[char]; !ok {
-			node.children[char] = &TrieNode{
-				children: make(map[byte]*TrieNode),
+		next := node.child(char)
+		if next == nil {
+			next = &TrieNode{
 				patterns: make([]*Pattern, 0),
 			}
+			node.labels = append(node.labels, char)
+			node.children = append(node.children, next)
 		}
-		node = node.children[char]
+		node = next
 	}
 	node.isEnd = true
 	node.patterns = append(node.patterns, pattern)
 }
 
+func (t *Trie) Remove(prefix string, pattern *Pattern) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	node := t.root
+	for i := 0; i < len(prefix); i++ {
+		if node = node.child(prefix[i]); node == nil {
+			return
+		}
+	}
+	node.patterns = withoutPattern(node.patterns, pattern)
+	node.isEnd = len(node.patterns) > 0
+}
+
 func (t *Trie) Search(path string) []*Pattern {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
@@ -57,8 +82,8 @@ func (t *Trie) Search(path string) []*Pattern {
 			results = append(results, node.patterns...)
 		}
 
-		child, ok := node.children[char]
-		if !ok {
+		child := node.child(char)
+		if child == nil {
 			break
 		}
 		node = child
@@ -71,6 +96,75 @@ func (t *Trie) Search(path string) []*Pattern {
 	return results
 }
 
+func (t *Trie) Find(path string, match func(*Pattern) bool) *Pattern {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	node := t.root
+	for i := 0; ; i++ {
+		if node.isEnd {
+			for _, p := range node.patterns {
+				if match(p) {
+					return p
+				}
+			}
+		}
+		if i == len(path) {
+			return nil
+		}
+		if node = node.child(path[i]); node == nil {
+			return nil
+		}
+	}
+}
+
+func (t *Trie) FindParts(parts []string, match func(*Pattern) bool) *Pattern {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	node := t.root
+	for n, part := range parts {
+		if n > 0 {
+			if p := node.first(match); p != nil {
+				return p
+			}
+			if node = node.child('.'); node == nil {
+				return nil
+			}
+		}
+		for i := 0; i < len(part); i++ {
+			c := part[i]
+			if c == '.' || c == '\\' {
+				if p := node.first(match); p != nil {
+					return p
+				}
+				if node = node.child('\\'); node == nil {
+					return nil
+				}
+			}
+			if p := node.first(match); p != nil {
+				return p
+			}
+			if node = node.child(c); node == nil {
+				return nil
+			}
+		}
+	}
+	return node.first(match)
+}
+
+func (n *TrieNode) first(match func(*Pattern) bool) *Pattern {
+	if !n.isEnd {
+		return nil
+	}
+	for _, p := range n.patterns {
+		if match(p) {
+			return p
+		}
+	}
+	return nil
+}
+
 func (t *Trie) SearchExact(prefix string) []*Pattern {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
@@ -78,8 +172,8 @@ func (t *Trie) SearchExact(prefix string) []*Pattern {
 	node := t.root
 	for i := 0; i < len(prefix); i++ {
 		char := prefix[i]
-		child, ok := node.children[char]
-		if !ok {
+		child := node.child(char)
+		if child == nil {
 			return nil
 		}
 		node = child
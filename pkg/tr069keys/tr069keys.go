@@ -0,0 +1,115 @@
+package tr069keys
+
+import "strings"
+
+const (
+	RootTR098 = "InternetGatewayDevice"
+	RootTR181 = "Device"
+)
+
+type HostExtractor struct {
+	Prefix string
+}
+
+func (e *HostExtractor) Extract(path, value string) string {
+	if i := instanceAfter(path, "Hosts.Host"); i != "" {
+		return e.Prefix + i
+	}
+	return ""
+}
+
+type WANExtractor struct {
+	Prefix string
+}
+
+func (e *WANExtractor) Extract(path, value string) string {
+	if strings.HasPrefix(path, RootTR098+".") {
+		return e.tr098(path)
+	}
+	if i := instanceAfter(path, "IP.Interface"); i != "" {
+		return e.Prefix + "ip." + i
+	}
+	if i := instanceAfter(path, "PPP.Interface"); i != "" {
+		return e.Prefix + "ppp." + i
+	}
+	return ""
+}
+
+func (e *WANExtractor) tr098(path string) string {
+	device := instanceAfter(path, "WANDevice")
+	if device == "" {
+		return ""
+	}
+	key := device
+
+	connDevice := instanceAfter(path, "WANConnectionDevice")
+	if connDevice == "" {
+		return e.Prefix + key
+	}
+	key += "." + connDevice
+
+	if i := instanceAfter(path, "WANIPConnection"); i != "" {
+		key += ".ip." + i
+	} else if i := instanceAfter(path, "WANPPPConnection"); i != "" {
+		key += ".ppp." + i
+	}
+	return e.Prefix + key
+}
+
+type WLANExtractor struct {
+	Prefix string
+}
+
+var wifiObjects = []string{"WiFi.SSID", "WiFi.AccessPoint", "WiFi.Radio"}
+
+func (e *WLANExtractor) Extract(path, value string) string {
+	if i := instanceAfter(path, "WLANConfiguration"); i != "" {
+		return e.Prefix + i
+	}
+	for _, object := range wifiObjects {
+		if i := instanceAfter(path, object); i != "" {
+			return e.Prefix + i
+		}
+	}
+	return ""
+}
+
+func instanceAfter(path, object string) string {
+	for from := 0; from < len(path); {
+		i := strings.Index(path[from:], object)
+		if i < 0 {
+			return ""
+		}
+		start := from + i
+		end := start + len(object)
+		from = end
+
+		if start > 0 && path[start-1] != '.' {
+			continue
+		}
+		if end >= len(path) || path[end] != '.' {
+			continue
+		}
+
+		segment := path[end+1:]
+		if dot := strings.IndexByte(segment, '.'); dot >= 0 {
+			segment = segment[:dot]
+		}
+		if IsInstance(segment) {
+			return segment
+		}
+	}
+	return ""
+}
+
+func IsInstance(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	for i := 0; i < len(segment); i++ {
+		if segment[i] < '0' || segment[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
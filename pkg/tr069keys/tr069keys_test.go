@@ -0,0 +1,48 @@
+package tr069keys
+
+import (
+	"testing"
+
+	"github.com/metalgrid/tr069-cel-mapper/pkg/extractor"
+)
+
+func TestExtractors(t *testing.T) {
+	tests := []struct {
+		name string
+		ext  extractor.KeyExtractor
+		path string
+		want string
+	}{
+		{"host tr098", &HostExtractor{Prefix: "host:"}, "InternetGatewayDevice.LANDevice.1.Hosts.Host.7.MACAddress", "host:7"},
+		{"host tr181", &HostExtractor{Prefix: "host:"}, "Device.Hosts.Host.3.HostName", "host:3"},
+		{"host table", &HostExtractor{}, "Device.Hosts.HostNumberOfEntries", ""},
+
+		{"wan ppp tr098", &WANExtractor{Prefix: "wan:"}, "InternetGatewayDevice.WANDevice.1.WANConnectionDevice.2.WANPPPConnection.1.Uptime", "wan:1.2.ppp.1"},
+		{"wan ip tr098", &WANExtractor{Prefix: "wan:"}, "InternetGatewayDevice.WANDevice.1.WANConnectionDevice.1.WANIPConnection.3.ExternalIPAddress", "wan:1.1.ip.3"},
+		{"wan link tr098", &WANExtractor{}, "InternetGatewayDevice.WANDevice.2.WANConnectionDevice.1.WANDSLLinkConfig.Enable", "2.1"},
+		{"wan device tr098", &WANExtractor{}, "InternetGatewayDevice.WANDevice.2.WANCommonInterfaceConfig.WANAccessType", "2"},
+		{"wan ip tr181", &WANExtractor{Prefix: "wan:"}, "Device.IP.Interface.2.IPv4Address.1.IPAddress", "wan:ip.2"},
+		{"wan ppp tr181", &WANExtractor{Prefix: "wan:"}, "Device.PPP.Interface.1.Username", "wan:ppp.1"},
+		{"wan unrelated", &WANExtractor{}, "Device.DeviceInfo.SerialNumber", ""},
+
+		{"wlan tr098", &WLANExtractor{Prefix: "wifi:"}, "InternetGatewayDevice.LANDevice.1.WLANConfiguration.2.SSID", "wifi:2"},
+		{"wlan ssid tr181", &WLANExtractor{Prefix: "wifi:"}, "Device.WiFi.SSID.1.SSID", "wifi:1"},
+		{"wlan ap tr181", &WLANExtractor{Prefix: "wifi:"}, "Device.WiFi.AccessPoint.2.Security.KeyPassphrase", "wifi:2"},
+		{"wlan radio tr181", &WLANExtractor{Prefix: "wifi:"}, "Device.WiFi.Radio.1.Channel", "wifi:1"},
+		{"wlan count", &WLANExtractor{}, "Device.WiFi.SSIDNumberOfEntries", ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.ext.Extract(tt.path, ""); got != tt.want {
+			t.Errorf("%s: Extract(%q) = %q, want %q", tt.name, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIsInstance(t *testing.T) {
+	for segment, want := range map[string]bool{"1": true, "42": true, "": false, "1a": false, "-1": false, "Host": false} {
+		if got := IsInstance(segment); got != want {
+			t.Errorf("IsInstance(%q) = %v, want %v", segment, got, want)
+		}
+	}
+}
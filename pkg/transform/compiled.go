@@ -0,0 +1,73 @@
+package transform
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+const maxCompiled = 1024
+
+type compiledEntry struct {
+	name string
+	fn   Transformer
+}
+
+type compiledCache struct {
+	mu      sync.Mutex
+	limit   int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newCompiledCache(limit int) *compiledCache {
+	return &compiledCache{limit: limit, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *compiledCache) get(name string) (Transformer, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[name]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*compiledEntry).fn, true
+}
+
+func (c *compiledCache) put(name string, fn Transformer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[name]; ok {
+		elem.Value.(*compiledEntry).fn = fn
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[name] = c.order.PushFront(&compiledEntry{name: name, fn: fn})
+	for c.order.Len() > c.limit {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*compiledEntry).name)
+	}
+}
+
+func (c *compiledCache) forgetBase(base string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prefix := base + ":"
+	for name, elem := range c.entries {
+		if strings.HasPrefix(name, prefix) {
+			c.order.Remove(elem)
+			delete(c.entries, name)
+		}
+	}
+}
+
+func (c *compiledCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
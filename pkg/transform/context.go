@@ -0,0 +1,52 @@
+package transform
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type ContextTransformer func(ctx context.Context, value string) (any, error)
+
+var (
+	contextTransformers = map[string]ContextTransformer{}
+	anyContextual       atomic.Bool
+)
+
+func RegisterContext(name string, fn ContextTransformer) {
+	transformerMu.Lock()
+	defer transformerMu.Unlock()
+	contextTransformers[name] = fn
+	anyContextual.Store(true)
+	transformers[name] = func(value string) (any, error) {
+		return fn(context.Background(), value)
+	}
+}
+
+func IsContextual(name string) bool {
+	transformerMu.RLock()
+	defer transformerMu.RUnlock()
+	_, ok := contextTransformers[name]
+	return ok
+}
+
+func ApplyContext(ctx context.Context, name, value string) (any, error) {
+	transformerMu.RLock()
+	fn, ok := contextTransformers[name]
+	transformerMu.RUnlock()
+	if !ok {
+		return Apply(name, value)
+	}
+	result, err := fn(ctx, value)
+	if err != nil {
+		return nil, &TransformError{Name: name, Value: value, Err: err}
+	}
+	return result, nil
+}
+
+func (ft *FastTransform) LookupContext(ctx context.Context, name, value string) (any, bool, error) {
+	if anyContextual.Load() && IsContextual(name) {
+		result, err := ApplyContext(ctx, name, value)
+		return result, false, err
+	}
+	return ft.Lookup(name, value)
+}
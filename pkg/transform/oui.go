@@ -0,0 +1,145 @@
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type Warning struct {
+	Transform string
+	Value     string
+	Message   string
+}
+
+var (
+	warningHandler atomic.Pointer[func(Warning)]
+
+	ouiListsMu sync.RWMutex
+	ouiLists   = make(map[string]map[string]bool)
+)
+
+func SetWarningHandler(handler func(Warning)) {
+	if handler == nil {
+		warningHandler.Store(nil)
+		return
+	}
+	warningHandler.Store(&handler)
+}
+
+func warn(w Warning) {
+	if handler := warningHandler.Load(); handler != nil {
+		(*handler)(w)
+	}
+}
+
+func RegisterOUIList(name string, ouis []string) error {
+	set := make(map[string]bool, len(ouis))
+	for _, oui := range ouis {
+		normalized, err := normalizeOUI(oui)
+		if err != nil {
+			return err
+		}
+		set[normalized] = true
+	}
+
+	ouiListsMu.Lock()
+	defer ouiListsMu.Unlock()
+	ouiLists[name] = set
+	return nil
+}
+
+func normalizeOUI(oui string) (string, error) {
+	hex := strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "").Replace(strings.TrimSpace(oui)))
+	if len(hex) != 6 {
+		return "", fmt.Errorf("invalid OUI %q", oui)
+	}
+	for _, c := range hex {
+		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')) {
+			return "", fmt.Errorf("invalid OUI %q", oui)
+		}
+	}
+	return hex[0:2] + ":" + hex[2:4] + ":" + hex[4:6], nil
+}
+
+func newMacOUI(params string) (Transformer, error) {
+	parsed, err := parseParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[string]bool)
+	var lists []string
+	reject := false
+	for key, value := range parsed {
+		switch key {
+		case "allow":
+			for _, oui := range strings.Split(value, ",") {
+				normalized, err := normalizeOUI(oui)
+				if err != nil {
+					return nil, err
+				}
+				allowed[normalized] = true
+			}
+		case "list":
+			ouiListsMu.RLock()
+			_, ok := ouiLists[value]
+			ouiListsMu.RUnlock()
+			if !ok {
+				return nil, fmt.Errorf("unknown OUI list %q", value)
+			}
+			lists = append(lists, value)
+		case "mode":
+			switch value {
+			case "warn":
+				reject = false
+			case "reject":
+				reject = true
+			default:
+				return nil, fmt.Errorf("invalid mode %q: must be warn or reject", value)
+			}
+		default:
+			return nil, fmt.Errorf("unknown parameter %q", key)
+		}
+	}
+	if len(allowed) == 0 && len(lists) == 0 {
+		return nil, errors.New("requires an allow or list parameter")
+	}
+
+	name := "mac_oui:" + params
+	return func(value string) (any, error) {
+		normalized, _ := MacNormalize(value)
+		mac := normalized.(string)
+		if len(mac) != 17 {
+			if reject {
+				return nil, fmt.Errorf("invalid MAC address %q", value)
+			}
+			warn(Warning{Transform: name, Value: value, Message: "invalid MAC address"})
+			return mac, nil
+		}
+
+		if oui := mac[:8]; !allowed[oui] && !inOUILists(lists, oui) {
+			if reject {
+				return nil, fmt.Errorf("OUI %s is not allowed", oui)
+			}
+			warn(Warning{Transform: name, Value: value, Message: "unknown OUI " + oui})
+		}
+		return mac, nil
+	}, nil
+}
+
+func inOUILists(lists []string, oui string) bool {
+	if len(lists) == 0 {
+		return false
+	}
+	ouiListsMu.RLock()
+	defer ouiListsMu.RUnlock()
+	for _, name := range lists {
+		if ouiLists[name][oui] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package transform
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var siPrefixes = map[string]float64{
+	"":   1,
+	"k":  1e3,
+	"K":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"T":  1e12,
+	"P":  1e15,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+}
+
+var siUnits = map[string][]string{
+	"bps": {"bps", "bit/s", "bits/s", "b/s"},
+	"B":   {"B", "byte", "bytes"},
+}
+
+func newSINormalize(params string) (Transformer, error) {
+	base, rest, _ := strings.Cut(params, ":")
+	units, ok := siUnits[base]
+	if !ok {
+		return nil, fmt.Errorf("unknown base unit %q: must be bps or B", base)
+	}
+
+	parsed, err := parseParams(rest)
+	if err != nil {
+		return nil, err
+	}
+	asInt := false
+	for key, value := range parsed {
+		switch key {
+		case "type":
+			switch value {
+			case "float":
+				asInt = false
+			case "int":
+				asInt = true
+			default:
+				return nil, fmt.Errorf("invalid type %q: must be float or int", value)
+			}
+		default:
+			return nil, fmt.Errorf("unknown parameter %q", key)
+		}
+	}
+
+	return func(value string) (any, error) {
+		f, err := parseSI(value, units)
+		if err != nil {
+			return nil, err
+		}
+		if !asInt {
+			return f, nil
+		}
+		f = math.Round(f)
+		if f >= math.MaxInt64 || f < math.MinInt64 {
+			return nil, fmt.Errorf("value %q overflows int64", value)
+		}
+		return int64(f), nil
+	}, nil
+}
+
+func parseSI(value string, units []string) (float64, error) {
+	value = strings.TrimSpace(value)
+	end := 0
+	for end < len(value) && strings.IndexByte("0123456789.+-eE", value[end]) >= 0 {
+		if (value[end] == 'e' || value[end] == 'E') && (end+1 >= len(value) || strings.IndexByte("0123456789+-", value[end+1]) < 0) {
+			break
+		}
+		end++
+	}
+
+	n, err := strconv.ParseFloat(value[:end], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number in %q", value)
+	}
+
+	unit := strings.TrimSpace(value[end:])
+	if unit == "" {
+		return n, nil
+	}
+	for _, name := range units {
+		if !strings.HasSuffix(unit, name) {
+			continue
+		}
+		if scale, ok := siPrefixes[unit[:len(unit)-len(name)]]; ok {
+			return n * scale, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown unit %q", unit)
+}
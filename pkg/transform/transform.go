@@ -1,47 +1,197 @@
 package transform
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net"
+	"net/url"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
 )
 
 type Transformer func(string) (any, error)
 
 var transformers = map[string]Transformer{
-	"mac_normalize": MacNormalize,
-	"ip_validate":   IPValidate,
-	"bool":          ToBool,
-	"int":           ToInt,
-	"float":         ToFloat,
-	"lower":         ToLower,
-	"upper":         ToUpper,
-	"trim":          Trim,
-	"percent_strip": StripPercent,
+	"mac_normalize":       MacNormalize,
+	"ip_validate":         IPValidate,
+	"ipv4_canonical":      IPv4Canonical,
+	"dns_servers":         DNSServers,
+	"dns_servers_lenient": DNSServersLenient,
+	"bool":                ToBool,
+	"int":                 ToInt,
+	"float":               ToFloat,
+	"lower":               ToLower,
+	"upper":               ToUpper,
+	"trim":                Trim,
+	"percent_strip":       StripPercent,
+	"tristate":            Tristate,
+	"ssid_clean":          SSIDClean,
+
+	"hostname_normalize": HostnameNormalize,
+	"datetime_epoch":     DateTimeEpoch,
+
+	"url_decode":         URLDecode,
+	"url_decode_lenient": URLDecodeLenient,
+
+	"band_normalize":         BandNormalize,
+	"band_normalize_lenient": BandNormalizeLenient,
+
+	"raw":  Raw,
+	"none": Raw,
+}
+
+type Factory func(params string) (Transformer, error)
+
+var ErrUnknownTransform = errors.New("unknown transform")
+
+var factories = map[string]Factory{
+	"float":         newScaledFloat,
+	"regex_replace": newRegexReplace,
+	"mac_oui":       newMacOUI,
+	"si_normalize":  newSINormalize,
 }
 
-var transformerMu sync.RWMutex
+var (
+	transformerMu sync.RWMutex
+	compiled      = newCompiledCache(maxCompiled)
+)
 
 func Register(name string, fn Transformer) {
 	transformerMu.Lock()
 	defer transformerMu.Unlock()
 	transformers[name] = fn
+	delete(contextTransformers, name)
+	setUncacheable(name, false)
+}
+
+func RegisterFactory(name string, factory Factory) {
+	transformerMu.Lock()
+	defer transformerMu.Unlock()
+	factories[name] = factory
+	compiled.forgetBase(name)
+	setUncacheable(name, false)
 }
 
 func Get(name string) (Transformer, bool) {
+	fn, err := Compile(name)
+	return fn, err == nil
+}
+
+func Compile(name string) (Transformer, error) {
 	transformerMu.RLock()
-	defer transformerMu.RUnlock()
 	fn, ok := transformers[name]
-	return fn, ok
+	transformerMu.RUnlock()
+	if ok {
+		return fn, nil
+	}
+
+	base, params, ok := strings.Cut(name, ":")
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownTransform, name)
+	}
+	if cached, ok := compiled.get(name); ok {
+		return cached, nil
+	}
+
+	transformerMu.RLock()
+	factory, ok := factories[base]
+	transformerMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownTransform, base)
+	}
+
+	fn, err := factory(params)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s: %w", name, err)
+	}
+	compiled.put(name, fn)
+	return fn, nil
+}
+
+func parseParams(params string) (map[string]string, error) {
+	result := make(map[string]string)
+	if params == "" {
+		return result, nil
+	}
+	for _, param := range strings.Split(params, ":") {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("malformed parameter %q, expected key=value", param)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+func List() []string {
+	transformerMu.RLock()
+	defer transformerMu.RUnlock()
+
+	names := make([]string, 0, len(transformers))
+	for name := range transformers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func ListFactories() []string {
+	transformerMu.RLock()
+	defer transformerMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func Has(name string) bool {
+	_, ok := Get(name)
+	return ok
+}
+
+type TransformError struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+func (e *TransformError) Error() string {
+	return fmt.Sprintf("transform %s failed for %q: %v", e.Name, e.Value, e.Err)
+}
+
+func (e *TransformError) Unwrap() error {
+	return e.Err
 }
 
 func Apply(name, value string) (any, error) {
-	fn, ok := Get(name)
-	if !ok {
+	fn, err := Compile(name)
+	if errors.Is(err, ErrUnknownTransform) {
 		return value, nil
 	}
-	return fn(value)
+	if err != nil {
+		return nil, &TransformError{Name: name, Value: value, Err: err}
+	}
+	result, err := fn(value)
+	if err != nil {
+		return nil, &TransformError{Name: name, Value: value, Err: err}
+	}
+	return result, nil
+}
+
+func Raw(value string) (any, error) {
+	return value, nil
 }
 
 func MacNormalize(value string) (any, error) {
@@ -83,6 +233,67 @@ func IPValidate(value string) (any, error) {
 	return value, nil
 }
 
+func IPv4Canonical(value string) (any, error) {
+	trimmed := strings.TrimSpace(value)
+	octets := strings.Split(trimmed, ".")
+	if len(octets) != 4 {
+		return nil, fmt.Errorf("invalid IPv4 address %q", value)
+	}
+
+	var sb strings.Builder
+	sb.Grow(15)
+	for i, octet := range octets {
+		if len(octet) == 0 || len(octet) > 3 {
+			return nil, fmt.Errorf("invalid IPv4 address %q", value)
+		}
+		n := 0
+		for _, c := range octet {
+			if c < '0' || c > '9' {
+				return nil, fmt.Errorf("invalid IPv4 address %q", value)
+			}
+			n = n*10 + int(c-'0')
+		}
+		if n > 255 {
+			return nil, fmt.Errorf("invalid IPv4 address %q: octet %s out of range", value, octet)
+		}
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(strconv.Itoa(n))
+	}
+	return sb.String(), nil
+}
+
+func DNSServers(value string) (any, error) {
+	return dnsServers(value, false)
+}
+
+func DNSServersLenient(value string) (any, error) {
+	return dnsServers(value, true)
+}
+
+func dnsServers(value string, lenient bool) (any, error) {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+
+	servers := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if ip := net.ParseIP(field); ip != nil {
+			servers = append(servers, ip.String())
+			continue
+		}
+		if canonical, err := IPv4Canonical(field); err == nil {
+			servers = append(servers, canonical.(string))
+			continue
+		}
+		if !lenient {
+			return nil, fmt.Errorf("invalid DNS server address %q", field)
+		}
+	}
+	return servers, nil
+}
+
 func ToBool(value string) (any, error) {
 	value = strings.ToLower(strings.TrimSpace(value))
 
@@ -133,6 +344,120 @@ func ToFloat(value string) (any, error) {
 	return strconv.ParseFloat(value, 64)
 }
 
+func newScaledFloat(params string) (Transformer, error) {
+	parsed, err := parseParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	scale, round := 1.0, -1
+	for key, value := range parsed {
+		switch key {
+		case "scale":
+			scale, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid scale %q: %w", value, err)
+			}
+		case "round":
+			round, err = strconv.Atoi(value)
+			if err != nil || round < 0 {
+				return nil, fmt.Errorf("invalid round %q: must be a non-negative integer", value)
+			}
+		default:
+			return nil, fmt.Errorf("unknown parameter %q", key)
+		}
+	}
+
+	return func(value string) (any, error) {
+		result, err := ToFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		f := result.(float64) * scale
+		if round >= 0 {
+			pow := math.Pow(10, float64(round))
+			f = math.Round(f*pow) / pow
+		}
+		return f, nil
+	}, nil
+}
+
+func newRegexReplace(params string) (Transformer, error) {
+	if len(params) < 3 || params[0] != '/' || params[len(params)-1] != '/' {
+		return nil, fmt.Errorf("expected /pattern/replacement/, got %q", params)
+	}
+
+	parts := splitUnescaped(params[1:len(params)-1], '/')
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("expected /pattern/replacement/, got %q", params)
+	}
+
+	re, err := regexp.Compile(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	repl := parts[1]
+
+	return func(value string) (any, error) {
+		return re.ReplaceAllString(value, repl), nil
+	}, nil
+}
+
+func splitUnescaped(s string, sep byte) []string {
+	var parts []string
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == sep:
+			sb.WriteByte(sep)
+			i++
+		case s[i] == sep:
+			parts = append(parts, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return append(parts, sb.String())
+}
+
+const (
+	TristateNo      int64 = -1
+	TristateUnknown int64 = 0
+	TristateYes     int64 = 1
+)
+
+var defaultTristate = NewTristate(
+	[]string{"true", "1", "yes", "on", "enabled", "up"},
+	[]string{"false", "0", "no", "off", "disabled", "down"},
+	[]string{"", "unknown", "n/a", "none"},
+)
+
+func Tristate(value string) (any, error) {
+	return defaultTristate(value)
+}
+
+func NewTristate(yes, no, unknown []string) Transformer {
+	tokens := make(map[string]int64, len(yes)+len(no)+len(unknown))
+	for _, t := range yes {
+		tokens[strings.ToLower(t)] = TristateYes
+	}
+	for _, t := range no {
+		tokens[strings.ToLower(t)] = TristateNo
+	}
+	for _, t := range unknown {
+		tokens[strings.ToLower(t)] = TristateUnknown
+	}
+
+	return func(value string) (any, error) {
+		state, ok := tokens[strings.ToLower(strings.TrimSpace(value))]
+		if !ok {
+			return nil, fmt.Errorf("unrecognized tristate value %q", value)
+		}
+		return state, nil
+	}
+}
+
 func ToLower(value string) (any, error) {
 	return strings.ToLower(value), nil
 }
@@ -145,6 +470,94 @@ func Trim(value string) (any, error) {
 	return strings.TrimSpace(value), nil
 }
 
+func SSIDClean(value string) (any, error) {
+	return norm.NFC.String(strings.TrimFunc(value, unicode.IsControl)), nil
+}
+
+func HostnameNormalize(value string) (any, error) {
+	name := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), ".")
+	if name == "" {
+		return "", nil
+	}
+
+	ascii, err := idna.ToASCII(name)
+	if err != nil {
+		return nil, err
+	}
+	return ascii, nil
+}
+
+var dateTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+}
+
+func DateTimeEpoch(value string) (any, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Unix(), nil
+		}
+	}
+	return nil, fmt.Errorf("invalid dateTime %q", value)
+}
+
+func URLDecode(value string) (any, error) {
+	decoded, err := url.QueryUnescape(value)
+	if err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+func URLDecodeLenient(value string) (any, error) {
+	if decoded, err := url.QueryUnescape(value); err == nil {
+		return decoded, nil
+	}
+	return value, nil
+}
+
+const (
+	Band24GHz = "2.4GHz"
+	Band5GHz  = "5GHz"
+	Band6GHz  = "6GHz"
+)
+
+var bandAliases = map[string]string{
+	"2.4ghz": Band24GHz,
+	"2.4g":   Band24GHz,
+	"2.4":    Band24GHz,
+	"2g":     Band24GHz,
+	"2ghz":   Band24GHz,
+	"5ghz":   Band5GHz,
+	"5g":     Band5GHz,
+	"5":      Band5GHz,
+	"6ghz":   Band6GHz,
+	"6g":     Band6GHz,
+	"6":      Band6GHz,
+}
+
+func normalizeBand(value string) (string, bool) {
+	key := strings.ToLower(strings.Join(strings.Fields(value), ""))
+	band, ok := bandAliases[key]
+	return band, ok
+}
+
+func BandNormalize(value string) (any, error) {
+	band, ok := normalizeBand(value)
+	if !ok {
+		return nil, fmt.Errorf("unrecognized frequency band %q", value)
+	}
+	return band, nil
+}
+
+func BandNormalizeLenient(value string) (any, error) {
+	if band, ok := normalizeBand(value); ok {
+		return band, nil
+	}
+	return value, nil
+}
+
 func StripPercent(value string) (any, error) {
 	if strings.HasSuffix(value, "%") {
 		return value[:len(value)-1], nil
@@ -153,24 +566,33 @@ func StripPercent(value string) (any, error) {
 }
 
 func Chain(transforms ...string) Transformer {
-	return func(value string) (any, error) {
-		var result any = value
-		for _, name := range transforms {
-			fn, ok := Get(name)
-			if !ok {
-				continue
-			}
+	steps, err := compileSteps(transforms)
+	if err != nil {
+		return func(string) (any, error) {
+			return nil, err
+		}
+	}
+	return chainOf(steps)
+}
 
-			var input string
-			switch v := result.(type) {
-			case string:
-				input = v
-			default:
-				input = strconv.Itoa(int(v.(int64)))
-			}
+func compileSteps(names []string) ([]Transformer, error) {
+	steps := make([]Transformer, len(names))
+	for i, name := range names {
+		fn, err := Compile(name)
+		if err != nil {
+			return nil, err
+		}
+		steps[i] = fn
+	}
+	return steps, nil
+}
 
+func chainOf(steps []Transformer) Transformer {
+	return func(value string) (any, error) {
+		var result any = value
+		for _, step := range steps {
 			var err error
-			result, err = fn(input)
+			result, err = step(chainInput(result))
 			if err != nil {
 				return nil, err
 			}
@@ -179,23 +601,76 @@ func Chain(transforms ...string) Transformer {
 	}
 }
 
+func chainInput(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func RegisterChain(name string, steps ...string) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("chain %s: requires at least one step", name)
+	}
+	for _, step := range steps {
+		if step == name {
+			return fmt.Errorf("chain %s: cannot include itself", name)
+		}
+	}
+	fns, err := compileSteps(steps)
+	if err != nil {
+		return fmt.Errorf("chain %s: %w", name, err)
+	}
+	Register(name, chainOf(fns))
+	for _, step := range steps {
+		if !Cacheable(step) {
+			MarkUncacheable(name)
+			break
+		}
+	}
+	return nil
+}
+
 type FastTransform struct {
 	cache sync.Map
 }
 
+type cacheKey struct {
+	name  string
+	value string
+}
+
 func NewFastTransform() *FastTransform {
 	return &FastTransform{}
 }
 
 func (ft *FastTransform) Transform(name, value string) (any, error) {
-	cacheKey := name + ":" + value
-	if cached, ok := ft.cache.Load(cacheKey); ok {
-		return cached, nil
+	result, _, err := ft.Lookup(name, value)
+	return result, err
+}
+
+func (ft *FastTransform) Lookup(name, value string) (any, bool, error) {
+	if !Cacheable(name) {
+		result, err := Apply(name, value)
+		return result, false, err
+	}
+
+	key := cacheKey{name: name, value: value}
+	if cached, ok := ft.cache.Load(key); ok {
+		return cached, true, nil
 	}
 
 	result, err := Apply(name, value)
 	if err == nil {
-		ft.cache.Store(cacheKey, result)
+		ft.cache.Store(key, result)
 	}
-	return result, err
+	return result, false, err
 }
@@ -0,0 +1,462 @@
+package transform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRegisterChainIntIntoFloat(t *testing.T) {
+	if err := RegisterChain("test_int_scaled", "int", "float:scale=2"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Apply("test_int_scaled", "12345678901")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != float64(24691357802) {
+		t.Errorf("got %v (%T), want 24691357802", got, got)
+	}
+}
+
+func TestRegisterChainUnknownStep(t *testing.T) {
+	err := RegisterChain("test_unknown_step", "trim", "no_such_transform")
+	if !errors.Is(err, ErrUnknownTransform) {
+		t.Fatalf("err = %v, want ErrUnknownTransform", err)
+	}
+	if _, ok := Get("test_unknown_step"); ok {
+		t.Error("chain with an unknown step was registered")
+	}
+}
+
+func TestRegisterChainCycle(t *testing.T) {
+	if err := RegisterChain("test_cycle_a", "trim"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterChain("test_cycle_b", "test_cycle_a", "upper"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterChain("test_cycle_a", "test_cycle_b"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Apply("test_cycle_a", "  abc ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "ABC" {
+		t.Errorf("got %q, want %q", got, "ABC")
+	}
+	if err := RegisterChain("test_cycle_c", "test_cycle_c"); err == nil {
+		t.Error("chain naming itself was accepted")
+	}
+}
+
+func TestMacOUIListReregistered(t *testing.T) {
+	if err := RegisterOUIList("test_reregistered", []string{"00:1a:2b"}); err != nil {
+		t.Fatal(err)
+	}
+	fn, err := Compile("mac_oui:list=test_reregistered:mode=reject")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fn("aa:bb:cc:00:00:01"); err == nil {
+		t.Fatal("expected unlisted OUI to be rejected")
+	}
+
+	if err := RegisterOUIList("test_reregistered", []string{"aa-bb-cc"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Apply("mac_oui:list=test_reregistered:mode=reject", "AABBCC000001"); err != nil || got != "aa:bb:cc:00:00:01" {
+		t.Errorf("got %v, %v after re-registering the list", got, err)
+	}
+	if _, err := fn("00:1a:2b:00:00:01"); err == nil {
+		t.Error("OUI removed from the list is still allowed")
+	}
+}
+
+func TestMacOUI(t *testing.T) {
+	if err := RegisterOUIList("test_known", []string{"00:1a:2b"}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		value   string
+		want    any
+		wantErr bool
+	}{
+		{"mac_oui:allow=001a2b,aa-bb-cc:mode=reject", "AA-BB-CC-00-00-01", "aa:bb:cc:00:00:01", false},
+		{"mac_oui:allow=001a2b:mode=reject", "aa:bb:cc:00:00:01", nil, true},
+		{"mac_oui:allow=001a2b:mode=reject", "not-a-mac", nil, true},
+		{"mac_oui:allow=001a2b", "aa:bb:cc:00:00:01", "aa:bb:cc:00:00:01", false},
+		{"mac_oui:list=test_known:allow=aabbcc:mode=reject", "00:1A:2B:00:00:01", "00:1a:2b:00:00:01", false},
+		{"mac_oui:list=test_known:allow=aabbcc:mode=reject", "aa:bb:cc:00:00:01", "aa:bb:cc:00:00:01", false},
+	}
+	for _, tt := range tests {
+		got, err := Apply(tt.name, tt.value)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("%s(%q) = %v, %v", tt.name, tt.value, got, err)
+		}
+	}
+
+	for _, name := range []string{"mac_oui:", "mac_oui:list=no_such_list", "mac_oui:allow=zz", "mac_oui:allow=001a2b:mode=loud"} {
+		if _, err := Compile(name); err == nil {
+			t.Errorf("Compile(%q) succeeded", name)
+		}
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	tests := []struct {
+		params  string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"", map[string]string{}, false},
+		{"scale=0.01", map[string]string{"scale": "0.01"}, false},
+		{"scale=0.01:round=2", map[string]string{"scale": "0.01", "round": "2"}, false},
+		{"mode=", map[string]string{"mode": ""}, false},
+		{"allow=a=b", map[string]string{"allow": "a=b"}, false},
+		{"scale", nil, true},
+		{"=1", nil, true},
+		{"scale=1:", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseParams(tt.params)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseParams(%q) err = %v, wantErr %v", tt.params, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseParams(%q) = %v, want %v", tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestScaledFloat(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"float:scale=0.01", "1234", 12.34, false},
+		{"float:scale=0.01:round=1", "1234", 12.3, false},
+		{"float:round=0", "2.5", 3, false},
+		{"float:scale=0.01:round=2", "80%", 0.8, false},
+		{"float:scale=1000", "1.5", 1500, false},
+		{"float:scale=2", "abc", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := Apply(tt.name, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s(%q) err = %v, wantErr %v", tt.name, tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("%s(%q) = %v, want %v", tt.name, tt.value, got, tt.want)
+		}
+	}
+
+	for _, name := range []string{"float:scale=x", "float:round=-1", "float:round=1.5", "float:precision=2", "float:scale"} {
+		if _, err := Compile(name); err == nil {
+			t.Errorf("Compile(%q) succeeded", name)
+		}
+	}
+}
+
+func TestSINormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  any
+	}{
+		{"si_normalize:bps", "100 Mbps", 1e8},
+		{"si_normalize:bps", "1.5Gbit/s", 1.5e9},
+		{"si_normalize:bps", "10 kb/s", 1e4},
+		{"si_normalize:bps", "10 Kbits/s", 1e4},
+		{"si_normalize:bps", "1 Kibps", 1024.0},
+		{"si_normalize:bps", "2 Tbps", 2e12},
+		{"si_normalize:bps", "42", 42.0},
+		{"si_normalize:bps", "  -5 Mbps  ", -5e6},
+		{"si_normalize:B", "1 GiB", float64(1 << 30)},
+		{"si_normalize:B", "512 bytes", 512.0},
+		{"si_normalize:B", "1 byte", 1.0},
+		{"si_normalize:B", "2e3 kB", 2e6},
+		{"si_normalize:B", "1.5E+2 MB", 1.5e8},
+		{"si_normalize:B", "3 PB", 3e15},
+		{"si_normalize:B", "4PiB", float64(4 << 50)},
+		{"si_normalize:B:type=float", "1.5 kB", 1500.0},
+		{"si_normalize:B:type=int", "1.5 kB", int64(1500)},
+		{"si_normalize:B:type=int", "2.6 B", int64(3)},
+		{"si_normalize:B:type=int", "0.4 B", int64(0)},
+		{"si_normalize:bps:type=int", "1 Gibps", int64(1 << 30)},
+	}
+	for _, tt := range tests {
+		got, err := Apply(tt.name, tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("%s(%q) = %v (%T), %v; want %v (%T)", tt.name, tt.value, got, got, err, tt.want, tt.want)
+		}
+	}
+
+	errs := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"si_normalize:B", "", `invalid number in ""`},
+		{"si_normalize:B", "   ", `invalid number in ""`},
+		{"si_normalize:B", "MB", `invalid number in "MB"`},
+		{"si_normalize:B", "e5 B", `invalid number in "e5 B"`},
+		{"si_normalize:B", "10 furlongs", `unknown unit "furlongs"`},
+		{"si_normalize:B", "10 mB", `unknown unit "mB"`},
+		{"si_normalize:B", "10 kbps", `unknown unit "kbps"`},
+		{"si_normalize:bps", "10 MB", `unknown unit "MB"`},
+		{"si_normalize:bps", "10 Xbps", `unknown unit "Xbps"`},
+		{"si_normalize:B", "1e400 B", `invalid number in "1e400 B"`},
+		{"si_normalize:B:type=int", "1e300 PB", `value "1e300 PB" overflows int64`},
+	}
+	for _, tt := range errs {
+		_, err := Apply(tt.name, tt.value)
+		var transformErr *TransformError
+		if !errors.As(err, &transformErr) {
+			t.Errorf("%s(%q) err = %v, want a TransformError", tt.name, tt.value, err)
+			continue
+		}
+		if got := transformErr.Err.Error(); got != tt.want {
+			t.Errorf("%s(%q) err = %q, want %q", tt.name, tt.value, got, tt.want)
+		}
+	}
+
+	for _, name := range []string{"si_normalize:", "si_normalize:Hz", "si_normalize:b", "si_normalize:B:type=double", "si_normalize:B:scale=2", "si_normalize:B:type"} {
+		if _, err := Compile(name); err == nil {
+			t.Errorf("Compile(%q) succeeded", name)
+		}
+	}
+}
+
+func TestSplitUnescaped(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"a/b", []string{"a", "b"}},
+		{"a", []string{"a"}},
+		{"", []string{""}},
+		{"a/", []string{"a", ""}},
+		{`a\/b/c`, []string{"a/b", "c"}},
+		{`a\d+/c`, []string{`a\d+`, "c"}},
+		{`a\\/b`, []string{`a\/b`}},
+		{`a\`, []string{`a\`}},
+	}
+	for _, tt := range tests {
+		if got := splitUnescaped(tt.in, '/'); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitUnescaped(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRegexReplace(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"regex_replace:/-/:/", "aa-bb-cc", "aa:bb:cc"},
+		{`regex_replace:/\//-/`, "a/b/c", "a-b-c"},
+		{`regex_replace:/^(-?\d+)\s*dBm$/$1/`, "-65 dBm", "-65"},
+		{"regex_replace:/x//", "axbxc", "abc"},
+		{"regex_replace:/x//", "", ""},
+		{"regex_replace:/x/y/", "abc", "abc"},
+		{`regex_replace:/^$/n\/a/`, "", "n/a"},
+		{`regex_replace:/^$/n\/a/`, "set", "set"},
+		{"regex_replace:/a*/-/", "", "-"},
+		{"regex_replace:/a*/-/", "baac", "-b-c-"},
+		{`regex_replace:/(\w+)@(\w+)/${2}_$1/`, "user@host", "host_user"},
+		{`regex_replace:/(?i)DBM$/ dBm/`, "-70dbm", "-70 dBm"},
+		{`regex_replace:/\s+/ /`, "  a \t b  ", " a b "},
+	}
+	for _, tt := range tests {
+		got, err := Apply(tt.name, tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("%s(%q) = %v, %v; want %q", tt.name, tt.value, got, err, tt.want)
+		}
+	}
+
+	invalid := []struct {
+		name string
+		want string
+	}{
+		{"regex_replace:", "expected /pattern/replacement/"},
+		{"regex_replace:/", "expected /pattern/replacement/"},
+		{"regex_replace://", "expected /pattern/replacement/"},
+		{"regex_replace:/a/", "expected /pattern/replacement/"},
+		{"regex_replace:/a/b/c/", "expected /pattern/replacement/"},
+		{"regex_replace:a/b/", "expected /pattern/replacement/"},
+		{"regex_replace:/a/b", "expected /pattern/replacement/"},
+		{"regex_replace:/(/x/", "missing closing )"},
+		{"regex_replace:/[a-/x/", "missing closing ]"},
+		{"regex_replace:/a**/x/", "invalid nested repetition operator"},
+		{"regex_replace:/(?P<x/x/", "invalid named capture"},
+		{"regex_replace:/x{2,1}/x/", "invalid repeat count"},
+		{`regex_replace:/a\/x/`, "expected /pattern/replacement/"},
+		{`regex_replace:/\q/x/`, "invalid escape sequence"},
+	}
+	for _, tt := range invalid {
+		_, err := Compile(tt.name)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Compile(%q) err = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+		if _, err := Apply(tt.name, "value"); err == nil {
+			t.Errorf("Apply(%q) succeeded", tt.name)
+		}
+	}
+}
+
+func TestCompiledCacheBounded(t *testing.T) {
+	for i := 0; i < maxCompiled+100; i++ {
+		if _, err := Compile(fmt.Sprintf("float:scale=%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := compiled.len(); got > maxCompiled {
+		t.Errorf("compiled cache holds %d entries, limit is %d", got, maxCompiled)
+	}
+}
+
+func TestRegisterFactoryReplacesCompiled(t *testing.T) {
+	RegisterFactory("test_factory", func(params string) (Transformer, error) {
+		return func(string) (any, error) { return "old:" + params, nil }, nil
+	})
+	if got, _ := Apply("test_factory:x", ""); got != "old:x" {
+		t.Fatalf("got %v", got)
+	}
+	RegisterFactory("test_factory", func(params string) (Transformer, error) {
+		return func(string) (any, error) { return "new:" + params, nil }, nil
+	})
+	if got, _ := Apply("test_factory:x", ""); got != "new:x" {
+		t.Errorf("got %v after re-registering the factory", got)
+	}
+}
+
+func TestList(t *testing.T) {
+	Register("test_list_plain", Raw)
+	RegisterContext("test_list_context", func(ctx context.Context, value string) (any, error) { return value, nil })
+	RegisterFactory("test_list_factory", func(params string) (Transformer, error) { return Raw, nil })
+
+	names := List()
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("List() is not sorted: %v", names)
+	}
+	for _, name := range []string{"int", "test_list_plain", "test_list_context"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("List() is missing %s", name)
+		}
+	}
+	if slices.Contains(names, "test_list_factory") {
+		t.Error("List() includes a factory")
+	}
+
+	factories := ListFactories()
+	if !sort.StringsAreSorted(factories) {
+		t.Errorf("ListFactories() is not sorted: %v", factories)
+	}
+	for _, name := range []string{"float", "regex_replace", "test_list_factory"} {
+		if !slices.Contains(factories, name) {
+			t.Errorf("ListFactories() is missing %s", name)
+		}
+	}
+}
+
+func TestFastTransformDoesNotCacheMacOUI(t *testing.T) {
+	if err := RegisterOUIList("test_uncached", []string{"00:1a:2b"}); err != nil {
+		t.Fatal(err)
+	}
+	var warnings []Warning
+	SetWarningHandler(func(w Warning) { warnings = append(warnings, w) })
+	defer SetWarningHandler(nil)
+
+	ft := NewFastTransform()
+	name := "mac_oui:list=test_uncached"
+	for i := 0; i < 2; i++ {
+		if got, hit, err := ft.Lookup(name, "aa:bb:cc:00:00:01"); err != nil || hit || got != "aa:bb:cc:00:00:01" {
+			t.Fatalf("Lookup = %v, %v, %v", got, hit, err)
+		}
+	}
+	if len(warnings) != 2 {
+		t.Errorf("got %d warnings for the same MAC twice, want 2", len(warnings))
+	}
+
+	if err := RegisterOUIList("test_uncached", []string{"aa:bb:cc"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ft.Lookup(name, "aa:bb:cc:00:00:01"); err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 2 {
+		t.Errorf("OUI added to the list still warns: %v", warnings[2:])
+	}
+	if _, _, err := ft.Lookup(name+":mode=reject", "00:1a:2b:00:00:01"); err == nil {
+		t.Error("OUI removed from the list is still allowed")
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	if Cacheable("mac_oui:allow=001a2b") {
+		t.Error("mac_oui is cacheable")
+	}
+	if !Cacheable("int") {
+		t.Error("int is not cacheable")
+	}
+
+	if err := RegisterChain("test_chain_oui", "trim", "mac_oui:allow=001a2b"); err != nil {
+		t.Fatal(err)
+	}
+	if Cacheable("test_chain_oui") {
+		t.Error("chain with a mac_oui step is cacheable")
+	}
+	Register("test_chain_oui", Raw)
+	if !Cacheable("test_chain_oui") {
+		t.Error("re-registered transform kept the uncacheable mark")
+	}
+
+	Register("test_uncacheable", Raw)
+	MarkUncacheable("test_uncacheable")
+	ft := NewFastTransform()
+	ft.Lookup("test_uncacheable", "x")
+	if _, hit, _ := ft.Lookup("test_uncacheable", "x"); hit {
+		t.Error("result of an uncacheable transform was cached")
+	}
+}
+
+func TestSSIDClean(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"home", "home"},
+		{"", ""},
+		{"home\x00\x00\x00", "home"},
+		{"\x00\r\nhome\t", "home"},
+		{"\x00\x00", ""},
+		{"  my wifi  ", "  my wifi  "},
+		{"\x00 my wifi \x00", " my wifi "},
+		{"a\tb", "a\tb"},
+		{"a\x00b", "a\x00b"},
+		{"Cafe\u0301\x00", "Caf\u00e9"},
+		{"\x00Cafe\u0301 5G", "Caf\u00e9 5G"},
+		{"\u0085home\u009f", "home"},
+	}
+	for _, tt := range tests {
+		got, err := Apply("ssid_clean", tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("ssid_clean(%q) = %q, %v; want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package transform
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+var uncacheable = func() *atomic.Pointer[map[string]bool] {
+	p := &atomic.Pointer[map[string]bool]{}
+	p.Store(&map[string]bool{"mac_oui": true})
+	return p
+}()
+
+func MarkUncacheable(name string) {
+	setUncacheable(name, true)
+}
+
+func Cacheable(name string) bool {
+	marked := *uncacheable.Load()
+	if len(marked) == 0 {
+		return true
+	}
+	base, _, _ := strings.Cut(name, ":")
+	return !marked[name] && !marked[base]
+}
+
+func setUncacheable(name string, on bool) {
+	for {
+		old := uncacheable.Load()
+		if (*old)[name] == on {
+			return
+		}
+		next := make(map[string]bool, len(*old)+1)
+		for k, v := range *old {
+			next[k] = v
+		}
+		if on {
+			next[name] = true
+		} else {
+			delete(next, name)
+		}
+		if uncacheable.CompareAndSwap(old, &next) {
+			return
+		}
+	}
+}
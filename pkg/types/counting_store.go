@@ -0,0 +1,147 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+type CountingStore struct {
+	mu       sync.RWMutex
+	counts   map[string]map[string]int64
+	entities map[string]map[string]any
+	locks    []sync.Mutex
+}
+
+func NewCountingStore() *CountingStore {
+	return &CountingStore{
+		counts:   make(map[string]map[string]int64),
+		entities: make(map[string]map[string]any),
+		locks:    make([]sync.Mutex, 64),
+	}
+}
+
+func (s *CountingStore) LockEntity(target, key string) func() {
+	mu := &s.locks[entityHash(target, key)%uint64(len(s.locks))]
+	mu.Lock()
+	return mu.Unlock
+}
+
+func (s *CountingStore) Upsert(target, key string, factory func() any) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group, ok := s.counts[target]
+	if !ok {
+		group = make(map[string]int64)
+		s.counts[target] = group
+		s.entities[target] = make(map[string]any)
+	}
+	group[key]++
+
+	obj, ok := s.entities[target][key]
+	if !ok {
+		obj = factory()
+		s.entities[target][key] = obj
+	}
+	return obj
+}
+
+func (s *CountingStore) Get(target, key string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count, ok := s.counts[target][key]
+	return count, ok
+}
+
+func (s *CountingStore) GetAll(target string) map[string]any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	group, ok := s.counts[target]
+	if !ok {
+		return nil
+	}
+	result := make(map[string]any, len(group))
+	for k, v := range group {
+		result[k] = v
+	}
+	return result
+}
+
+func (s *CountingStore) Counts(target string) map[string]int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	group, ok := s.counts[target]
+	if !ok {
+		return nil
+	}
+	result := make(map[string]int64, len(group))
+	for k, v := range group {
+		result[k] = v
+	}
+	return result
+}
+
+func (s *CountingStore) Count(target string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.counts[target])
+}
+
+func (s *CountingStore) Targets() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	targets := make([]string, 0, len(s.counts))
+	for target, group := range s.counts {
+		if len(group) > 0 {
+			targets = append(targets, target)
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func (s *CountingStore) ForEach(fn func(target, key string, obj any) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for target, group := range s.counts {
+		for key, count := range group {
+			if err := fn(target, key, count); err != nil {
+				return fmt.Errorf("error processing %s[%s]: %w", target, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *CountingStore) Delete(target, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if group, ok := s.counts[target]; ok {
+		delete(group, key)
+		delete(s.entities[target], key)
+	}
+}
+
+func (s *CountingStore) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts = make(map[string]map[string]int64)
+	s.entities = make(map[string]map[string]any)
+}
+
+func (s *CountingStore) ClearTarget(target string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.counts, target)
+	delete(s.entities, target)
+}
@@ -0,0 +1,102 @@
+package types
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+type countedHost struct {
+	Name string
+}
+
+func TestCountingStoreTee(t *testing.T) {
+	counting := NewCountingStore()
+	store := NewTeeStore(NewMapStore(), counting)
+
+	factory := func() any { return &countedHost{} }
+	for _, key := range []string{"1", "1", "2", "1"} {
+		obj := store.Upsert("host", key, factory)
+		obj.(*countedHost).Name = key
+	}
+
+	if got := store.Count("host"); got != 2 {
+		t.Errorf("primary Count = %d, want 2", got)
+	}
+	if obj, _ := store.Get("host", "2"); obj.(*countedHost).Name != "2" {
+		t.Errorf("primary entity was not kept: %+v", obj)
+	}
+
+	counts := counting.Counts("host")
+	if counts["1"] != 3 || counts["2"] != 1 {
+		t.Errorf("Counts = %v, want map[1:3 2:1]", counts)
+	}
+	if got := counting.Count("host"); got != 2 {
+		t.Errorf("counting Count = %d, want 2", got)
+	}
+
+	store.Delete("host", "1")
+	if _, ok := counting.Get("host", "1"); ok {
+		t.Error("Delete was not forwarded to the counting store")
+	}
+}
+
+func TestCountingStoreConcurrentUpserts(t *testing.T) {
+	store := NewCountingStore()
+	factory := func() any { return &countedHost{} }
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				key := strconv.Itoa(i % 50)
+				store.Upsert("host", key, factory)
+			}
+		}()
+	}
+	wg.Wait()
+
+	first, _ := store.Upsert("host", "0", factory).(*countedHost)
+	if again := store.Upsert("host", "0", factory); again != first {
+		t.Error("Upsert returned a new object for an existing key")
+	}
+	if got := store.Count("host"); got != 50 {
+		t.Errorf("Count = %d, want 50", got)
+	}
+	for key, count := range store.Counts("host") {
+		want := int64(32)
+		if key == "0" {
+			want += 2
+		}
+		if count != want {
+			t.Errorf("Counts[%s] = %d, want 32", key, count)
+		}
+	}
+}
+
+func TestCountingStoreStableSentinel(t *testing.T) {
+	store := NewCountingStore()
+	created := 0
+	factory := func() any {
+		created++
+		return &countedHost{}
+	}
+
+	first := store.Upsert("host", "1", factory)
+	if again := store.Upsert("host", "1", factory); again != first {
+		t.Error("Upsert returned a different object for the same key")
+	}
+	if other := store.Upsert("host", "2", factory); other == first {
+		t.Error("Upsert shared one object across keys")
+	}
+	if created != 2 {
+		t.Errorf("factory called %d times, want 2", created)
+	}
+
+	store.Delete("host", "1")
+	if store.Upsert("host", "1", factory) == first {
+		t.Error("Upsert kept the object of a deleted key")
+	}
+}
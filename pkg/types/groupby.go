@@ -0,0 +1,40 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+)
+
+func GroupBy(store Store, target, fieldName string) map[string][]any {
+	groups := make(map[string][]any)
+	for _, e := range GetAllSorted(store, target) {
+		key := groupKey(e.Entity, fieldName)
+		groups[key] = append(groups[key], e.Entity)
+	}
+	return groups
+}
+
+func groupKey(obj any, fieldName string) string {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+
+	field := v.FieldByName(fieldName)
+	for field.IsValid() && (field.Kind() == reflect.Ptr || field.Kind() == reflect.Interface) {
+		if field.IsNil() {
+			return ""
+		}
+		field = field.Elem()
+	}
+	if !field.IsValid() || !field.CanInterface() || field.IsZero() {
+		return ""
+	}
+	return fmt.Sprint(field.Interface())
+}
@@ -0,0 +1,38 @@
+package types
+
+import "testing"
+
+type groupHost struct {
+	Interface string
+	Active    *bool
+}
+
+func TestGroupBy(t *testing.T) {
+	active := true
+	store := NewMapStore()
+	store.Upsert("host", "2", func() any { return &groupHost{Interface: "WiFi", Active: &active} })
+	store.Upsert("host", "1", func() any { return &groupHost{Interface: "WiFi"} })
+	store.Upsert("host", "3", func() any { return &groupHost{Interface: "Ethernet"} })
+	store.Upsert("host", "4", func() any { return &groupHost{} })
+
+	groups := GroupBy(store, "host", "Interface")
+	if len(groups) != 3 {
+		t.Fatalf("groups = %v, want 3 buckets", groups)
+	}
+	wifi := groups["WiFi"]
+	if len(wifi) != 2 || wifi[0].(*groupHost).Active != nil {
+		t.Errorf("WiFi bucket = %v, want hosts 1 and 2 in key order", wifi)
+	}
+	if len(groups["Ethernet"]) != 1 || len(groups[""]) != 1 {
+		t.Errorf("groups = %v, want one Ethernet and one empty-key host", groups)
+	}
+
+	byActive := GroupBy(store, "host", "Active")
+	if len(byActive["true"]) != 1 || len(byActive[""]) != 3 {
+		t.Errorf("Active groups = %v, want nil pointers under the empty key", byActive)
+	}
+
+	if missing := GroupBy(store, "host", "Nope"); len(missing[""]) != 4 {
+		t.Errorf("unknown field groups = %v, want everything under the empty key", missing)
+	}
+}
@@ -0,0 +1,204 @@
+package types
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type FieldRecorder interface {
+	Record(target, key, field string, value any)
+}
+
+type Sample struct {
+	Value any
+	Time  time.Time
+}
+
+type HistoryStore struct {
+	Store
+
+	size int
+
+	mu      sync.RWMutex
+	tracked map[historyField]bool
+	history map[historyKey]*ring
+}
+
+type historyField struct {
+	target string
+	field  string
+}
+
+type historyKey struct {
+	target string
+	key    string
+	field  string
+}
+
+type ring struct {
+	samples []Sample
+	next    int
+	full    bool
+}
+
+func NewHistoryStore(inner Store, size int) *HistoryStore {
+	if inner == nil {
+		inner = NewMapStore()
+	}
+	if size <= 0 {
+		size = 16
+	}
+
+	return &HistoryStore{
+		Store:   inner,
+		size:    size,
+		tracked: make(map[historyField]bool),
+		history: make(map[historyKey]*ring),
+	}
+}
+
+func (s *HistoryStore) Track(target string, fields ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, field := range fields {
+		s.tracked[historyField{target: target, field: field}] = true
+	}
+}
+
+func (s *HistoryStore) Record(target, key, field string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.tracked[historyField{target: target, field: field}] {
+		return
+	}
+
+	hk := historyKey{target: target, key: key, field: field}
+	r, ok := s.history[hk]
+	if !ok {
+		r = &ring{samples: make([]Sample, s.size)}
+		s.history[hk] = r
+	}
+
+	r.samples[r.next] = Sample{Value: value, Time: time.Now()}
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+func (s *HistoryStore) History(target, key, field string) []Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.history[historyKey{target: target, key: key, field: field}]
+	if !ok {
+		return nil
+	}
+	return r.ordered()
+}
+
+func (r *ring) ordered() []Sample {
+	if !r.full {
+		return append([]Sample(nil), r.samples[:r.next]...)
+	}
+	result := make([]Sample, 0, len(r.samples))
+	result = append(result, r.samples[r.next:]...)
+	return append(result, r.samples[:r.next]...)
+}
+
+func mergeRings(a, b *ring, size int) *ring {
+	samples := append(a.ordered(), b.ordered()...)
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].Time.Before(samples[j].Time) })
+	if len(samples) > size {
+		samples = samples[len(samples)-size:]
+	}
+
+	merged := &ring{samples: make([]Sample, size)}
+	merged.next = copy(merged.samples, samples) % size
+	merged.full = len(samples) == size
+	return merged
+}
+
+func (s *HistoryStore) Count(target string) int {
+	return CountTarget(s.Store, target)
+}
+
+func (s *HistoryStore) Targets() []string {
+	return ListTargets(s.Store)
+}
+
+func (s *HistoryStore) ForEachSnapshot(fn func(target, key string, obj any) error) error {
+	return ForEachSnapshot(s.Store, fn)
+}
+
+func (s *HistoryStore) Delete(target, key string) {
+	DeleteEntity(s.Store, target, key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hk := range s.history {
+		if hk.target == target && hk.key == key {
+			delete(s.history, hk)
+		}
+	}
+}
+
+func (s *HistoryStore) Clear() {
+	s.Store.Clear()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = make(map[historyKey]*ring)
+}
+
+func (s *HistoryStore) ClearTarget(target string) {
+	ClearTarget(s.Store, target)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hk := range s.history {
+		if hk.target == target {
+			delete(s.history, hk)
+		}
+	}
+}
+
+func (s *HistoryStore) replaceTarget(target string, entities map[string]any, moved map[string]string) error {
+	if err := replaceTarget(s.Store, target, entities, moved); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rekeyed := make(map[historyKey]*ring)
+	for hk, r := range s.history {
+		if hk.target != target {
+			continue
+		}
+		delete(s.history, hk)
+		key, ok := moved[hk.key]
+		if !ok {
+			continue
+		}
+		nk := historyKey{target: target, key: key, field: hk.field}
+		if existing, ok := rekeyed[nk]; ok {
+			r = mergeRings(existing, r, s.size)
+		}
+		rekeyed[nk] = r
+	}
+	for hk, r := range rekeyed {
+		s.history[hk] = r
+	}
+	return nil
+}
+
+func (s *HistoryStore) LockEntity(target, key string) func() {
+	if locker, ok := s.Store.(EntityLocker); ok {
+		return locker.LockEntity(target, key)
+	}
+	return func() {}
+}
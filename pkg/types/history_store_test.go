@@ -0,0 +1,35 @@
+package types
+
+import "testing"
+
+func TestHistoryStoreRingBuffer(t *testing.T) {
+	store := NewHistoryStore(NewMapStore(), 3)
+	store.Track("ap", "Signal")
+
+	for _, v := range []int{-40, -41, -42, -43} {
+		store.Record("ap", "1", "Signal", v)
+	}
+	store.Record("ap", "1", "SSID", "untracked")
+
+	samples := store.History("ap", "1", "Signal")
+	if len(samples) != 3 {
+		t.Fatalf("got %d samples, want 3", len(samples))
+	}
+	for i, want := range []int{-41, -42, -43} {
+		if samples[i].Value != want {
+			t.Errorf("sample %d = %v, want %d", i, samples[i].Value, want)
+		}
+	}
+	if samples[2].Time.Before(samples[0].Time) {
+		t.Errorf("samples are not in chronological order")
+	}
+
+	if got := store.History("ap", "1", "SSID"); got != nil {
+		t.Errorf("untracked field has history %v", got)
+	}
+
+	store.ClearTarget("ap")
+	if got := store.History("ap", "1", "Signal"); got != nil {
+		t.Errorf("history survived ClearTarget: %v", got)
+	}
+}
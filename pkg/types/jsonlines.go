@@ -0,0 +1,23 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type jsonLine struct {
+	Target string `json:"target"`
+	Key    string `json:"key"`
+	Data   any    `json:"data"`
+}
+
+func WriteJSONLines(w io.Writer, store Store) error {
+	enc := json.NewEncoder(w)
+	return store.ForEach(func(target, key string, obj any) error {
+		if err := enc.Encode(jsonLine{Target: target, Key: key, Data: obj}); err != nil {
+			return fmt.Errorf("failed to encode %s[%s]: %w", target, key, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestWriteJSONLines(t *testing.T) {
+	store := NewMapStore()
+	store.Upsert("host", "Host.1", func() any { return &rekeyHost{MAC: "aa:bb", Name: "laptop"} })
+	store.Upsert("wifi", "1", func() any { return map[string]any{"SSID": "home"} })
+
+	var buf bytes.Buffer
+	if err := WriteJSONLines(&buf, store); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var line struct {
+			Target string          `json:"target"`
+			Key    string          `json:"key"`
+			Data   json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		got[line.Target+"/"+line.Key] = string(line.Data)
+	}
+
+	want := map[string]string{
+		"host/Host.1": `{"MAC":"aa:bb","Name":"laptop"}`,
+		"wifi/1":      `{"SSID":"home"}`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d: %v", len(got), len(want), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %s, want %s", k, got[k], v)
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestWriteJSONLinesWriteError(t *testing.T) {
+	store := NewMapStore()
+	store.Upsert("host", "Host.1", func() any { return &rekeyHost{} })
+
+	if err := WriteJSONLines(failingWriter{}, store); err == nil {
+		t.Fatal("expected write error")
+	}
+}
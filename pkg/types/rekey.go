@@ -0,0 +1,55 @@
+package types
+
+import "sort"
+
+type MergeFunc func(existing, incoming any) any
+
+type targetReplacer interface {
+	replaceTarget(target string, entities map[string]any, moved map[string]string) error
+}
+
+func Rekey(store Store, target string, fn func(key string, obj any) string, merge MergeFunc) error {
+	entities := GetAllSorted(store, target)
+	if len(entities) == 0 {
+		return nil
+	}
+
+	rekeyed := make(map[string]any, len(entities))
+	moved := make(map[string]string, len(entities))
+	for _, e := range entities {
+		key := fn(e.Key, e.Entity)
+		if key == "" {
+			key = e.Key
+		}
+		moved[e.Key] = key
+		existing, ok := rekeyed[key]
+		if !ok {
+			rekeyed[key] = e.Entity
+			continue
+		}
+		if merge != nil {
+			rekeyed[key] = merge(existing, e.Entity)
+		}
+	}
+
+	return replaceTarget(store, target, rekeyed, moved)
+}
+
+func replaceTarget(store Store, target string, entities map[string]any, moved map[string]string) error {
+	if r, ok := store.(targetReplacer); ok {
+		return r.replaceTarget(target, entities, moved)
+	}
+	if err := ClearTarget(store, target); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(entities))
+	for key := range entities {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return NaturalLess(keys[i], keys[j]) })
+	for _, key := range keys {
+		obj := entities[key]
+		store.Upsert(target, key, func() any { return obj })
+	}
+	return nil
+}
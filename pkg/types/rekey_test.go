@@ -0,0 +1,115 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type rekeyHost struct {
+	MAC  string
+	Name string
+}
+
+func TestRekey(t *testing.T) {
+	store := NewMapStore()
+	store.Upsert("host", "Host.1", func() any { return &rekeyHost{MAC: "aa:bb", Name: "laptop"} })
+	store.Upsert("host", "Host.2", func() any { return &rekeyHost{MAC: "cc:dd"} })
+	store.Upsert("host", "Host.3", func() any { return &rekeyHost{MAC: "aa:bb", Name: "laptop-wifi"} })
+	store.Upsert("host", "Host.4", func() any { return &rekeyHost{} })
+	store.Upsert("wifi", "Host.1", func() any { return "untouched" })
+
+	err := Rekey(store, "host", func(key string, obj any) string {
+		return obj.(*rekeyHost).MAC
+	}, func(existing, incoming any) any {
+		existing.(*rekeyHost).Name += "," + incoming.(*rekeyHost).Name
+		return existing
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := store.Count("host"); got != 3 {
+		t.Fatalf("count = %d, want 3", got)
+	}
+	obj, ok := store.Get("host", "aa:bb")
+	if !ok || obj.(*rekeyHost).Name != "laptop,laptop-wifi" {
+		t.Errorf("aa:bb = %+v, want merged names", obj)
+	}
+	if _, ok := store.Get("host", "cc:dd"); !ok {
+		t.Error("cc:dd missing")
+	}
+	if _, ok := store.Get("host", "Host.4"); !ok {
+		t.Error("empty derived key should keep Host.4")
+	}
+	if _, ok := store.Get("wifi", "Host.1"); !ok {
+		t.Error("other targets must not be rekeyed")
+	}
+}
+
+func TestRekeyKeepsFirstWithoutMerge(t *testing.T) {
+	store := NewMapStore()
+	store.Upsert("host", "Host.2", func() any { return "second" })
+	store.Upsert("host", "Host.1", func() any { return "first" })
+
+	if err := Rekey(store, "host", func(string, any) string { return "same" }, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if obj, _ := store.Get("host", "same"); obj != "first" {
+		t.Errorf("same = %v, want first", obj)
+	}
+}
+
+func TestRekeyMovesHistory(t *testing.T) {
+	store := NewHistoryStore(NewStripedStore(4), 4)
+	store.Track("host", "Name")
+	for key, mac := range map[string]string{"Host.1": "aa:bb", "Host.2": "cc:dd", "Host.3": "aa:bb"} {
+		store.Upsert("host", key, func() any { return &rekeyHost{MAC: mac} })
+	}
+	store.Record("host", "Host.1", "Name", "laptop")
+	store.Record("host", "Host.2", "Name", "phone")
+	store.Record("host", "Host.3", "Name", "laptop-wifi")
+
+	err := Rekey(store, "host", func(key string, obj any) string {
+		return obj.(*rekeyHost).MAC
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := store.Count("host"); got != 2 {
+		t.Fatalf("count = %d, want 2", got)
+	}
+	if _, ok := store.Get("host", "Host.1"); ok {
+		t.Error("old key Host.1 still stored")
+	}
+	var names []any
+	for _, s := range store.History("host", "aa:bb", "Name") {
+		names = append(names, s.Value)
+	}
+	if fmt.Sprint(names) != "[laptop laptop-wifi]" {
+		t.Errorf("aa:bb history = %v, want both merged entities' samples", names)
+	}
+	if got := store.History("host", "cc:dd", "Name"); len(got) != 1 || got[0].Value != "phone" {
+		t.Errorf("cc:dd history = %v", got)
+	}
+	if got := store.History("host", "Host.2", "Name"); got != nil {
+		t.Errorf("history left under old key: %v", got)
+	}
+}
+
+func TestRekeyLeavesStoreWithoutDeleteUntouched(t *testing.T) {
+	store := upsertOnlyStore{NewMapStore()}
+	store.Upsert("host", "Host.1", func() any { return &rekeyHost{MAC: "aa:bb"} })
+
+	err := Rekey(store, "host", func(key string, obj any) string {
+		return obj.(*rekeyHost).MAC
+	}, nil)
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("Rekey error = %v, want ErrUnsupported", err)
+	}
+	if _, ok := store.Get("host", "Host.1"); !ok {
+		t.Error("Host.1 removed by a failed rekey")
+	}
+}
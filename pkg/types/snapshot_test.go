@@ -0,0 +1,83 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type snapshotStore interface {
+	Store
+	Counter
+	Deleter
+	ForEachSnapshot(fn func(target, key string, obj any) error) error
+}
+
+func TestForEachSnapshotAllowsWrites(t *testing.T) {
+	stores := map[string]snapshotStore{
+		"map":     NewMapStore(),
+		"striped": NewStripedStore(4),
+		"history": NewHistoryStore(NewMapStore(), 4),
+		"tee":     NewTeeStore(NewMapStore(), NewCountingStore()),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 4; i++ {
+				key := fmt.Sprintf("host:%d", i)
+				store.Upsert("host", key, func() any { return &counter{N: i} })
+			}
+
+			seen := 0
+			err := store.ForEachSnapshot(func(target, key string, obj any) error {
+				seen++
+				store.Upsert("host", "added:"+key, func() any { return &counter{} })
+				if key == "host:3" || key == "host:2" {
+					store.Delete("host", "host:3")
+					store.Delete("host", "host:2")
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if seen != 3 {
+				t.Errorf("visited %d entities, want 3 (one deleted mid-iteration)", seen)
+			}
+			if got := store.Count("host"); got != 5 {
+				t.Errorf("count = %d, want 5", got)
+			}
+		})
+	}
+}
+
+func TestForEachSnapshotFallback(t *testing.T) {
+	inner := NewMapStore()
+	store := upsertOnlyStore{inner}
+	for i := 0; i < 3; i++ {
+		store.Upsert("host", fmt.Sprintf("host:%d", i), func() any { return &counter{N: i} })
+	}
+	store.Upsert("wifi", "wifi:1", func() any { return &counter{} })
+
+	var seen []string
+	err := ForEachSnapshot(store, func(target, key string, obj any) error {
+		seen = append(seen, target+"/"+key)
+		store.Upsert(target, "added:"+key, func() any { return &counter{} })
+		if key == "host:0" {
+			inner.Delete("host", "host:1")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 3 {
+		t.Errorf("visited %v, want 3 entities (one deleted mid-iteration)", seen)
+	}
+
+	boom := errors.New("boom")
+	err = ForEachSnapshot(store, func(target, key string, obj any) error { return boom })
+	if !errors.Is(err, boom) {
+		t.Errorf("error = %v, want it to wrap the callback error", err)
+	}
+}
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"sort"
+	"strings"
+)
+
+type KeyedEntity struct {
+	Key    string
+	Entity any
+}
+
+func GetAllSorted(store Store, target string) []KeyedEntity {
+	all := store.GetAll(target)
+	entities := make([]KeyedEntity, 0, len(all))
+	for key, obj := range all {
+		entities = append(entities, KeyedEntity{Key: key, Entity: obj})
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		return NaturalLess(entities[i].Key, entities[j].Key)
+	})
+	return entities
+}
+
+func NaturalLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			na, nextA := digitRun(a, i)
+			nb, nextB := digitRun(b, j)
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			i, j = nextA, nextB
+			continue
+		}
+		if a[i] != b[j] {
+			return a[i] < b[j]
+		}
+		i++
+		j++
+	}
+	if len(a)-i != len(b)-j {
+		return len(a)-i < len(b)-j
+	}
+	return a < b
+}
+
+func digitRun(s string, start int) (string, int) {
+	end := start
+	for end < len(s) && isDigit(s[end]) {
+		end++
+	}
+	return strings.TrimLeft(s[start:end], "0"), end
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,30 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetAllSorted(t *testing.T) {
+	store := NewMapStore()
+	for _, key := range []string{"Host.10", "Host.2", "Host.1", "Host.02", "AP.3", "Host.2.1"} {
+		store.Upsert("host", key, func() any { return key })
+	}
+
+	var keys []string
+	for _, e := range GetAllSorted(store, "host") {
+		keys = append(keys, e.Key)
+		if e.Entity != e.Key {
+			t.Errorf("entity for %s = %v", e.Key, e.Entity)
+		}
+	}
+
+	want := []string{"AP.3", "Host.1", "Host.02", "Host.2", "Host.2.1", "Host.10"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+
+	if got := GetAllSorted(store, "missing"); len(got) != 0 {
+		t.Errorf("missing target = %v, want empty", got)
+	}
+}
@@ -0,0 +1,250 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+type EntityLocker interface {
+	LockEntity(target, key string) (unlock func())
+}
+
+type StripedStore struct {
+	stripes []storeStripe
+	locks   []sync.Mutex
+}
+
+type storeStripe struct {
+	mu   sync.RWMutex
+	data map[string]map[string]any
+}
+
+func NewStripedStore(stripes int) *StripedStore {
+	if stripes <= 0 {
+		stripes = 64
+	}
+
+	s := &StripedStore{
+		stripes: make([]storeStripe, stripes),
+		locks:   make([]sync.Mutex, stripes),
+	}
+	for i := range s.stripes {
+		s.stripes[i].data = make(map[string]map[string]any)
+	}
+	return s
+}
+
+func (s *StripedStore) index(target, key string) int {
+	return int(entityHash(target, key) % uint64(len(s.stripes)))
+}
+
+func entityHash(target, key string) uint64 {
+	const (
+		offset = 14695981039346656037
+		prime  = 1099511628211
+	)
+
+	h := uint64(offset)
+	for i := 0; i < len(target); i++ {
+		h ^= uint64(target[i])
+		h *= prime
+	}
+	h *= prime
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= prime
+	}
+	return h
+}
+
+func (s *StripedStore) LockEntity(target, key string) func() {
+	mu := &s.locks[s.index(target, key)]
+	mu.Lock()
+	return mu.Unlock
+}
+
+func (s *StripedStore) Upsert(target, key string, factory func() any) any {
+	stripe := &s.stripes[s.index(target, key)]
+	stripe.mu.Lock()
+	defer stripe.mu.Unlock()
+
+	group, ok := stripe.data[target]
+	if !ok {
+		group = make(map[string]any)
+		stripe.data[target] = group
+	}
+
+	obj, ok := group[key]
+	if !ok {
+		obj = factory()
+		group[key] = obj
+	}
+	return obj
+}
+
+func (s *StripedStore) Get(target, key string) (any, bool) {
+	stripe := &s.stripes[s.index(target, key)]
+	stripe.mu.RLock()
+	defer stripe.mu.RUnlock()
+
+	group, ok := stripe.data[target]
+	if !ok {
+		return nil, false
+	}
+	obj, ok := group[key]
+	return obj, ok
+}
+
+func (s *StripedStore) GetAll(target string) map[string]any {
+	var result map[string]any
+	for i := range s.stripes {
+		stripe := &s.stripes[i]
+		stripe.mu.RLock()
+		if group, ok := stripe.data[target]; ok {
+			if result == nil {
+				result = make(map[string]any, len(group))
+			}
+			for k, v := range group {
+				result[k] = v
+			}
+		}
+		stripe.mu.RUnlock()
+	}
+	return result
+}
+
+func (s *StripedStore) Count(target string) int {
+	count := 0
+	for i := range s.stripes {
+		stripe := &s.stripes[i]
+		stripe.mu.RLock()
+		count += len(stripe.data[target])
+		stripe.mu.RUnlock()
+	}
+	return count
+}
+
+func (s *StripedStore) Targets() []string {
+	seen := make(map[string]bool)
+	for i := range s.stripes {
+		stripe := &s.stripes[i]
+		stripe.mu.RLock()
+		for target, group := range stripe.data {
+			if len(group) > 0 {
+				seen[target] = true
+			}
+		}
+		stripe.mu.RUnlock()
+	}
+
+	targets := make([]string, 0, len(seen))
+	for target := range seen {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func (s *StripedStore) ForEach(fn func(target, key string, obj any) error) error {
+	for i := range s.stripes {
+		if err := s.stripes[i].forEach(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (st *storeStripe) forEach(fn func(target, key string, obj any) error) error {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	for target, group := range st.data {
+		for key, obj := range group {
+			if err := fn(target, key, obj); err != nil {
+				return fmt.Errorf("error processing %s[%s]: %w", target, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *StripedStore) ForEachSnapshot(fn func(target, key string, obj any) error) error {
+	var keys [][2]string
+	for i := range s.stripes {
+		stripe := &s.stripes[i]
+		stripe.mu.RLock()
+		for target, group := range stripe.data {
+			for key := range group {
+				keys = append(keys, [2]string{target, key})
+			}
+		}
+		stripe.mu.RUnlock()
+	}
+
+	for _, k := range keys {
+		obj, ok := s.Get(k[0], k[1])
+		if !ok {
+			continue
+		}
+		if err := fn(k[0], k[1], obj); err != nil {
+			return fmt.Errorf("error processing %s[%s]: %w", k[0], k[1], err)
+		}
+	}
+	return nil
+}
+
+func (s *StripedStore) Clear() {
+	for i := range s.stripes {
+		stripe := &s.stripes[i]
+		stripe.mu.Lock()
+		stripe.data = make(map[string]map[string]any)
+		stripe.mu.Unlock()
+	}
+}
+
+func (s *StripedStore) ClearTarget(target string) {
+	for i := range s.stripes {
+		stripe := &s.stripes[i]
+		stripe.mu.Lock()
+		delete(stripe.data, target)
+		stripe.mu.Unlock()
+	}
+}
+
+func (s *StripedStore) replaceTarget(target string, entities map[string]any, moved map[string]string) error {
+	groups := make([]map[string]any, len(s.stripes))
+	for key, obj := range entities {
+		i := s.index(target, key)
+		if groups[i] == nil {
+			groups[i] = make(map[string]any)
+		}
+		groups[i][key] = obj
+	}
+
+	for i := range s.stripes {
+		s.stripes[i].mu.Lock()
+	}
+	for i := range s.stripes {
+		stripe := &s.stripes[i]
+		if groups[i] == nil {
+			delete(stripe.data, target)
+		} else {
+			stripe.data[target] = groups[i]
+		}
+	}
+	for i := range s.stripes {
+		s.stripes[i].mu.Unlock()
+	}
+	return nil
+}
+
+func (s *StripedStore) Delete(target, key string) {
+	stripe := &s.stripes[s.index(target, key)]
+	stripe.mu.Lock()
+	defer stripe.mu.Unlock()
+
+	if group, ok := stripe.data[target]; ok {
+		delete(group, key)
+	}
+}
@@ -0,0 +1,87 @@
+package types
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+type counter struct {
+	N int
+}
+
+func TestStripedStoreConcurrentUpdates(t *testing.T) {
+	store := NewStripedStore(16)
+
+	const (
+		workers  = 8
+		entities = 32
+		rounds   = 200
+	)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				for e := 0; e < entities; e++ {
+					key := fmt.Sprintf("host:%d", e)
+					unlock := store.LockEntity("host", key)
+					obj := store.Upsert("host", key, func() any { return &counter{} })
+					obj.(*counter).N++
+					unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	all := store.GetAll("host")
+	if len(all) != entities {
+		t.Fatalf("expected %d entities, got %d", entities, len(all))
+	}
+	for key, obj := range all {
+		if n := obj.(*counter).N; n != workers*rounds {
+			t.Errorf("%s: expected %d updates, got %d", key, workers*rounds, n)
+		}
+	}
+}
+
+func BenchmarkStoreContention(b *testing.B) {
+	keys := make([]string, 256)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("host:%d", i)
+	}
+
+	b.Run("MapStore", func(b *testing.B) {
+		store := NewMapStore()
+		var mu sync.Mutex
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				key := keys[i%len(keys)]
+				mu.Lock()
+				obj := store.Upsert("host", key, func() any { return &counter{} })
+				obj.(*counter).N++
+				mu.Unlock()
+				i++
+			}
+		})
+	})
+
+	b.Run("StripedStore", func(b *testing.B) {
+		store := NewStripedStore(0)
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				key := keys[i%len(keys)]
+				unlock := store.LockEntity("host", key)
+				obj := store.Upsert("host", key, func() any { return &counter{} })
+				obj.(*counter).N++
+				unlock()
+				i++
+			}
+		})
+	})
+}
@@ -0,0 +1,78 @@
+package types
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type minimalStore struct {
+	Store
+}
+
+type deleteOnlyStore struct {
+	minimalStore
+}
+
+func (s deleteOnlyStore) Delete(target, key string) {
+	s.Store.(*MapStore).Delete(target, key)
+}
+
+func TestTargets(t *testing.T) {
+	stores := map[string]Store{
+		"map":         NewMapStore(),
+		"striped":     NewStripedStore(4),
+		"counting":    NewCountingStore(),
+		"tee":         NewTeeStore(NewMapStore(), NewCountingStore()),
+		"history":     NewHistoryStore(NewMapStore(), 4),
+		"delete only": deleteOnlyStore{minimalStore{NewMapStore()}},
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			if got := ListTargets(store); len(got) != 0 {
+				t.Errorf("empty store targets = %v", got)
+			}
+
+			store.Upsert("wifi", "1", func() any { return &counter{} })
+			store.Upsert("host", "1", func() any { return &counter{} })
+			store.Upsert("host", "2", func() any { return &counter{} })
+			store.Upsert("wan", "1", func() any { return &counter{} })
+			if err := DeleteEntity(store, "wan", "1"); err != nil {
+				t.Fatal(err)
+			}
+
+			want := []string{"host", "wifi"}
+			if got := ListTargets(store); !reflect.DeepEqual(got, want) {
+				t.Errorf("targets = %v, want %v", got, want)
+			}
+			if got := CountTarget(store, "host"); got != 2 {
+				t.Errorf("CountTarget = %d, want 2", got)
+			}
+
+			if err := ClearTarget(store, "host"); err != nil {
+				t.Fatal(err)
+			}
+			if got := ListTargets(store); !reflect.DeepEqual(got, []string{"wifi"}) {
+				t.Errorf("after ClearTarget targets = %v, want [wifi]", got)
+			}
+		})
+	}
+}
+
+func TestOptionalStoreMethodsUnsupported(t *testing.T) {
+	store := minimalStore{NewMapStore()}
+	store.Upsert("host", "1", func() any { return &counter{} })
+
+	if got := ListTargets(store); !reflect.DeepEqual(got, []string{"host"}) {
+		t.Errorf("targets = %v, want [host]", got)
+	}
+	if err := DeleteEntity(store, "host", "1"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("DeleteEntity err = %v, want ErrUnsupported", err)
+	}
+	if err := ClearTarget(store, "host"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("ClearTarget err = %v, want ErrUnsupported", err)
+	}
+	if _, ok := store.Get("host", "1"); !ok {
+		t.Error("entity removed by an unsupported operation")
+	}
+}
@@ -0,0 +1,108 @@
+package types
+
+import (
+	"errors"
+	"reflect"
+)
+
+type TeeStore struct {
+	primary Store
+	others  []Store
+}
+
+func NewTeeStore(primary Store, others ...Store) *TeeStore {
+	return &TeeStore{primary: primary, others: others}
+}
+
+func (s *TeeStore) Upsert(target, key string, factory func() any) any {
+	obj := s.primary.Upsert(target, key, factory)
+	if len(s.others) == 0 {
+		return obj
+	}
+	fresh := freshFactory(obj)
+	for _, other := range s.others {
+		other.Upsert(target, key, fresh)
+	}
+	return obj
+}
+
+func freshFactory(obj any) func() any {
+	t := reflect.TypeOf(obj)
+	if t == nil || t.Kind() != reflect.Pointer {
+		return func() any { return obj }
+	}
+	return func() any { return reflect.New(t.Elem()).Interface() }
+}
+
+func (s *TeeStore) Get(target, key string) (any, bool) {
+	return s.primary.Get(target, key)
+}
+
+func (s *TeeStore) GetAll(target string) map[string]any {
+	return s.primary.GetAll(target)
+}
+
+func (s *TeeStore) Count(target string) int {
+	return CountTarget(s.primary, target)
+}
+
+func (s *TeeStore) Targets() []string {
+	return ListTargets(s.primary)
+}
+
+func (s *TeeStore) ForEach(fn func(target, key string, obj any) error) error {
+	return s.primary.ForEach(fn)
+}
+
+func (s *TeeStore) ForEachSnapshot(fn func(target, key string, obj any) error) error {
+	return ForEachSnapshot(s.primary, fn)
+}
+
+func (s *TeeStore) Delete(target, key string) {
+	_ = s.deleteEntity(target, key)
+}
+
+func (s *TeeStore) deleteEntity(target, key string) error {
+	errs := []error{DeleteEntity(s.primary, target, key)}
+	for _, other := range s.others {
+		errs = append(errs, DeleteEntity(other, target, key))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *TeeStore) Clear() {
+	s.primary.Clear()
+	for _, other := range s.others {
+		other.Clear()
+	}
+}
+
+func (s *TeeStore) ClearTarget(target string) {
+	_ = s.clearTarget(target)
+}
+
+func (s *TeeStore) clearTarget(target string) error {
+	errs := []error{ClearTarget(s.primary, target)}
+	for _, other := range s.others {
+		errs = append(errs, ClearTarget(other, target))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *TeeStore) LockEntity(target, key string) func() {
+	if locker, ok := s.primary.(EntityLocker); ok {
+		return locker.LockEntity(target, key)
+	}
+	return func() {}
+}
+
+func (s *TeeStore) Record(target, key, field string, value any) {
+	if recorder, ok := s.primary.(FieldRecorder); ok {
+		recorder.Record(target, key, field, value)
+	}
+	for _, other := range s.others {
+		if recorder, ok := other.(FieldRecorder); ok {
+			recorder.Record(target, key, field, value)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+type upsertOnlyStore struct {
+	Store
+}
+
+func TestTeeStoreGivesSecondariesOwnObjects(t *testing.T) {
+	mirror := NewMapStore()
+	store := NewTeeStore(NewMapStore(), mirror)
+
+	calls := 0
+	pooled := &countedHost{}
+	obj := store.Upsert("host", "1", func() any {
+		calls++
+		return pooled
+	})
+	if obj != pooled {
+		t.Fatalf("primary object = %p, want the factory object %p", obj, pooled)
+	}
+	if calls != 1 {
+		t.Errorf("factory called %d times, want 1", calls)
+	}
+
+	copied, ok := mirror.Get("host", "1")
+	if !ok {
+		t.Fatal("secondary store has no entity")
+	}
+	if copied == obj {
+		t.Error("secondary store shares the primary object")
+	}
+	if _, ok := copied.(*countedHost); !ok {
+		t.Errorf("secondary object = %T, want *countedHost", copied)
+	}
+
+	if again := store.Upsert("host", "1", func() any { return &countedHost{} }); again != obj {
+		t.Error("Upsert did not return the existing primary object")
+	}
+}
+
+func TestTeeStorePropagatesSecondaryErrors(t *testing.T) {
+	primary := NewMapStore()
+	store := NewTeeStore(primary, upsertOnlyStore{NewMapStore()})
+	store.Upsert("host", "1", func() any { return &countedHost{} })
+
+	if err := DeleteEntity(store, "host", "1"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("DeleteEntity error = %v, want ErrUnsupported", err)
+	}
+	if _, ok := primary.Get("host", "1"); ok {
+		t.Error("primary entity was not deleted")
+	}
+
+	store.Upsert("host", "2", func() any { return &countedHost{} })
+	if err := ClearTarget(store, "host"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("ClearTarget error = %v, want ErrUnsupported", err)
+	}
+	if got := primary.GetAll("host"); len(got) != 0 {
+		t.Errorf("primary kept %d entities", len(got))
+	}
+
+	clean := NewTeeStore(NewMapStore(), NewCountingStore())
+	clean.Upsert("host", "1", func() any { return &countedHost{} })
+	if err := DeleteEntity(clean, "host", "1"); err != nil {
+		t.Errorf("DeleteEntity error = %v", err)
+	}
+	if err := ClearTarget(clean, "host"); err != nil {
+		t.Errorf("ClearTarget error = %v", err)
+	}
+}
@@ -1,26 +1,38 @@
 package types
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
 
 	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/interpreter"
 )
 
 type FieldMapping struct {
-	Name      string `yaml:"name"`
-	When      string `yaml:"when"`
-	Value     string `yaml:"value"`
-	FieldType string `yaml:"type,omitempty"`
+	Name       string   `yaml:"name"`
+	When       string   `yaml:"when"`
+	Value      string   `yaml:"value"`
+	FieldType  string   `yaml:"type,omitempty"`
+	Transforms []string `yaml:"transforms,omitempty"`
+}
+
+type DerivedField struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
 }
 
 type RuleConfig struct {
-	Name      string         `yaml:"name"`
-	Target    string         `yaml:"target"`
-	Route     string         `yaml:"route"`
-	EntityKey string         `yaml:"entity_key"`
-	Fields    []FieldMapping `yaml:"fields"`
+	Name      string            `yaml:"name"`
+	Target    string            `yaml:"target"`
+	Route     string            `yaml:"route"`
+	EntityKey string            `yaml:"entity_key"`
+	Priority  int               `yaml:"priority,omitempty"`
+	Metadata  map[string]string `yaml:"metadata,omitempty"`
+	Fields    []FieldMapping    `yaml:"fields"`
+	Derived   []DerivedField    `yaml:"derived,omitempty"`
 }
 
 type RulesConfig struct {
@@ -29,11 +41,18 @@ type RulesConfig struct {
 }
 
 type CompiledFieldRule struct {
-	Name      string
-	When      cel.Program
-	Value     cel.Program
-	FieldType reflect.Type
-	Setter    func(any, any) error
+	Name       string
+	When       cel.Program
+	Value      cel.Program
+	FieldType  reflect.Type
+	Setter     func(any, any) error
+	Transforms []string
+}
+
+type CompiledDerivedField struct {
+	Name   string
+	Value  cel.Program
+	Setter func(any, any) error
 }
 
 type CompiledRule struct {
@@ -42,13 +61,18 @@ type CompiledRule struct {
 	Route     cel.Program
 	EntityKey cel.Program
 	Fields    []CompiledFieldRule
+	Derived   []CompiledDerivedField
 	Factory   func() any
+	Metadata  map[string]string
+	Source    RuleConfig
 }
 
 type ProcessContext struct {
 	Path  string
 	Value string
 	Data  map[string]any
+
+	activation interpreter.Activation
 }
 
 func NewProcessContext(path, value string) *ProcessContext {
@@ -59,6 +83,40 @@ func NewProcessContext(path, value string) *ProcessContext {
 	}
 }
 
+var processContextPool = sync.Pool{
+	New: func() any {
+		return &ProcessContext{Data: make(map[string]any, 2)}
+	},
+}
+
+func AcquireProcessContext(path, value string) *ProcessContext {
+	ctx := processContextPool.Get().(*ProcessContext)
+	ctx.Reset(path, value)
+	return ctx
+}
+
+func ReleaseProcessContext(ctx *ProcessContext) {
+	clear(ctx.Data)
+	ctx.Path = ""
+	ctx.Value = ""
+	processContextPool.Put(ctx)
+}
+
+func (ctx *ProcessContext) Reset(path, value string) {
+	clear(ctx.Data)
+	ctx.Path = path
+	ctx.Value = value
+	ctx.Data["path"] = path
+	ctx.Data["value"] = value
+}
+
+func (ctx *ProcessContext) Activation() interpreter.Activation {
+	if ctx.activation == nil {
+		ctx.activation, _ = interpreter.NewActivation(ctx.Data)
+	}
+	return ctx.activation
+}
+
 func (ctx *ProcessContext) WithData(key string, value any) *ProcessContext {
 	ctx.Data[key] = value
 	return ctx
@@ -72,6 +130,112 @@ type Store interface {
 	Clear()
 }
 
+type Counter interface {
+	Count(target string) int
+}
+
+type TargetLister interface {
+	Targets() []string
+}
+
+type Deleter interface {
+	Delete(target, key string)
+}
+
+type TargetClearer interface {
+	ClearTarget(target string)
+}
+
+type Snapshotter interface {
+	ForEachSnapshot(fn func(target, key string, obj any) error) error
+}
+
+type checkedDeleter interface {
+	deleteEntity(target, key string) error
+}
+
+type checkedClearer interface {
+	clearTarget(target string) error
+}
+
+func CountTarget(store Store, target string) int {
+	if c, ok := store.(Counter); ok {
+		return c.Count(target)
+	}
+	return len(store.GetAll(target))
+}
+
+func ListTargets(store Store) []string {
+	if l, ok := store.(TargetLister); ok {
+		return l.Targets()
+	}
+	seen := make(map[string]bool)
+	store.ForEach(func(target, key string, obj any) error {
+		seen[target] = true
+		return nil
+	})
+	targets := make([]string, 0, len(seen))
+	for target := range seen {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func ForEachSnapshot(store Store, fn func(target, key string, obj any) error) error {
+	if s, ok := store.(Snapshotter); ok {
+		return s.ForEachSnapshot(fn)
+	}
+	for _, target := range ListTargets(store) {
+		all := store.GetAll(target)
+		keys := make([]string, 0, len(all))
+		for key := range all {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return NaturalLess(keys[i], keys[j]) })
+		for _, key := range keys {
+			obj, ok := store.Get(target, key)
+			if !ok {
+				continue
+			}
+			if err := fn(target, key, obj); err != nil {
+				return fmt.Errorf("error processing %s[%s]: %w", target, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+func DeleteEntity(store Store, target, key string) error {
+	if c, ok := store.(checkedDeleter); ok {
+		return c.deleteEntity(target, key)
+	}
+	d, ok := store.(Deleter)
+	if !ok {
+		return fmt.Errorf("store %T cannot delete entities: %w", store, errors.ErrUnsupported)
+	}
+	d.Delete(target, key)
+	return nil
+}
+
+func ClearTarget(store Store, target string) error {
+	if c, ok := store.(checkedClearer); ok {
+		return c.clearTarget(target)
+	}
+	if c, ok := store.(TargetClearer); ok {
+		c.ClearTarget(target)
+		return nil
+	}
+	d, ok := store.(Deleter)
+	if !ok {
+		return fmt.Errorf("store %T cannot clear target %s: %w", store, target, errors.ErrUnsupported)
+	}
+	for key := range store.GetAll(target) {
+		d.Delete(target, key)
+	}
+	return nil
+}
+
 type MapStore struct {
 	mu   sync.RWMutex
 	data map[string]map[string]any
@@ -128,6 +292,27 @@ func (s *MapStore) GetAll(target string) map[string]any {
 	return result
 }
 
+func (s *MapStore) Count(target string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.data[target])
+}
+
+func (s *MapStore) Targets() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	targets := make([]string, 0, len(s.data))
+	for target, group := range s.data {
+		if len(group) > 0 {
+			targets = append(targets, target)
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
+
 func (s *MapStore) ForEach(fn func(target, key string, obj any) error) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -142,9 +327,60 @@ func (s *MapStore) ForEach(fn func(target, key string, obj any) error) error {
 	return nil
 }
 
+func (s *MapStore) ForEachSnapshot(fn func(target, key string, obj any) error) error {
+	s.mu.RLock()
+	keys := make([][2]string, 0, len(s.data))
+	for target, group := range s.data {
+		for key := range group {
+			keys = append(keys, [2]string{target, key})
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, k := range keys {
+		obj, ok := s.Get(k[0], k[1])
+		if !ok {
+			continue
+		}
+		if err := fn(k[0], k[1], obj); err != nil {
+			return fmt.Errorf("error processing %s[%s]: %w", k[0], k[1], err)
+		}
+	}
+	return nil
+}
+
 func (s *MapStore) Clear() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	s.data = make(map[string]map[string]any)
 }
+
+func (s *MapStore) replaceTarget(target string, entities map[string]any, moved map[string]string) error {
+	group := make(map[string]any, len(entities))
+	for key, obj := range entities {
+		group[key] = obj
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[target] = group
+	return nil
+}
+
+func (s *MapStore) ClearTarget(target string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data, target)
+}
+
+func (s *MapStore) Delete(target, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if group, ok := s.data[target]; ok {
+		delete(group, key)
+	}
+}